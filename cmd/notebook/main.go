@@ -1,23 +1,46 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/Rasulikus/notebook/internal/app"
 	"github.com/Rasulikus/notebook/internal/config"
 )
 
+// shutdownTimeout is how long in-flight requests may take to finish on shutdown.
+const shutdownTimeout = 10 * time.Second
+
 func main() {
 	cfg := config.LoadConfig()
+
+	// cancelled on SIGINT/SIGTERM: stops the background workers and the server
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	server := http.Server{
 		Addr:    fmt.Sprintf("%s:%s", cfg.HTTP.Host, cfg.HTTP.Port),
-		Handler: app.App(cfg),
+		Handler: app.App(ctx, cfg),
 	}
 
-	log.Printf("Server start at addres: " + server.Addr)
-	if err := server.ListenAndServe(); err != nil {
-		log.Fatalf("listen: %v", err)
+	go func() {
+		log.Printf("Server start at addres: " + server.Addr)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("listen: %v", err)
+		}
+	}()
+
+	<-ctx.Done()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("shutdown: %v", err)
 	}
 }
@@ -0,0 +1,188 @@
+// Package handler - Gin HTTP handlers for sharing notes and tags with other users.
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Rasulikus/notebook/internal/api/middleware"
+	"github.com/Rasulikus/notebook/internal/model"
+	"github.com/Rasulikus/notebook/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// AccessHandler wires HTTP to ACLService.
+type AccessHandler struct {
+	s service.ACLService
+}
+
+// NewAccessHandler - constructor.
+func NewAccessHandler(s service.ACLService) *AccessHandler { return &AccessHandler{s: s} }
+
+// GrantResp - public shape of an access grant; exactly one of note_id and tag_id is set.
+type GrantResp struct {
+	ID           int64     `json:"id"`
+	NoteID       *int64    `json:"note_id"`
+	TagID        *int64    `json:"tag_id"`
+	Role         string    `json:"role"`
+	GranteeEmail string    `json:"grantee_email"`
+	GranteeName  string    `json:"grantee_name"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// toGrantResp - maps domain grant to API response.
+func toGrantResp(g *model.AccessGrant) GrantResp {
+	resp := GrantResp{
+		ID:           g.ID,
+		Role:         string(g.Role),
+		GranteeEmail: g.GranteeEmail,
+		GranteeName:  g.GranteeName,
+		CreatedAt:    g.CreatedAt,
+	}
+	if g.NoteID != 0 {
+		resp.NoteID = &g.NoteID
+	}
+	if g.TagID != 0 {
+		resp.TagID = &g.TagID
+	}
+	return resp
+}
+
+// toGrantsResp - maps slice of domain grants to []GrantResp.
+func toGrantsResp(gs []model.AccessGrant) []GrantResp {
+	out := make([]GrantResp, 0, len(gs))
+	for i := range gs {
+		out = append(out, toGrantResp(&gs[i]))
+	}
+	return out
+}
+
+// GrantReq request body for sharing with a registered user.
+// `viewer` reads the notes, `editor` also changes their title, text and checklists.
+type GrantReq struct {
+	Email string `json:"email" binding:"required,email"`
+	Role  string `json:"role" binding:"required,oneof=viewer editor"`
+}
+
+// bindGrant parses the id param and GrantReq, writing the error response on failure.
+func bindGrant(c *gin.Context) (int64, *GrantReq, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		status, pub := model.ToHTTP(model.ErrBadRequest)
+		c.AbortWithStatusJSON(status, pub)
+		return 0, nil, false
+	}
+	var req GrantReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if vErr, as := model.AsValidationError(req, err); as {
+			status, pub := model.ToHTTP(vErr)
+			c.AbortWithStatusJSON(status, pub)
+			return 0, nil, false
+		}
+		status, pub := model.ToHTTP(model.ErrBadRequest)
+		c.AbortWithStatusJSON(status, pub)
+		return 0, nil, false
+	}
+	return id, &req, true
+}
+
+// ShareNote (POST /notes/:id/grants) shares the note with a user; sharing again changes the role.
+// Only the owner may share (403 for others); 201 + GrantResp.
+func (h *AccessHandler) ShareNote(c *gin.Context) {
+	userID := middleware.CurrentUserID(c)
+	noteID, req, ok := bindGrant(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	grant, err := h.s.ShareNote(ctx, userID, noteID, req.Email, model.Role(req.Role))
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	c.JSON(http.StatusCreated, toGrantResp(grant))
+}
+
+// ShareTag (POST /tags/:id/grants) shares all notes with the tag, present and future, with a user;
+// global tags can't be shared (403); 201 + GrantResp.
+func (h *AccessHandler) ShareTag(c *gin.Context) {
+	userID := middleware.CurrentUserID(c)
+	tagID, req, ok := bindGrant(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	grant, err := h.s.ShareTag(ctx, userID, tagID, req.Email, model.Role(req.Role))
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	c.JSON(http.StatusCreated, toGrantResp(grant))
+}
+
+// ListNoteGrants (GET /notes/:id/grants) returns who the note is shared with; 200 + []GrantResp.
+func (h *AccessHandler) ListNoteGrants(c *gin.Context) {
+	userID := middleware.CurrentUserID(c)
+
+	noteID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		status, pub := model.ToHTTP(model.ErrBadRequest)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+
+	ctx := c.Request.Context()
+	grants, err := h.s.ListNoteGrants(ctx, userID, noteID)
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	c.JSON(http.StatusOK, toGrantsResp(grants))
+}
+
+// ListTagGrants (GET /tags/:id/grants) returns who the tag is shared with; 200 + []GrantResp.
+func (h *AccessHandler) ListTagGrants(c *gin.Context) {
+	userID := middleware.CurrentUserID(c)
+
+	tagID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		status, pub := model.ToHTTP(model.ErrBadRequest)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+
+	ctx := c.Request.Context()
+	grants, err := h.s.ListTagGrants(ctx, userID, tagID)
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	c.JSON(http.StatusOK, toGrantsResp(grants))
+}
+
+// Revoke (DELETE /grants/:id) takes back access given by the user; 204 No Content.
+func (h *AccessHandler) Revoke(c *gin.Context) {
+	userID := middleware.CurrentUserID(c)
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		status, pub := model.ToHTTP(model.ErrBadRequest)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := h.s.Revoke(ctx, userID, id); err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
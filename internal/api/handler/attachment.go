@@ -0,0 +1,250 @@
+// Package handler - Gin HTTP handlers for note attachments.
+package handler
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Rasulikus/notebook/internal/api/middleware"
+	"github.com/Rasulikus/notebook/internal/model"
+	"github.com/Rasulikus/notebook/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// multipartOverhead is the room left for multipart headers on top of the file size limit.
+const multipartOverhead = 1 << 20
+
+// AttachmentHandler wires HTTP to AttachmentService.
+type AttachmentHandler struct {
+	s       service.AttachmentService
+	maxSize int64 // largest accepted file; 0 - no limit
+}
+
+// NewAttachmentHandler - constructor.
+func NewAttachmentHandler(s service.AttachmentService, maxSize int64) *AttachmentHandler {
+	return &AttachmentHandler{s: s, maxSize: maxSize}
+}
+
+// AttachmentResp - public shape of an attachment; the content is at GET /attachments/:id.
+type AttachmentResp struct {
+	ID          int64     `json:"id"`
+	NoteID      int64     `json:"note_id"`
+	Name        string    `json:"name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	SHA256      string    `json:"sha256"`
+	ThumbStatus string    `json:"thumb_status"` // none | pending | ready | failed
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// toAttachmentResp - maps domain attachment to API response.
+func toAttachmentResp(a *model.Attachment) AttachmentResp {
+	return AttachmentResp{
+		ID:          a.ID,
+		NoteID:      a.NoteID,
+		Name:        a.Name,
+		ContentType: a.ContentType,
+		Size:        a.Size,
+		SHA256:      a.SHA256,
+		ThumbStatus: a.ThumbStatus,
+		CreatedAt:   a.CreatedAt,
+	}
+}
+
+// toAttachmentsResp - maps slice of domain attachments to []AttachmentResp.
+func toAttachmentsResp(as []model.Attachment) []AttachmentResp {
+	out := make([]AttachmentResp, 0, len(as))
+	for i := range as {
+		out = append(out, toAttachmentResp(&as[i]))
+	}
+	return out
+}
+
+// Upload (POST /notes/:id/attachments) attaches the multipart field `file` to the note; 201 + AttachmentResp.
+// Only images (PNG, JPEG, GIF, WebP) and PDFs are accepted, whatever the client claims;
+// 413 when the file or the user's storage quota is too small for it, 415 for other types.
+func (h *AttachmentHandler) Upload(c *gin.Context) {
+	userID := middleware.CurrentUserID(c)
+
+	noteID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		status, pub := model.ToHTTP(model.ErrBadRequest)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	if h.maxSize > 0 {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxSize+multipartOverhead)
+	}
+	fh, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			status, pub := model.ToHTTP(model.ErrTooLarge)
+			c.AbortWithStatusJSON(status, pub)
+			return
+		}
+		status, pub := model.ToHTTP(&model.ValidationError{Fields: map[string]string{"file": "required field"}})
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	f, err := fh.Open()
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	defer f.Close()
+
+	ctx := c.Request.Context()
+	att := model.Attachment{NoteID: noteID, UserID: userID, Name: fh.Filename, Size: fh.Size}
+	if err := h.s.Upload(ctx, &att, f); err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	c.JSON(http.StatusCreated, toAttachmentResp(&att))
+}
+
+// ListByNote (GET /notes/:id/attachments) returns attachments of the note; 200 + []AttachmentResp.
+func (h *AttachmentHandler) ListByNote(c *gin.Context) {
+	userID := middleware.CurrentUserID(c)
+
+	noteID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		status, pub := model.ToHTTP(model.ErrBadRequest)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+
+	ctx := c.Request.Context()
+	attachments, err := h.s.ListByNote(ctx, userID, noteID)
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	c.JSON(http.StatusOK, toAttachmentsResp(attachments))
+}
+
+// DownloadQuery query params for downloading an attachment.
+// `download=true` asks the browser to save the file instead of showing it.
+type DownloadQuery struct {
+	Download bool `form:"download"`
+}
+
+// Download (GET /attachments/:id) streams the attachment content; 200 + the file.
+func (h *AttachmentHandler) Download(c *gin.Context) {
+	userID := middleware.CurrentUserID(c)
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		status, pub := model.ToHTTP(model.ErrBadRequest)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	var q DownloadQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		status, pub := model.ToHTTP(model.ErrBadRequest)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+
+	ctx := c.Request.Context()
+	att, rc, err := h.s.Open(ctx, userID, id)
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	defer rc.Close()
+
+	disposition := "inline"
+	if q.Download {
+		disposition = "attachment"
+	}
+	c.DataFromReader(http.StatusOK, att.Size, att.ContentType, rc, map[string]string{
+		"Content-Disposition":    mime.FormatMediaType(disposition, map[string]string{"filename": att.Name}),
+		"X-Content-Type-Options": "nosniff",
+		"Cache-Control":          "private",
+	})
+}
+
+// ThumbQuery query params for an attachment thumbnail; `size` is one of the configured sizes in px.
+type ThumbQuery struct {
+	Size int `form:"size" binding:"required"`
+}
+
+// Thumb (GET /attachments/:id/thumb?size=256) returns a JPEG or PNG preview of an image attachment
+// that fits into a size x size square; 200 + the image with ETag, or 304 if If-None-Match matches.
+// Attachments other than JPEG, PNG and GIF images have no thumbnails (404).
+func (h *AttachmentHandler) Thumb(c *gin.Context) {
+	userID := middleware.CurrentUserID(c)
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		status, pub := model.ToHTTP(model.ErrBadRequest)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	var q ThumbQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		if vErr, as := model.AsValidationError(q, err); as {
+			status, pub := model.ToHTTP(vErr)
+			c.AbortWithStatusJSON(status, pub)
+			return
+		}
+		status, pub := model.ToHTTP(model.ErrBadRequest)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+
+	ctx := c.Request.Context()
+	thumb, err := h.s.Thumbnail(ctx, userID, id, q.Size)
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	// attachments never change, so neither do their thumbnails
+	tag := contentETag(thumb.SHA256)
+	c.Header("ETag", tag)
+	c.Header("Cache-Control", "private, max-age=31536000, immutable")
+	if notModifiedContent(c, tag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	rc, err := h.s.OpenThumbnail(ctx, thumb)
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	defer rc.Close()
+
+	c.DataFromReader(http.StatusOK, thumb.ByteSize, thumb.ContentType, rc, map[string]string{
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+// DeleteByID (DELETE /attachments/:id) removes the attachment; 204 No Content.
+func (h *AttachmentHandler) DeleteByID(c *gin.Context) {
+	userID := middleware.CurrentUserID(c)
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		status, pub := model.ToHTTP(model.ErrBadRequest)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := h.s.DeleteByID(ctx, userID, id); err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,47 @@
+// Package handler - Gin HTTP handlers for bulk note operations.
+package handler
+
+import (
+	"net/http"
+
+	"github.com/Rasulikus/notebook/internal/api/middleware"
+	"github.com/Rasulikus/notebook/internal/model"
+	"github.com/Rasulikus/notebook/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// BulkTagsReq request body for re-tagging many notes: tag IDs from `add` are attached
+// to every note from `note_ids`, tag IDs from `remove` are detached.
+type BulkTagsReq struct {
+	NoteIDs []int64 `json:"note_ids" binding:"required,min=1,max=100,dive,min=1"`
+	Add     []int64 `json:"add" binding:"max=50,dive,min=1"`
+	Remove  []int64 `json:"remove" binding:"max=50,dive,min=1"`
+}
+
+// UpdateTags (POST /notes/bulk/tags) adds and removes tags on many notes at once;
+// 200 + []NoteResp of the updated notes.
+func (h *NoteHandler) UpdateTags(c *gin.Context) {
+	userID := middleware.CurrentUserID(c)
+
+	var req BulkTagsReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if vErr, as := model.AsValidationError(req, err); as {
+			status, pub := model.ToHTTP(vErr)
+			c.AbortWithStatusJSON(status, pub)
+			return
+		}
+		status, pub := model.ToHTTP(model.ErrBadRequest)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+
+	ctx := c.Request.Context()
+	r := &service.UpdateTagsNoteReq{NoteIDs: req.NoteIDs, Add: req.Add, Remove: req.Remove}
+	notes, err := h.s.UpdateTags(ctx, userID, r)
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	c.JSON(http.StatusOK, toNotesResp(notes))
+}
@@ -0,0 +1,103 @@
+// Package handler — ETag helpers for conditional requests on versioned resources.
+package handler
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/Rasulikus/notebook/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+// etag formats a resource version as a strong entity tag: 3 -> "3".
+func etag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// parseETagList splits an If-Match / If-None-Match header into versions.
+// wildcard is true for "*". Weak tags (W/"3") are accepted as their strong value.
+func parseETagList(header string) (versions []int64, wildcard bool, err error) {
+	for _, part := range strings.Split(header, ",") {
+		tag := strings.TrimSpace(part)
+		if tag == "" {
+			continue
+		}
+		if tag == "*" {
+			wildcard = true
+			continue
+		}
+		tag = strings.TrimPrefix(tag, "W/")
+		if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+			return nil, false, model.ErrBadRequest
+		}
+		v, err := strconv.ParseInt(tag[1:len(tag)-1], 10, 64)
+		if err != nil || v <= 0 {
+			// a tag we never issued can't match any version
+			v = -1
+		}
+		versions = append(versions, v)
+	}
+	return versions, wildcard, nil
+}
+
+// ifMatchVersion returns the version required by the If-Match header; 0 means no condition.
+// Several tags are not supported since only one version can be checked atomically;
+// a tag that was never issued yields ErrPreconditionFailed right away.
+func ifMatchVersion(c *gin.Context) (int64, error) {
+	header := c.GetHeader("If-Match")
+	if header == "" {
+		return 0, nil
+	}
+	versions, wildcard, err := parseETagList(header)
+	if err != nil {
+		return 0, err
+	}
+	if wildcard {
+		return 0, nil
+	}
+	if len(versions) != 1 {
+		return 0, model.ErrBadRequest
+	}
+	if versions[0] < 0 {
+		return 0, model.ErrPreconditionFailed
+	}
+	return versions[0], nil
+}
+
+// notModified reports whether the If-None-Match header matches the current version.
+func notModified(c *gin.Context, version int64) bool {
+	header := c.GetHeader("If-None-Match")
+	if header == "" {
+		return false
+	}
+	versions, wildcard, err := parseETagList(header)
+	if err != nil {
+		return false
+	}
+	if wildcard {
+		return true
+	}
+	for _, v := range versions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+// contentETag formats a content digest as a strong entity tag.
+func contentETag(digest string) string {
+	return `"` + digest + `"`
+}
+
+// notModifiedContent reports whether the If-None-Match header matches the content entity tag.
+func notModifiedContent(c *gin.Context, tag string) bool {
+	header := c.GetHeader("If-None-Match")
+	for _, part := range strings.Split(header, ",") {
+		part = strings.TrimPrefix(strings.TrimSpace(part), "W/")
+		if part == "*" || part == tag {
+			return true
+		}
+	}
+	return false
+}
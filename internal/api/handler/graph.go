@@ -0,0 +1,145 @@
+// Package handler - Gin HTTP handlers for the note graph.
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Rasulikus/notebook/internal/api/middleware"
+	"github.com/Rasulikus/notebook/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+// GraphNodeResp - a note or a tag; id is "note:<id>" or "tag:<id>", so both kinds share one id space.
+type GraphNodeResp struct {
+	ID    string `json:"id"`
+	Type  string `json:"type"` // note | tag
+	RefID int64  `json:"ref_id"`
+	Label string `json:"label"` // note title or tag path
+}
+
+// GraphEdgeResp - a note-tag edge ("tag", with the tag source) or a note-note link ("link", with its ref).
+type GraphEdgeResp struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Type   string `json:"type"`            // tag | link
+	Label  string `json:"label,omitempty"` // tag source or link ref
+}
+
+// GraphResp - public shape of the note graph.
+type GraphResp struct {
+	Nodes []GraphNodeResp `json:"nodes"`
+	Edges []GraphEdgeResp `json:"edges"`
+}
+
+func noteNodeID(id int64) string { return fmt.Sprintf("note:%d", id) }
+func tagNodeID(id int64) string  { return fmt.Sprintf("tag:%d", id) }
+
+// toGraphResp - maps domain graph to nodes and edges.
+func toGraphResp(g *model.NoteGraph) GraphResp {
+	resp := GraphResp{
+		Nodes: make([]GraphNodeResp, 0, len(g.Notes)+len(g.Tags)),
+		Edges: make([]GraphEdgeResp, 0, len(g.NoteTags)+len(g.Links)),
+	}
+	for _, n := range g.Notes {
+		resp.Nodes = append(resp.Nodes, GraphNodeResp{ID: noteNodeID(n.ID), Type: "note", RefID: n.ID, Label: n.Title})
+	}
+	for _, t := range g.Tags {
+		resp.Nodes = append(resp.Nodes, GraphNodeResp{ID: tagNodeID(t.ID), Type: "tag", RefID: t.ID, Label: t.Path})
+	}
+	for _, nt := range g.NoteTags {
+		resp.Edges = append(resp.Edges, GraphEdgeResp{Source: noteNodeID(nt.NoteID), Target: tagNodeID(nt.TagID), Type: "tag", Label: nt.Source})
+	}
+	for _, l := range g.Links {
+		resp.Edges = append(resp.Edges, GraphEdgeResp{Source: noteNodeID(l.SourceID), Target: noteNodeID(l.TargetID), Type: "link", Label: l.Ref})
+	}
+	return resp
+}
+
+// toDOT - renders the graph in GraphViz DOT: notes are boxes, tags are ellipses,
+// tag edges are dashed.
+func toDOT(g GraphResp) string {
+	var b strings.Builder
+	b.WriteString("digraph notes {\n")
+	for _, n := range g.Nodes {
+		shape := "box"
+		if n.Type == "tag" {
+			shape = "ellipse"
+		}
+		fmt.Fprintf(&b, "  %s [label=%s, shape=%s];\n", dotQuote(n.ID), dotQuote(n.Label), shape)
+	}
+	for _, e := range g.Edges {
+		style := "solid"
+		if e.Type == "tag" {
+			style = "dashed"
+		}
+		fmt.Fprintf(&b, "  %s -> %s [style=%s];\n", dotQuote(e.Source), dotQuote(e.Target), style)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// dotQuote - makes a DOT quoted string, escaping quotes, backslashes and line breaks.
+func dotQuote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\r", "", "\n", `\n`)
+	return `"` + r.Replace(s) + `"`
+}
+
+// GraphQuery query params for the graph.
+// `format` is json (default) or dot; `tags` are comma-separated tag IDs,
+// `tag_descendants=true` also matches their descendants; `notebook_id` with `recursive` as in GET /notes.
+// `limit` caps the number of notes, 500 by default and 2000 at most.
+type GraphQuery struct {
+	Format         string `form:"format" binding:"omitempty,oneof=json dot"`
+	Limit          int    `form:"limit"`
+	Tags           string `form:"tags"`
+	TagDescendants bool   `form:"tag_descendants"`
+	NotebookID     int64  `form:"notebook_id" binding:"omitempty,min=1"`
+	Recursive      bool   `form:"recursive"`
+}
+
+// Graph (GET /graph) returns user's notes and their tags as nodes, note-tag and note-note links
+// as edges; 200 + GraphResp, or text/vnd.graphviz with format=dot.
+func (h *NoteHandler) Graph(c *gin.Context) {
+	userID := middleware.CurrentUserID(c)
+
+	var q GraphQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		if vErr, as := model.AsValidationError(q, err); as {
+			status, pub := model.ToHTTP(vErr)
+			c.AbortWithStatusJSON(status, pub)
+			return
+		}
+		status, pub := model.ToHTTP(model.ErrBadRequest)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	tagIDs, err := parseIDList(q.Tags)
+	if err != nil {
+		status, pub := model.ToHTTP(&model.ValidationError{Fields: map[string]string{"tags": err.Error()}})
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+
+	ctx := c.Request.Context()
+	filter := &model.NoteFilter{
+		Limit:          q.Limit,
+		NotebookID:     q.NotebookID,
+		Recursive:      q.Recursive,
+		TagIDs:         tagIDs,
+		TagDescendants: q.TagDescendants,
+	}
+	graph, err := h.s.Graph(ctx, userID, filter)
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	resp := toGraphResp(graph)
+	if q.Format == "dot" {
+		c.Data(http.StatusOK, "text/vnd.graphviz; charset=utf-8", []byte(toDOT(resp)))
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,93 @@
+// Package handler - Gin HTTP handlers for links between notes.
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Rasulikus/notebook/internal/api/middleware"
+	"github.com/Rasulikus/notebook/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+// LinkResp - a [[...]] link from a note; target is null for dangling links.
+type LinkResp struct {
+	Ref         string  `json:"ref"`
+	TargetID    *int64  `json:"target_id"`
+	TargetTitle *string `json:"target_title"`
+	Dangling    bool    `json:"dangling"`
+}
+
+// toLinksResp - maps links of a note to []LinkResp.
+func toLinksResp(links []model.NoteLink) []LinkResp {
+	out := make([]LinkResp, 0, len(links))
+	for i := range links {
+		l := &links[i]
+		resp := LinkResp{Ref: l.Ref, Dangling: l.Dangling()}
+		if !l.Dangling() {
+			resp.TargetID = &l.TargetID
+			resp.TargetTitle = &l.TargetTitle
+		}
+		out = append(out, resp)
+	}
+	return out
+}
+
+// BacklinkResp - a note that links to the requested one and the reference it uses.
+type BacklinkResp struct {
+	NoteID int64  `json:"note_id"`
+	Title  string `json:"title"`
+	Ref    string `json:"ref"`
+}
+
+// toBacklinksResp - maps backlinks to []BacklinkResp.
+func toBacklinksResp(links []model.NoteLink) []BacklinkResp {
+	out := make([]BacklinkResp, 0, len(links))
+	for _, l := range links {
+		out = append(out, BacklinkResp{NoteID: l.SourceID, Title: l.SourceTitle, Ref: l.Ref})
+	}
+	return out
+}
+
+// ListLinks (GET /notes/:id/links) returns links from the note ordered by ref,
+// dangling ones included; 200 + []LinkResp.
+func (h *NoteHandler) ListLinks(c *gin.Context) {
+	userID := middleware.CurrentUserID(c)
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		status, pub := model.ToHTTP(model.ErrBadRequest)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+
+	ctx := c.Request.Context()
+	links, err := h.s.ListLinks(ctx, userID, id)
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	c.JSON(http.StatusOK, toLinksResp(links))
+}
+
+// ListBacklinks (GET /notes/:id/backlinks) returns notes linking to the note; 200 + []BacklinkResp.
+func (h *NoteHandler) ListBacklinks(c *gin.Context) {
+	userID := middleware.CurrentUserID(c)
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		status, pub := model.ToHTTP(model.ErrBadRequest)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+
+	ctx := c.Request.Context()
+	links, err := h.s.ListBacklinks(ctx, userID, id)
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	c.JSON(http.StatusOK, toBacklinksResp(links))
+}
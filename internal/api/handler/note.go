@@ -2,11 +2,14 @@
 package handler
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Rasulikus/notebook/internal/api/middleware"
+	"github.com/Rasulikus/notebook/internal/markup"
 	"github.com/Rasulikus/notebook/internal/model"
 	"github.com/Rasulikus/notebook/internal/service"
 	"github.com/gin-gonic/gin"
@@ -21,30 +24,62 @@ func NewNoteHandler(s service.NoteService) *NoteHandler { return &NoteHandler{s:
 
 // NoteResp - public shape returned by the API.
 type NoteResp struct {
-	ID        int64     `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Title     string    `json:"title"`
-	Text      string    `json:"text"`
-	Tags      []string  `json:"tags"` // from n.Tags[i].Name
-	UserID    int64     `json:"user_id"`
+	ID          int64      `json:"id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	Version     int64      `json:"version"`
+	Title       string     `json:"title"`
+	Text        string     `json:"text"`
+	Pinned      bool       `json:"pinned"`
+	Archived    bool       `json:"archived"`
+	Favorite    bool       `json:"favorite"`
+	AutoTags    bool       `json:"auto_tags"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"` // only for notes in trash
+	Tags        []string   `json:"tags"`                 // from n.Tags[i].Name
+	Extracted   []string   `json:"extracted_tags"`       // part of Tags extracted from #hashtags, the rest are manual
+	NotebookID  *int64     `json:"notebook_id"`          // null if the note is not in a notebook
+	UserID      int64      `json:"user_id"`              // the author for notes of a workspace
+	WorkspaceID *int64     `json:"workspace_id"`         // null for personal notes
+	Snippet     string     `json:"snippet,omitempty"`    // only in search results
+
+	Rendered *RenderResp `json:"rendered,omitempty"` // only with format=html
 }
 
 // toNoteResp - maps domain note to API response.
 func toNoteResp(n *model.Note) NoteResp {
 	tags := make([]string, 0, len(n.Tags))
+	extracted := []string{}
 	for _, tag := range n.Tags {
 		tags = append(tags, tag.Name)
+		if tag.Source == model.TagSourceAuto {
+			extracted = append(extracted, tag.Name)
+		}
 	}
-	return NoteResp{
+	resp := NoteResp{
 		ID:        n.ID,
 		CreatedAt: n.CreatedAt,
 		UpdatedAt: n.UpdatedAt,
+		Version:   n.Version,
 		Title:     n.Title,
 		Text:      n.Text,
+		Pinned:    n.Pinned,
+		Archived:  n.Archived,
+		Favorite:  n.Favorite,
+		AutoTags:  n.AutoTags,
 		Tags:      tags,
+		Extracted: extracted,
 		UserID:    n.UserID,
 	}
+	if !n.DeletedAt.IsZero() {
+		resp.DeletedAt = &n.DeletedAt
+	}
+	if n.NotebookID != 0 {
+		resp.NotebookID = &n.NotebookID
+	}
+	if n.WorkspaceID != 0 {
+		resp.WorkspaceID = &n.WorkspaceID
+	}
+	return resp
 }
 
 // toNotesResp - maps slice of domain notes to []NoteResp.
@@ -57,11 +92,18 @@ func toNotesResp(ns []model.Note) []NoteResp {
 }
 
 // CreateNoteReq request body for note creation.
-// Only `title` is required. `text` and `tags` (IDs) are optional.
+// Only `title` is required. `text`, `tags` (IDs), `tag_names` and `notebook_id` are optional.
+// `tag_names` are tag names or paths like "work/acme"; missing root tags are created
+// with the same name rules as POST /tags (3 to 50 characters).
+// `auto_tags=true` also attaches tags for #hashtags in the text and keeps them in sync on updates;
+// hashtags are matched to tags ignoring case, and ones that break the tag name rules (e.g. "#go") are skipped.
 type CreateNoteReq struct {
-	Title string  `json:"title" binding:"required,min=3,max=100"`
-	Text  string  `json:"text"`
-	Tags  []int64 `json:"tags"`
+	Title      string   `json:"title" binding:"required,min=3,max=100"`
+	Text       string   `json:"text"`
+	Tags       []int64  `json:"tags"`
+	TagNames   []string `json:"tag_names" binding:"max=50,dive,min=3,max=255"`
+	NotebookID int64    `json:"notebook_id" binding:"omitempty,min=1"`
+	AutoTags   bool     `json:"auto_tags"`
 }
 
 // Create (POST /notes) creates a note for current user; 201 + NoteResp.
@@ -81,8 +123,8 @@ func (h *NoteHandler) Create(c *gin.Context) {
 	}
 
 	ctx := c.Request.Context()
-	n := model.Note{Title: req.Title, Text: req.Text, UserID: userID}
-	newNote, err := h.s.Create(ctx, &n, req.Tags)
+	n := model.Note{Title: req.Title, Text: req.Text, NotebookID: req.NotebookID, AutoTags: req.AutoTags, UserID: userID}
+	newNote, err := h.s.Create(ctx, &n, req.Tags, req.TagNames)
 	if err != nil {
 		status, pub := model.ToHTTP(err)
 		c.AbortWithStatusJSON(status, pub)
@@ -93,34 +135,216 @@ func (h *NoteHandler) Create(c *gin.Context) {
 
 // NoteListQuery query params for listing notes.
 // All query params are optional; if omitted, defaults are used by the service.
+// `sort` is a comma-separated list of fields from model.NoteSortFields, "-" prefix
+// means descending, e.g. "-updated_at,title".
+// `cursor` is the `next_cursor` of the previous page; `tags` and `exclude_tags` are comma-separated tag IDs, dates are RFC 3339.
+// `archived` defaults to false, so archived notes are hidden unless asked for; by default pinned notes go first.
+// `notebook_id` limits notes to a notebook, `recursive=true` includes its sub-notebooks.
+// `tag_descendants=true` makes `tags` and `exclude_tags` also match descendants of the given tags.
 type NoteListQuery struct {
-	Limit  int    `form:"limit"`
-	Offset int    `form:"offset"`
-	Order  string `form:"order"`
+	Limit          int       `form:"limit"`
+	Offset         int       `form:"offset"`
+	Sort           string    `form:"sort"`
+	Cursor         string    `form:"cursor"`
+	Pinned         *bool     `form:"pinned"`
+	Archived       *bool     `form:"archived"`
+	Favorite       *bool     `form:"favorite"`
+	NotebookID     int64     `form:"notebook_id" binding:"omitempty,min=1"`
+	Recursive      bool      `form:"recursive"`
+	Tags           string    `form:"tags"`
+	TagMode        string    `form:"tag_mode" binding:"omitempty,oneof=any all"`
+	ExcludeTags    string    `form:"exclude_tags"`
+	TagDescendants bool      `form:"tag_descendants"`
+	CreatedAfter   time.Time `form:"created_after"`
+	CreatedBefore  time.Time `form:"created_before"`
+	UpdatedAfter   time.Time `form:"updated_after"`
+	UpdatedBefore  time.Time `form:"updated_before"`
+}
+
+// toNoteFilter - maps list query params to the domain filter.
+func (q *NoteListQuery) toNoteFilter() (*model.NoteFilter, error) {
+	tagIDs, err := parseIDList(q.Tags)
+	if err != nil {
+		return nil, &model.ValidationError{Fields: map[string]string{"tags": err.Error()}}
+	}
+	excludeTagIDs, err := parseIDList(q.ExcludeTags)
+	if err != nil {
+		return nil, &model.ValidationError{Fields: map[string]string{"exclude_tags": err.Error()}}
+	}
+	sort, err := model.ParseSort(q.Sort, model.NoteSortFields)
+	if err != nil {
+		return nil, err
+	}
+	return &model.NoteFilter{
+		Limit:          q.Limit,
+		Offset:         q.Offset,
+		Sort:           sort,
+		Cursor:         q.Cursor,
+		Pinned:         q.Pinned,
+		Archived:       q.Archived,
+		Favorite:       q.Favorite,
+		NotebookID:     q.NotebookID,
+		Recursive:      q.Recursive,
+		TagIDs:         tagIDs,
+		TagMode:        model.TagMatchMode(q.TagMode),
+		ExcludeTagIDs:  excludeTagIDs,
+		TagDescendants: q.TagDescendants,
+		CreatedAfter:   q.CreatedAfter,
+		CreatedBefore:  q.CreatedBefore,
+		UpdatedAfter:   q.UpdatedAfter,
+		UpdatedBefore:  q.UpdatedBefore,
+	}, nil
 }
 
-// List (GET /notes) returns user's notes with pagination/sorting; 200 + []NoteResp.
+// parseIDList parses comma-separated positive IDs like "1,4,7"; empty input gives nil.
+func parseIDList(raw string) ([]int64, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	parts := strings.Split(raw, ",")
+	ids := make([]int64, 0, len(parts))
+	for _, p := range parts {
+		id, err := strconv.ParseInt(strings.TrimSpace(p), 10, 64)
+		if err != nil || id <= 0 {
+			return nil, fmt.Errorf("invalid id %q", p)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// List (GET /notes) returns user's notes with filtering by tags and dates,
+// offset or cursor pagination and sorting; 200 + PageResp[NoteResp].
 func (h *NoteHandler) List(c *gin.Context) {
 	userID := middleware.CurrentUserID(c)
 
 	var q NoteListQuery
 	if err := c.ShouldBindQuery(&q); err != nil {
+		if vErr, as := model.AsValidationError(q, err); as {
+			status, pub := model.ToHTTP(vErr)
+			c.AbortWithStatusJSON(status, pub)
+			return
+		}
 		status, pub := model.ToHTTP(model.ErrBadRequest)
 		c.AbortWithStatusJSON(status, pub)
 		return
 	}
+	filter, err := q.toNoteFilter()
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
 
 	ctx := c.Request.Context()
-	notes, err := h.s.List(ctx, userID, q.Limit, q.Offset, q.Order)
+	page, err := h.s.List(ctx, userID, filter)
 	if err != nil {
 		status, pub := model.ToHTTP(err)
 		c.AbortWithStatusJSON(status, pub)
 		return
 	}
-	c.JSON(http.StatusOK, toNotesResp(notes))
+	c.JSON(http.StatusOK, toPageResp(page, toNotesResp))
 }
 
-// GetByID (GET /notes/:id) returns a single note by id for current user; 200 + NoteResp.
+// NoteSearchQuery query params for full-text search.
+// `q` is required; `limit` and `offset` fall back to service defaults.
+type NoteSearchQuery struct {
+	Q      string `form:"q" binding:"required,max=200"`
+	Limit  int    `form:"limit"`
+	Offset int    `form:"offset"`
+}
+
+// Search (GET /notes/search) finds user's notes by title and text;
+// 200 + []NoteResp ordered by rank, each with a highlighted snippet.
+func (h *NoteHandler) Search(c *gin.Context) {
+	userID := middleware.CurrentUserID(c)
+
+	var q NoteSearchQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		if vErr, as := model.AsValidationError(q, err); as {
+			status, pub := model.ToHTTP(vErr)
+			c.AbortWithStatusJSON(status, pub)
+			return
+		}
+		status, pub := model.ToHTTP(model.ErrBadRequest)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+
+	ctx := c.Request.Context()
+	hits, err := h.s.Search(ctx, userID, q.Q, q.Limit, q.Offset)
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	out := make([]NoteResp, 0, len(hits))
+	for _, hit := range hits {
+		resp := toNoteResp(hit.Note)
+		resp.Snippet = hit.Snippet
+		out = append(out, resp)
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+// SharedWithMeResp - a note of another user with the role the current user has on it.
+type SharedWithMeResp struct {
+	NoteResp
+	Role       string `json:"role"`
+	OwnerEmail string `json:"owner_email"`
+	OwnerName  string `json:"owner_name"`
+}
+
+// SharedWithMeQuery query params for listing notes shared with the user.
+type SharedWithMeQuery struct {
+	Limit  int `form:"limit"`
+	Offset int `form:"offset"`
+}
+
+// ListSharedWithMe (GET /notes/shared-with-me) returns notes other users shared with the user,
+// recently updated first; 200 + []SharedWithMeResp.
+func (h *NoteHandler) ListSharedWithMe(c *gin.Context) {
+	userID := middleware.CurrentUserID(c)
+
+	var q SharedWithMeQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		if vErr, as := model.AsValidationError(q, err); as {
+			status, pub := model.ToHTTP(vErr)
+			c.AbortWithStatusJSON(status, pub)
+			return
+		}
+		status, pub := model.ToHTTP(model.ErrBadRequest)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+
+	ctx := c.Request.Context()
+	shared, err := h.s.ListSharedWithMe(ctx, userID, q.Limit, q.Offset)
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	out := make([]SharedWithMeResp, 0, len(shared))
+	for _, sn := range shared {
+		out = append(out, SharedWithMeResp{
+			NoteResp:   toNoteResp(sn.Note),
+			Role:       string(sn.Role),
+			OwnerEmail: sn.OwnerEmail,
+			OwnerName:  sn.OwnerName,
+		})
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+// NoteGetQuery query params for loading a note.
+// `format=html` adds the text rendered to sanitized HTML with a table of contents.
+type NoteGetQuery struct {
+	Format string `form:"format" binding:"omitempty,oneof=text html"`
+}
+
+// GetByID (GET /notes/:id) returns a single note by id for current user; 200 + NoteResp with ETag,
+// or 304 if If-None-Match matches the current version.
 func (h *NoteHandler) GetByID(c *gin.Context) {
 	userID := middleware.CurrentUserID(c)
 
@@ -130,6 +354,17 @@ func (h *NoteHandler) GetByID(c *gin.Context) {
 		c.AbortWithStatusJSON(status, pub)
 		return
 	}
+	var q NoteGetQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		if vErr, as := model.AsValidationError(q, err); as {
+			status, pub := model.ToHTTP(vErr)
+			c.AbortWithStatusJSON(status, pub)
+			return
+		}
+		status, pub := model.ToHTTP(model.ErrBadRequest)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
 
 	ctx := c.Request.Context()
 	note, err := h.s.GetByID(ctx, userID, id)
@@ -138,17 +373,43 @@ func (h *NoteHandler) GetByID(c *gin.Context) {
 		c.AbortWithStatusJSON(status, pub)
 		return
 	}
-	c.JSON(http.StatusOK, toNoteResp(note))
+	c.Header("ETag", etag(note.Version))
+	if notModified(c, note.Version) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	resp := toNoteResp(note)
+	if q.Format == "html" {
+		rendered, err := markup.Render(note.Text)
+		if err != nil {
+			status, pub := model.ToHTTP(err)
+			c.AbortWithStatusJSON(status, pub)
+			return
+		}
+		r := toRenderResp(rendered)
+		resp.Rendered = &r
+	}
+	c.JSON(http.StatusOK, resp)
 }
 
 // UpdateByIDNoteReq request body for partial update (nil fields are ignored).
+// `notebook_id` moves the note to another notebook, 0 takes it out of its notebook.
+// `tags` and `tag_names` together replace manually attached tags of the note; missing tags by name are created.
+// `auto_tags` turns syncing of tags with #hashtags in the text on or off; when turned off, extracted tags stay as manual ones.
+// Hashtags shorter than 3 or longer than 50 characters make no tags.
 type UpdateByIDNoteReq struct {
-	Title   *string  `json:"title" binding:"omitempty,min=1,max=100"`
-	Text    *string  `json:"text"  binding:"omitempty,max=20000"`
-	TagsIDs *[]int64 `json:"tags"`
+	Title      *string   `json:"title" binding:"omitempty,min=1,max=100"`
+	Text       *string   `json:"text"  binding:"omitempty,max=20000"`
+	TagsIDs    *[]int64  `json:"tags"`
+	TagNames   *[]string `json:"tag_names" binding:"omitempty,max=50,dive,min=3,max=255"`
+	Pinned     *bool     `json:"pinned"`
+	Archived   *bool     `json:"archived"`
+	Favorite   *bool     `json:"favorite"`
+	NotebookID *int64    `json:"notebook_id" binding:"omitempty,min=0"`
+	AutoTags   *bool     `json:"auto_tags"`
 }
 
-// UpdateByID (PATCH /notes/:id) partial update of a note; 200 + NoteResp.
+// UpdateByID (PATCH /notes/:id) partial update of a note, honoring If-Match; 200 + NoteResp with ETag.
 func (h *NoteHandler) UpdateByID(c *gin.Context) {
 	userID := middleware.CurrentUserID(c)
 
@@ -159,6 +420,13 @@ func (h *NoteHandler) UpdateByID(c *gin.Context) {
 		return
 	}
 
+	ifVersion, err := ifMatchVersion(c)
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+
 	var req UpdateByIDNoteReq
 	if err := c.ShouldBindJSON(&req); err != nil {
 		if vErr, as := model.AsValidationError(req, err); as {
@@ -173,9 +441,16 @@ func (h *NoteHandler) UpdateByID(c *gin.Context) {
 
 	ctx := c.Request.Context()
 	r := &service.UpdateByIDNoteReq{
-		Title:   req.Title,
-		Text:    req.Text,
-		TagsIDs: req.TagsIDs,
+		Title:      req.Title,
+		Text:       req.Text,
+		TagsIDs:    req.TagsIDs,
+		TagNames:   req.TagNames,
+		Pinned:     req.Pinned,
+		Archived:   req.Archived,
+		Favorite:   req.Favorite,
+		NotebookID: req.NotebookID,
+		AutoTags:   req.AutoTags,
+		IfVersion:  ifVersion,
 	}
 	note, err := h.s.UpdateByID(ctx, userID, id, r)
 	if err != nil {
@@ -183,11 +458,12 @@ func (h *NoteHandler) UpdateByID(c *gin.Context) {
 		c.AbortWithStatusJSON(status, pub)
 		return
 	}
+	c.Header("ETag", etag(note.Version))
 
 	c.JSON(http.StatusOK, toNoteResp(note))
 }
 
-// DeleteByID (DELETE /notes/:id) deletes a note; 204 No Content.
+// DeleteByID (DELETE /notes/:id) moves a note to trash, honoring If-Match; 204 No Content.
 func (h *NoteHandler) DeleteByID(c *gin.Context) {
 	userID := middleware.CurrentUserID(c)
 
@@ -198,8 +474,15 @@ func (h *NoteHandler) DeleteByID(c *gin.Context) {
 		return
 	}
 
+	ifVersion, err := ifMatchVersion(c)
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+
 	ctx := c.Request.Context()
-	if err := h.s.DeleteByID(ctx, userID, id); err != nil {
+	if err := h.s.DeleteByID(ctx, userID, id, ifVersion); err != nil {
 		status, pub := model.ToHTTP(err)
 		c.AbortWithStatusJSON(status, pub)
 		return
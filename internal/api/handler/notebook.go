@@ -0,0 +1,212 @@
+// Package handler - Gin HTTP handlers for Notebook CRUD.
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Rasulikus/notebook/internal/api/middleware"
+	"github.com/Rasulikus/notebook/internal/model"
+	"github.com/Rasulikus/notebook/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// NotebookHandler wires HTTP to NotebookService.
+type NotebookHandler struct {
+	s service.NotebookService
+}
+
+// NewNotebookHandler - constructor.
+func NewNotebookHandler(s service.NotebookService) *NotebookHandler { return &NotebookHandler{s: s} }
+
+// NotebookResp - public shape returned by the API; parent_id is null for root notebooks.
+type NotebookResp struct {
+	ID        int64     `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Name      string    `json:"name"`
+	ParentID  *int64    `json:"parent_id"`
+	UserID    int64     `json:"user_id"`
+}
+
+// toNotebookResp - maps domain notebook to API response.
+func toNotebookResp(nb *model.Notebook) NotebookResp {
+	resp := NotebookResp{
+		ID:        nb.ID,
+		CreatedAt: nb.CreatedAt,
+		UpdatedAt: nb.UpdatedAt,
+		Name:      nb.Name,
+		UserID:    nb.UserID,
+	}
+	if nb.ParentID != 0 {
+		resp.ParentID = &nb.ParentID
+	}
+	return resp
+}
+
+// toNotebooksResp - maps slice of domain notebooks to []NotebookResp.
+func toNotebooksResp(nbs []model.Notebook) []NotebookResp {
+	out := make([]NotebookResp, 0, len(nbs))
+	for _, nb := range nbs {
+		out = append(out, toNotebookResp(&nb))
+	}
+	return out
+}
+
+// CreateNotebookReq request body for creating a notebook; without parent_id it is created at the root.
+type CreateNotebookReq struct {
+	Name     string `json:"name" binding:"required,min=1,max=100"`
+	ParentID int64  `json:"parent_id" binding:"omitempty,min=1"`
+}
+
+// Create (POST /notebooks) creates a notebook for current user; 201 + NotebookResp.
+func (h *NotebookHandler) Create(c *gin.Context) {
+	userID := middleware.CurrentUserID(c)
+
+	var req CreateNotebookReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if vErr, as := model.AsValidationError(req, err); as {
+			status, pub := model.ToHTTP(vErr)
+			c.AbortWithStatusJSON(status, pub)
+			return
+		}
+		status, pub := model.ToHTTP(model.ErrBadRequest)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+
+	ctx := c.Request.Context()
+	nb := model.Notebook{Name: req.Name, ParentID: req.ParentID, UserID: userID}
+	if err := h.s.Create(ctx, &nb); err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	c.JSON(http.StatusCreated, toNotebookResp(&nb))
+}
+
+// NotebookListQuery query params for listing notebooks.
+// Without `parent_id` the whole tree is returned, `parent_id=0` gives root notebooks.
+type NotebookListQuery struct {
+	ParentID *int64 `form:"parent_id" binding:"omitempty,min=0"`
+}
+
+// List (GET /notebooks) returns user's notebooks ordered by name; 200 + []NotebookResp.
+func (h *NotebookHandler) List(c *gin.Context) {
+	userID := middleware.CurrentUserID(c)
+
+	var q NotebookListQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		status, pub := model.ToHTTP(model.ErrBadRequest)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+
+	ctx := c.Request.Context()
+	notebooks, err := h.s.List(ctx, userID, q.ParentID)
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	c.JSON(http.StatusOK, toNotebooksResp(notebooks))
+}
+
+// GetByID (GET /notebooks/:id) loads one notebook by id for current user; 200 + NotebookResp.
+func (h *NotebookHandler) GetByID(c *gin.Context) {
+	userID := middleware.CurrentUserID(c)
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		status, pub := model.ToHTTP(model.ErrBadRequest)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+
+	ctx := c.Request.Context()
+	nb, err := h.s.GetByID(ctx, userID, id)
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	c.JSON(http.StatusOK, toNotebookResp(nb))
+}
+
+// UpdateByIDNotebookReq request body for renaming or moving a notebook (nil fields are ignored).
+// `parent_id` 0 moves the notebook to the root; sub-notebooks move along with it.
+type UpdateByIDNotebookReq struct {
+	Name     *string `json:"name" binding:"omitempty,min=1,max=100"`
+	ParentID *int64  `json:"parent_id" binding:"omitempty,min=0"`
+}
+
+// UpdateByID (PATCH /notebooks/:id) renames and/or moves a notebook; 200 + NotebookResp.
+func (h *NotebookHandler) UpdateByID(c *gin.Context) {
+	userID := middleware.CurrentUserID(c)
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		status, pub := model.ToHTTP(model.ErrBadRequest)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	var req UpdateByIDNotebookReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if vErr, as := model.AsValidationError(req, err); as {
+			status, pub := model.ToHTTP(vErr)
+			c.AbortWithStatusJSON(status, pub)
+			return
+		}
+		status, pub := model.ToHTTP(model.ErrBadRequest)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+
+	ctx := c.Request.Context()
+	upd := &model.NotebookUpdate{Name: req.Name, ParentID: req.ParentID}
+	updNotebook, err := h.s.UpdateByID(ctx, userID, id, upd)
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	c.JSON(http.StatusOK, toNotebookResp(updNotebook))
+}
+
+// DeleteNotebookQuery query params for deleting a notebook.
+// `policy` decides what happens to the notes: move_to_root (default) or trash.
+type DeleteNotebookQuery struct {
+	Policy string `form:"policy" binding:"omitempty,oneof=move_to_root trash"`
+}
+
+// DeleteByID (DELETE /notebooks/:id) deletes a notebook with its sub-notebooks; 204 No Content.
+func (h *NotebookHandler) DeleteByID(c *gin.Context) {
+	userID := middleware.CurrentUserID(c)
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		status, pub := model.ToHTTP(model.ErrBadRequest)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	var q DeleteNotebookQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		if vErr, as := model.AsValidationError(q, err); as {
+			status, pub := model.ToHTTP(vErr)
+			c.AbortWithStatusJSON(status, pub)
+			return
+		}
+		status, pub := model.ToHTTP(model.ErrBadRequest)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := h.s.DeleteByID(ctx, userID, id, model.NotebookDeletePolicy(q.Policy)); err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
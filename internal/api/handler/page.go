@@ -0,0 +1,21 @@
+// Package handler - response envelope shared by paginated list endpoints.
+package handler
+
+import "github.com/Rasulikus/notebook/internal/model"
+
+// PageResp - envelope around a page of items.
+// `next_cursor` is passed back as `?cursor=` to fetch the next page.
+type PageResp[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+}
+
+// toPageResp - maps a domain page to the envelope using the items mapper.
+func toPageResp[M, R any](page *model.Page[M], toResp func([]M) []R) PageResp[R] {
+	return PageResp[R]{
+		Items:      toResp(page.Items),
+		NextCursor: page.NextCursor,
+		HasMore:    page.HasMore,
+	}
+}
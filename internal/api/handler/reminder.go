@@ -0,0 +1,176 @@
+// Package handler - Gin HTTP handlers for note reminders.
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Rasulikus/notebook/internal/api/middleware"
+	"github.com/Rasulikus/notebook/internal/model"
+	"github.com/Rasulikus/notebook/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// ReminderHandler wires HTTP to ReminderService.
+type ReminderHandler struct {
+	s service.ReminderService
+}
+
+// NewReminderHandler - constructor.
+func NewReminderHandler(s service.ReminderService) *ReminderHandler { return &ReminderHandler{s: s} }
+
+// ReminderResp - public shape of a reminder; `remind_at` is the next time it fires,
+// `rule` is empty for one-off reminders.
+type ReminderResp struct {
+	ID          int64      `json:"id"`
+	NoteID      int64      `json:"note_id"`
+	RemindAt    time.Time  `json:"remind_at"`
+	Rule        string     `json:"rule"`
+	Timezone    string     `json:"timezone"`
+	FiredCount  int        `json:"fired_count"`
+	LastFiredAt *time.Time `json:"last_fired_at"` // null until it fires
+	Done        bool       `json:"done"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// toReminderResp - maps domain reminder to API response.
+func toReminderResp(r *model.Reminder) ReminderResp {
+	resp := ReminderResp{
+		ID:         r.ID,
+		NoteID:     r.NoteID,
+		RemindAt:   r.RemindAt,
+		Rule:       r.Rule,
+		Timezone:   r.Timezone,
+		FiredCount: r.FiredCount,
+		Done:       r.Done,
+		CreatedAt:  r.CreatedAt,
+	}
+	if !r.LastFiredAt.IsZero() {
+		resp.LastFiredAt = &r.LastFiredAt
+	}
+	return resp
+}
+
+// toRemindersResp - maps slice of domain reminders to []ReminderResp.
+func toRemindersResp(rs []model.Reminder) []ReminderResp {
+	out := make([]ReminderResp, 0, len(rs))
+	for i := range rs {
+		out = append(out, toReminderResp(&rs[i]))
+	}
+	return out
+}
+
+// CreateReminderReq request body for setting a reminder on a note.
+// `remind_at` is RFC 3339 and must be in the future. `rule` makes it recurring: daily, weekly, monthly
+// or an RRULE subset like "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR;COUNT=10".
+// `timezone` (IANA, UTC by default) keeps recurring reminders at the same local time across DST.
+type CreateReminderReq struct {
+	RemindAt time.Time `json:"remind_at" binding:"required"`
+	Rule     string    `json:"rule" binding:"max=200"`
+	Timezone string    `json:"timezone" binding:"max=64"`
+}
+
+// Create (POST /notes/:id/reminders) sets a reminder on the note; 201 + ReminderResp.
+func (h *ReminderHandler) Create(c *gin.Context) {
+	userID := middleware.CurrentUserID(c)
+
+	noteID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		status, pub := model.ToHTTP(model.ErrBadRequest)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	var req CreateReminderReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if vErr, as := model.AsValidationError(req, err); as {
+			status, pub := model.ToHTTP(vErr)
+			c.AbortWithStatusJSON(status, pub)
+			return
+		}
+		status, pub := model.ToHTTP(model.ErrBadRequest)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+
+	ctx := c.Request.Context()
+	rem := model.Reminder{NoteID: noteID, UserID: userID, RemindAt: req.RemindAt, Rule: req.Rule, Timezone: req.Timezone}
+	if err := h.s.Create(ctx, &rem); err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	c.JSON(http.StatusCreated, toReminderResp(&rem))
+}
+
+// ListByNote (GET /notes/:id/reminders) returns reminders of the note, soonest first; 200 + []ReminderResp.
+func (h *ReminderHandler) ListByNote(c *gin.Context) {
+	userID := middleware.CurrentUserID(c)
+
+	noteID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		status, pub := model.ToHTTP(model.ErrBadRequest)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+
+	ctx := c.Request.Context()
+	reminders, err := h.s.ListByNote(ctx, userID, noteID)
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	c.JSON(http.StatusOK, toRemindersResp(reminders))
+}
+
+// ReminderListQuery query params for listing reminders.
+// `upcoming=true` gives reminders that are still going to fire, soonest first;
+// otherwise all reminders are returned, the latest first.
+type ReminderListQuery struct {
+	Upcoming bool `form:"upcoming"`
+	Limit    int  `form:"limit"`
+	Offset   int  `form:"offset"`
+}
+
+// List (GET /reminders) returns user's reminders; 200 + []ReminderResp.
+func (h *ReminderHandler) List(c *gin.Context) {
+	userID := middleware.CurrentUserID(c)
+
+	var q ReminderListQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		status, pub := model.ToHTTP(model.ErrBadRequest)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+
+	ctx := c.Request.Context()
+	filter := &model.ReminderFilter{Upcoming: q.Upcoming, Limit: q.Limit, Offset: q.Offset}
+	reminders, err := h.s.List(ctx, userID, filter)
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	c.JSON(http.StatusOK, toRemindersResp(reminders))
+}
+
+// DeleteByID (DELETE /reminders/:id) cancels a reminder; 204 No Content.
+func (h *ReminderHandler) DeleteByID(c *gin.Context) {
+	userID := middleware.CurrentUserID(c)
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		status, pub := model.ToHTTP(model.ErrBadRequest)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := h.s.DeleteByID(ctx, userID, id); err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,61 @@
+// Package handler - Gin HTTP handlers for Markdown rendering.
+package handler
+
+import (
+	"net/http"
+
+	"github.com/Rasulikus/notebook/internal/markup"
+	"github.com/Rasulikus/notebook/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+// TOCEntryResp - a heading of rendered text; `id` is its anchor in the HTML.
+type TOCEntryResp struct {
+	Level int    `json:"level"`
+	Text  string `json:"text"`
+	ID    string `json:"id"`
+}
+
+// RenderResp - sanitized HTML of Markdown text with its table of contents.
+type RenderResp struct {
+	HTML string         `json:"html"`
+	TOC  []TOCEntryResp `json:"toc"`
+}
+
+// toRenderResp - maps rendered Markdown to API response.
+func toRenderResp(r *markup.Rendered) RenderResp {
+	toc := make([]TOCEntryResp, 0, len(r.TOC))
+	for _, h := range r.TOC {
+		toc = append(toc, TOCEntryResp{Level: h.Level, Text: h.Text, ID: h.ID})
+	}
+	return RenderResp{HTML: r.HTML, TOC: toc}
+}
+
+// RenderReq request body for rendering arbitrary Markdown, e.g. a note preview before saving.
+type RenderReq struct {
+	Text string `json:"text" binding:"max=20000"`
+}
+
+// Render (POST /render) renders CommonMark + GFM to sanitized HTML with a table of contents;
+// 200 + RenderResp.
+func (h *NoteHandler) Render(c *gin.Context) {
+	var req RenderReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if vErr, as := model.AsValidationError(req, err); as {
+			status, pub := model.ToHTTP(vErr)
+			c.AbortWithStatusJSON(status, pub)
+			return
+		}
+		status, pub := model.ToHTTP(model.ErrBadRequest)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+
+	rendered, err := markup.Render(req.Text)
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	c.JSON(http.StatusOK, toRenderResp(rendered))
+}
@@ -0,0 +1,160 @@
+// Package handler - Gin HTTP handlers for note revision history.
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Rasulikus/notebook/internal/api/middleware"
+	"github.com/Rasulikus/notebook/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+// RevisionResp - public shape of a note revision.
+// `text` is omitted in listings to keep them small.
+type RevisionResp struct {
+	Rev       int       `json:"rev"`
+	NoteID    int64     `json:"note_id"`
+	Title     string    `json:"title"`
+	Text      *string   `json:"text,omitempty"`
+	TagIDs    []int64   `json:"tag_ids"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// toRevisionResp - maps domain revision to API response.
+func toRevisionResp(r *model.NoteRevision, withText bool) RevisionResp {
+	resp := RevisionResp{
+		Rev:       r.Rev,
+		NoteID:    r.NoteID,
+		Title:     r.Title,
+		TagIDs:    r.TagIDs,
+		CreatedAt: r.CreatedAt,
+	}
+	if withText {
+		resp.Text = &r.Text
+	}
+	return resp
+}
+
+// RevisionDiffResp - unified diff between two versions of a note text.
+type RevisionDiffResp struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Diff string `json:"diff"`
+}
+
+// parseNoteRevParams reads :id and :rev path params; aborts with 400 on failure.
+func parseNoteRevParams(c *gin.Context) (noteID int64, rev int, ok bool) {
+	noteID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		status, pub := model.ToHTTP(model.ErrBadRequest)
+		c.AbortWithStatusJSON(status, pub)
+		return 0, 0, false
+	}
+	rev, err = strconv.Atoi(c.Param("rev"))
+	if err != nil || rev <= 0 {
+		status, pub := model.ToHTTP(model.ErrBadRequest)
+		c.AbortWithStatusJSON(status, pub)
+		return 0, 0, false
+	}
+	return noteID, rev, true
+}
+
+// ListRevisions (GET /notes/:id/revisions) returns note revisions, newest first; 200 + []RevisionResp.
+func (h *NoteHandler) ListRevisions(c *gin.Context) {
+	userID := middleware.CurrentUserID(c)
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		status, pub := model.ToHTTP(model.ErrBadRequest)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+
+	ctx := c.Request.Context()
+	revs, err := h.s.ListRevisions(ctx, userID, id)
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	out := make([]RevisionResp, 0, len(revs))
+	for i := range revs {
+		out = append(out, toRevisionResp(&revs[i], false))
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+// GetRevision (GET /notes/:id/revisions/:rev) returns a revision with its text; 200 + RevisionResp.
+func (h *NoteHandler) GetRevision(c *gin.Context) {
+	userID := middleware.CurrentUserID(c)
+
+	id, rev, ok := parseNoteRevParams(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	revision, err := h.s.GetRevision(ctx, userID, id, rev)
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	c.JSON(http.StatusOK, toRevisionResp(revision, true))
+}
+
+// DiffRevision (GET /notes/:id/revisions/:rev/diff?against=) returns a unified line diff
+// from the revision to `against` (a revision number or "current", the default); 200 + RevisionDiffResp.
+func (h *NoteHandler) DiffRevision(c *gin.Context) {
+	userID := middleware.CurrentUserID(c)
+
+	id, rev, ok := parseNoteRevParams(c)
+	if !ok {
+		return
+	}
+	against := 0
+	to := "current"
+	if raw := c.Query("against"); raw != "" && raw != "current" {
+		v, err := strconv.Atoi(raw)
+		if err != nil || v <= 0 {
+			status, pub := model.ToHTTP(&model.ValidationError{Fields: map[string]string{
+				"against": "must be a revision number or \"current\"",
+			}})
+			c.AbortWithStatusJSON(status, pub)
+			return
+		}
+		against = v
+		to = "rev " + strconv.Itoa(v)
+	}
+
+	ctx := c.Request.Context()
+	diff, err := h.s.DiffRevision(ctx, userID, id, rev, against)
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	c.JSON(http.StatusOK, RevisionDiffResp{From: "rev " + strconv.Itoa(rev), To: to, Diff: diff})
+}
+
+// RestoreRevision (POST /notes/:id/revisions/:rev/restore) overwrites the note
+// with the revision content; 200 + NoteResp.
+func (h *NoteHandler) RestoreRevision(c *gin.Context) {
+	userID := middleware.CurrentUserID(c)
+
+	id, rev, ok := parseNoteRevParams(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	note, err := h.s.RestoreRevision(ctx, userID, id, rev)
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	c.JSON(http.StatusOK, toNoteResp(note))
+}
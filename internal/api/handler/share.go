@@ -0,0 +1,223 @@
+// Package handler - Gin HTTP handlers for public note share links.
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Rasulikus/notebook/internal/api/middleware"
+	"github.com/Rasulikus/notebook/internal/markup"
+	"github.com/Rasulikus/notebook/internal/model"
+	"github.com/Rasulikus/notebook/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// sharePasswordHeader carries the password of a protected share link.
+const sharePasswordHeader = "X-Share-Password"
+
+// ShareHandler wires HTTP to ShareService.
+type ShareHandler struct {
+	s service.ShareService
+}
+
+// NewShareHandler - constructor.
+func NewShareHandler(s service.ShareService) *ShareHandler { return &ShareHandler{s: s} }
+
+// ShareResp - public shape of a share link for its owner. The token is never listed,
+// only returned once by Create.
+type ShareResp struct {
+	ID           int64      `json:"id"`
+	NoteID       int64      `json:"note_id"`
+	NoteTitle    string     `json:"note_title,omitempty"`
+	HasPassword  bool       `json:"has_password"`
+	Active       bool       `json:"active"` // neither revoked nor expired
+	ExpiresAt    *time.Time `json:"expires_at"`
+	RevokedAt    *time.Time `json:"revoked_at"`
+	Views        int64      `json:"views"`
+	LastViewedAt *time.Time `json:"last_viewed_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// CreatedShareResp - a new share link with its token and the path that opens it.
+type CreatedShareResp struct {
+	ShareResp
+	Token string `json:"token"`
+	Path  string `json:"path"`
+}
+
+// SharedNoteResp - read-only note as seen through a share link; it carries no ids of the owner.
+type SharedNoteResp struct {
+	Title     string     `json:"title"`
+	Text      string     `json:"text"`
+	Tags      []string   `json:"tags"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	Rendered  RenderResp `json:"rendered"`
+}
+
+// optionalTime maps a zero time to null.
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// toShareResp - maps domain share to API response.
+func toShareResp(s *model.NoteShare) ShareResp {
+	return ShareResp{
+		ID:           s.ID,
+		NoteID:       s.NoteID,
+		NoteTitle:    s.NoteTitle,
+		HasPassword:  s.PasswordHash != "",
+		Active:       s.Active(time.Now()),
+		ExpiresAt:    optionalTime(s.ExpiresAt),
+		RevokedAt:    optionalTime(s.RevokedAt),
+		Views:        s.Views,
+		LastViewedAt: optionalTime(s.LastViewedAt),
+		CreatedAt:    s.CreatedAt,
+	}
+}
+
+// toSharesResp - maps slice of domain shares to []ShareResp.
+func toSharesResp(ss []model.NoteShare) []ShareResp {
+	out := make([]ShareResp, 0, len(ss))
+	for i := range ss {
+		out = append(out, toShareResp(&ss[i]))
+	}
+	return out
+}
+
+// CreateShareReq request body for sharing a note. Both fields are optional:
+// without `expires_at` the link works until revoked, with `password` it asks for one.
+type CreateShareReq struct {
+	ExpiresAt *time.Time `json:"expires_at"`
+	Password  string     `json:"password" binding:"omitempty,min=8,max=72"`
+}
+
+// Create (POST /notes/:id/share) makes a public read-only link to the note; 201 + CreatedShareResp.
+// An empty body makes a link without expiry and password.
+func (h *ShareHandler) Create(c *gin.Context) {
+	userID := middleware.CurrentUserID(c)
+
+	noteID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		status, pub := model.ToHTTP(model.ErrBadRequest)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	var req CreateShareReq
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		if vErr, as := model.AsValidationError(req, err); as {
+			status, pub := model.ToHTTP(vErr)
+			c.AbortWithStatusJSON(status, pub)
+			return
+		}
+		status, pub := model.ToHTTP(model.ErrBadRequest)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	var expiresAt time.Time
+	if req.ExpiresAt != nil {
+		expiresAt = *req.ExpiresAt
+	}
+
+	ctx := c.Request.Context()
+	share, token, err := h.s.Create(ctx, userID, noteID, expiresAt, req.Password)
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	c.JSON(http.StatusCreated, CreatedShareResp{ShareResp: toShareResp(share), Token: token, Path: "/s/" + token})
+}
+
+// ListByNote (GET /notes/:id/shares) returns share links of the note, the latest first; 200 + []ShareResp.
+func (h *ShareHandler) ListByNote(c *gin.Context) {
+	userID := middleware.CurrentUserID(c)
+
+	noteID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		status, pub := model.ToHTTP(model.ErrBadRequest)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+
+	ctx := c.Request.Context()
+	shares, err := h.s.List(ctx, userID, noteID)
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	c.JSON(http.StatusOK, toSharesResp(shares))
+}
+
+// List (GET /shares) returns all share links of the user, the latest first; 200 + []ShareResp.
+func (h *ShareHandler) List(c *gin.Context) {
+	userID := middleware.CurrentUserID(c)
+
+	ctx := c.Request.Context()
+	shares, err := h.s.List(ctx, userID, 0)
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	c.JSON(http.StatusOK, toSharesResp(shares))
+}
+
+// Revoke (DELETE /shares/:id) makes the share link stop working; 204 No Content.
+func (h *ShareHandler) Revoke(c *gin.Context) {
+	userID := middleware.CurrentUserID(c)
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		status, pub := model.ToHTTP(model.ErrBadRequest)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := h.s.Revoke(ctx, userID, id); err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// Open (GET /s/:token) shows the shared note read-only, no authentication needed; 200 + SharedNoteResp.
+// A protected link takes its password in the X-Share-Password header, 401 without it or with a wrong one;
+// after several wrong passwords in a row the link answers 429 for a while;
+// unknown, revoked and expired links are all 404.
+func (h *ShareHandler) Open(c *gin.Context) {
+	ctx := c.Request.Context()
+	note, err := h.s.Open(ctx, c.Param("token"), c.GetHeader(sharePasswordHeader))
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	rendered, err := markup.Render(note.Text)
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+
+	tags := make([]string, 0, len(note.Tags))
+	for _, tag := range note.Tags {
+		tags = append(tags, tag.Path)
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, SharedNoteResp{
+		Title:     note.Title,
+		Text:      note.Text,
+		Tags:      tags,
+		UpdatedAt: note.UpdatedAt,
+		Rendered:  toRenderResp(rendered),
+	})
+}
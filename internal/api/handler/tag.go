@@ -13,26 +13,62 @@ import (
 
 // TagHandler wires HTTP to TagService.
 type TagHandler struct {
-	s service.TagService
+	s      service.TagService
+	global bool // manage global tags instead of the current user's ones
 }
 
 // NewTagHandler - constructor.
 func NewTagHandler(s service.TagService) *TagHandler { return &TagHandler{s: s} }
 
+// NewAdminTagHandler - constructor for admin CRUD of global tags; routes must be behind AdminMiddleware.
+func NewAdminTagHandler(s service.TagService) *TagHandler { return &TagHandler{s: s, global: true} }
+
+// ownerID returns the owner of tags handled by the request: the current user or 0 for global tags.
+func (h *TagHandler) ownerID(c *gin.Context) int64 {
+	if h.global {
+		return 0
+	}
+	return middleware.CurrentUserID(c)
+}
+
 // TagResp - public shape returned by the API.
+// parent_id is null for root tags, path is the full name like "work/clients/acme".
 type TagResp struct {
-	ID     int64  `json:"id"`
-	Name   string `json:"name"`
-	UserID int64  `json:"user_id"`
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`
+	ParentID    *int64 `json:"parent_id"`
+	Path        string `json:"path"`
+	UserID      int64  `json:"user_id"`
+	WorkspaceID *int64 `json:"workspace_id"`         // null unless the tag belongs to a workspace
+	NoteCount   *int64 `json:"note_count,omitempty"` // only with with_counts=true and in suggestions
 }
 
 // toTagResp - maps domain tag to API response.
 func toTagResp(tag *model.Tag) TagResp {
-	return TagResp{
+	resp := TagResp{
 		ID:     tag.ID,
 		Name:   tag.Name,
+		Path:   tag.Path,
 		UserID: tag.UserID,
 	}
+	if tag.ParentID != 0 {
+		resp.ParentID = &tag.ParentID
+	}
+	if tag.WorkspaceID != 0 {
+		resp.WorkspaceID = &tag.WorkspaceID
+	}
+	return resp
+}
+
+// toTagsWithCountsResp - maps slice of domain tags to []TagResp with note counts.
+func toTagsWithCountsResp(tags []model.Tag) []TagResp {
+	out := make([]TagResp, 0, len(tags))
+	for _, tag := range tags {
+		resp := toTagResp(&tag)
+		resp.NoteCount = &tag.NoteCount
+		out = append(out, resp)
+	}
+	return out
 }
 
 // toTagsResp - maps slice of domain tags to []TagResp.
@@ -44,14 +80,16 @@ func toTagsResp(tags []model.Tag) []TagResp {
 	return out
 }
 
-// CreateTagReq request body for creating a tag.
+// CreateTagReq request body for creating a tag; without parent_id it is created at the root.
+// The name is one level of the path, so it can't contain "/".
 type CreateTagReq struct {
-	Name string `json:"name" binding:"required,min=3,max=50"`
+	Name     string `json:"name" binding:"required,min=3,max=50,excludes=/"`
+	ParentID int64  `json:"parent_id" binding:"omitempty,min=1"`
 }
 
 // Create (POST /tags) creates a tag for current user; 201 + TagResp.
 func (h *TagHandler) Create(c *gin.Context) {
-	userID := middleware.CurrentUserID(c)
+	userID := h.ownerID(c)
 
 	var req CreateTagReq
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -66,7 +104,7 @@ func (h *TagHandler) Create(c *gin.Context) {
 	}
 
 	ctx := c.Request.Context()
-	tag := model.Tag{Name: req.Name, UserID: userID}
+	tag := model.Tag{Name: req.Name, ParentID: req.ParentID, UserID: userID}
 	if err := h.s.Create(ctx, &tag); err != nil {
 		status, pub := model.ToHTTP(err)
 		c.AbortWithStatusJSON(status, pub)
@@ -77,15 +115,23 @@ func (h *TagHandler) Create(c *gin.Context) {
 
 // TagListQuery query params for listing tags.
 // All query params are optional; if omitted, defaults are used by the service.
+// `sort` is a comma-separated list of fields from model.TagSortFields, "-" prefix
+// means descending, e.g. "-note_count,name".
+// `cursor` is the `next_cursor` of the previous page.
+// `parent_id` limits tags to children of a tag, `parent_id=0` gives root tags.
+// `with_counts=true` adds the number of user's notes to every tag.
 type TagListQuery struct {
-	Limit  int    `form:"limit"`
-	Offset int    `form:"offset"`
-	Order  string `form:"order"`
+	Limit      int    `form:"limit"`
+	Offset     int    `form:"offset"`
+	Sort       string `form:"sort"`
+	Cursor     string `form:"cursor"`
+	ParentID   *int64 `form:"parent_id" binding:"omitempty,min=0"`
+	WithCounts bool   `form:"with_counts"`
 }
 
-// List (GET /tags) returns user's tags; 200 + []TagResp.
+// List (GET /tags) returns user's tags; 200 + PageResp[TagResp].
 func (h *TagHandler) List(c *gin.Context) {
-	userID := middleware.CurrentUserID(c)
+	userID := h.ownerID(c)
 
 	var q TagListQuery
 	if err := c.ShouldBindQuery(&q); err != nil {
@@ -94,19 +140,65 @@ func (h *TagHandler) List(c *gin.Context) {
 		return
 	}
 
+	sort, err := model.ParseSort(q.Sort, model.TagSortFields)
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+
 	ctx := c.Request.Context()
-	tags, err := h.s.List(ctx, userID, q.Limit, q.Offset, q.Order)
+	filter := &model.TagFilter{Limit: q.Limit, Offset: q.Offset, Sort: sort, Cursor: q.Cursor, ParentID: q.ParentID}
+	page, err := h.s.List(ctx, userID, filter)
 	if err != nil {
 		status, pub := model.ToHTTP(err)
 		c.AbortWithStatusJSON(status, pub)
 		return
 	}
-	c.JSON(http.StatusOK, toTagsResp(tags))
+	if q.WithCounts {
+		c.JSON(http.StatusOK, toPageResp(page, toTagsWithCountsResp))
+		return
+	}
+	c.JSON(http.StatusOK, toPageResp(page, toTagsResp))
+}
+
+// TagSuggestQuery query params for tag autocomplete.
+// `prefix` matches the beginning of a tag name or path, case-insensitive; `limit` defaults to 10, at most 50.
+type TagSuggestQuery struct {
+	Prefix string `form:"prefix" binding:"max=100"`
+	Limit  int    `form:"limit"`
+}
+
+// Suggest (GET /tags/suggest) returns tags for autocomplete ordered by usage and recency;
+// 200 + []TagResp with note_count.
+func (h *TagHandler) Suggest(c *gin.Context) {
+	userID := h.ownerID(c)
+
+	var q TagSuggestQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		if vErr, as := model.AsValidationError(q, err); as {
+			status, pub := model.ToHTTP(vErr)
+			c.AbortWithStatusJSON(status, pub)
+			return
+		}
+		status, pub := model.ToHTTP(model.ErrBadRequest)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+
+	ctx := c.Request.Context()
+	tags, err := h.s.Suggest(ctx, userID, q.Prefix, q.Limit)
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	c.JSON(http.StatusOK, toTagsWithCountsResp(tags))
 }
 
 // GetByID (GET /tags/:id) loads one tag by id for current user; 200 + TagResp.
 func (h *TagHandler) GetByID(c *gin.Context) {
-	userID := middleware.CurrentUserID(c)
+	userID := h.ownerID(c)
 
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
@@ -125,14 +217,16 @@ func (h *TagHandler) GetByID(c *gin.Context) {
 	c.JSON(http.StatusOK, toTagResp(tag))
 }
 
-// UpdateByIDTagReq request body for updating tag name.
+// UpdateByIDTagReq request body for renaming or moving a tag (nil fields are ignored).
+// `parent_id` 0 moves the tag to the root; descendant tags move along with it.
 type UpdateByIDTagReq struct {
-	Name string `json:"name" binding:"required,min=3,max=100"`
+	Name     *string `json:"name" binding:"omitempty,min=3,max=100,excludes=/"`
+	ParentID *int64  `json:"parent_id" binding:"omitempty,min=0"`
 }
 
-// UpdateByID (PATCH /tags/:id) updates tag name; 200 + TagResp.
+// UpdateByID (PATCH /tags/:id) renames and/or moves a tag; 200 + TagResp.
 func (h *TagHandler) UpdateByID(c *gin.Context) {
-	userID := middleware.CurrentUserID(c)
+	userID := h.ownerID(c)
 
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
@@ -153,8 +247,8 @@ func (h *TagHandler) UpdateByID(c *gin.Context) {
 	}
 
 	ctx := c.Request.Context()
-	tag := &model.Tag{ID: id, UserID: userID, Name: req.Name}
-	updTag, err := h.s.UpdateByID(ctx, userID, tag)
+	r := &service.UpdateByIDTagReq{Name: req.Name, ParentID: req.ParentID}
+	updTag, err := h.s.UpdateByID(ctx, userID, id, r)
 	if err != nil {
 		status, pub := model.ToHTTP(err)
 		c.AbortWithStatusJSON(status, pub)
@@ -163,9 +257,9 @@ func (h *TagHandler) UpdateByID(c *gin.Context) {
 	c.JSON(http.StatusOK, toTagResp(updTag))
 }
 
-// DeleteByID (DELETE /tags/:id) deletes a tag; 204 No Content.
+// DeleteByID (DELETE /tags/:id) deletes a tag with its descendants; 204 No Content.
 func (h *TagHandler) DeleteByID(c *gin.Context) {
-	userID := middleware.CurrentUserID(c)
+	userID := h.ownerID(c)
 
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
@@ -182,3 +276,41 @@ func (h *TagHandler) DeleteByID(c *gin.Context) {
 	}
 	c.Status(http.StatusNoContent)
 }
+
+// MergeTagReq request body for merging a tag into another one.
+type MergeTagReq struct {
+	TargetID int64 `json:"target_id" binding:"required,min=1"`
+}
+
+// Merge (POST /tags/:id/merge) moves notes and child tags of the tag to the target
+// and deletes the tag; 200 + TagResp of the target.
+func (h *TagHandler) Merge(c *gin.Context) {
+	userID := h.ownerID(c)
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		status, pub := model.ToHTTP(model.ErrBadRequest)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	var req MergeTagReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if vErr, as := model.AsValidationError(req, err); as {
+			status, pub := model.ToHTTP(vErr)
+			c.AbortWithStatusJSON(status, pub)
+			return
+		}
+		status, pub := model.ToHTTP(model.ErrBadRequest)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+
+	ctx := c.Request.Context()
+	target, err := h.s.Merge(ctx, userID, id, req.TargetID)
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	c.JSON(http.StatusOK, toTagResp(target))
+}
@@ -0,0 +1,150 @@
+// Package handler - Gin HTTP handlers for checklist items of notes.
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/Rasulikus/notebook/internal/api/middleware"
+	"github.com/Rasulikus/notebook/internal/model"
+	"github.com/Rasulikus/notebook/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// TaskResp - a checklist item of a note; `index` addresses it in PATCH /notes/:id/tasks/:index,
+// `line` is its line number in the note text, from 1.
+type TaskResp struct {
+	NoteID    int64  `json:"note_id"`
+	NoteTitle string `json:"note_title"`
+	Index     int    `json:"index"`
+	Line      int    `json:"line"`
+	Text      string `json:"text"`
+	Done      bool   `json:"done"`
+}
+
+// toTaskResp - maps domain task to API response.
+func toTaskResp(t *model.NoteTask) TaskResp {
+	return TaskResp{
+		NoteID:    t.NoteID,
+		NoteTitle: t.NoteTitle,
+		Index:     t.Index,
+		Line:      t.Line,
+		Text:      t.Text,
+		Done:      t.Done,
+	}
+}
+
+// toTasksResp - maps slice of domain tasks to []TaskResp.
+func toTasksResp(ts []model.NoteTask) []TaskResp {
+	out := make([]TaskResp, 0, len(ts))
+	for i := range ts {
+		out = append(out, toTaskResp(&ts[i]))
+	}
+	return out
+}
+
+// ListTasks (GET /notes/:id/tasks) returns checklist items of the note; 200 + []TaskResp.
+func (h *NoteHandler) ListTasks(c *gin.Context) {
+	userID := middleware.CurrentUserID(c)
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		status, pub := model.ToHTTP(model.ErrBadRequest)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+
+	ctx := c.Request.Context()
+	tasks, err := h.s.ListTasks(ctx, userID, id)
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	c.JSON(http.StatusOK, toTasksResp(tasks))
+}
+
+// SetTaskReq optional request body for changing a checklist item; without `done` the item is toggled.
+type SetTaskReq struct {
+	Done *bool `json:"done"`
+}
+
+// SetTask (PATCH /notes/:id/tasks/:index) checks, unchecks or toggles a checklist item by rewriting
+// the note text, honoring If-Match; 200 + TaskResp with the ETag of the new note version.
+func (h *NoteHandler) SetTask(c *gin.Context) {
+	userID := middleware.CurrentUserID(c)
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		status, pub := model.ToHTTP(model.ErrBadRequest)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	index, err := strconv.Atoi(c.Param("index"))
+	if err != nil || index < 0 {
+		status, pub := model.ToHTTP(model.ErrBadRequest)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	ifVersion, err := ifMatchVersion(c)
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	var req SetTaskReq
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		status, pub := model.ToHTTP(model.ErrBadRequest)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+
+	ctx := c.Request.Context()
+	r := &service.SetTaskReq{Index: index, Done: req.Done, IfVersion: ifVersion}
+	task, note, err := h.s.SetTask(ctx, userID, id, r)
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	c.Header("ETag", etag(note.Version))
+	c.JSON(http.StatusOK, toTaskResp(task))
+}
+
+// TaskListQuery query params for listing checklist items across notes.
+// `done=false` gives open items only; `limit` is 100 by default and 500 at most.
+type TaskListQuery struct {
+	Done   *bool `form:"done"`
+	Limit  int   `form:"limit" binding:"min=0"`
+	Offset int   `form:"offset" binding:"min=0"`
+}
+
+// ListAllTasks (GET /tasks) returns checklist items from all notes that are not archived,
+// recently updated notes first; 200 + []TaskResp.
+func (h *NoteHandler) ListAllTasks(c *gin.Context) {
+	userID := middleware.CurrentUserID(c)
+
+	var q TaskListQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		if vErr, as := model.AsValidationError(q, err); as {
+			status, pub := model.ToHTTP(vErr)
+			c.AbortWithStatusJSON(status, pub)
+			return
+		}
+		status, pub := model.ToHTTP(model.ErrBadRequest)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+
+	ctx := c.Request.Context()
+	filter := &model.TaskFilter{Done: q.Done, Limit: q.Limit, Offset: q.Offset}
+	tasks, err := h.s.ListAllTasks(ctx, userID, filter)
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	c.JSON(http.StatusOK, toTasksResp(tasks))
+}
@@ -0,0 +1,79 @@
+// Package handler - Gin HTTP handlers for the notes trash.
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Rasulikus/notebook/internal/api/middleware"
+	"github.com/Rasulikus/notebook/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+// TrashListQuery query params for listing trash; defaults are used by the service.
+type TrashListQuery struct {
+	Limit  int `form:"limit"`
+	Offset int `form:"offset"`
+}
+
+// ListTrash (GET /notes/trash) returns user's deleted notes, most recent first; 200 + []NoteResp.
+func (h *NoteHandler) ListTrash(c *gin.Context) {
+	userID := middleware.CurrentUserID(c)
+
+	var q TrashListQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		status, pub := model.ToHTTP(model.ErrBadRequest)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+
+	ctx := c.Request.Context()
+	notes, err := h.s.ListTrash(ctx, userID, q.Limit, q.Offset)
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	c.JSON(http.StatusOK, toNotesResp(notes))
+}
+
+// Restore (POST /notes/:id/restore) moves a note from trash back; 200 + NoteResp.
+func (h *NoteHandler) Restore(c *gin.Context) {
+	userID := middleware.CurrentUserID(c)
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		status, pub := model.ToHTTP(model.ErrBadRequest)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+
+	ctx := c.Request.Context()
+	note, err := h.s.Restore(ctx, userID, id)
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	c.JSON(http.StatusOK, toNoteResp(note))
+}
+
+// DeletePermanently (DELETE /notes/trash/:id) removes a note from trash for good; 204 No Content.
+func (h *NoteHandler) DeletePermanently(c *gin.Context) {
+	userID := middleware.CurrentUserID(c)
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		status, pub := model.ToHTTP(model.ErrBadRequest)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := h.s.DeletePermanently(ctx, userID, id); err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
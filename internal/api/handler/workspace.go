@@ -0,0 +1,365 @@
+// Package handler - Gin HTTP handlers for team workspaces, their members and invitations.
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Rasulikus/notebook/internal/api/middleware"
+	"github.com/Rasulikus/notebook/internal/model"
+	"github.com/Rasulikus/notebook/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// WorkspaceHandler wires HTTP to WorkspaceService.
+type WorkspaceHandler struct {
+	s service.WorkspaceService
+}
+
+// NewWorkspaceHandler - constructor.
+func NewWorkspaceHandler(s service.WorkspaceService) *WorkspaceHandler {
+	return &WorkspaceHandler{s: s}
+}
+
+// WorkspaceResp - public shape of a workspace with the role of the current user in it.
+type WorkspaceResp struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// toWorkspaceResp - maps domain workspace to API response.
+func toWorkspaceResp(ws *model.Workspace) WorkspaceResp {
+	return WorkspaceResp{ID: ws.ID, Name: ws.Name, Role: string(ws.Role), CreatedAt: ws.CreatedAt}
+}
+
+// MemberResp - public shape of a workspace member.
+type MemberResp struct {
+	UserID    int64     `json:"user_id"`
+	Email     string    `json:"email"`
+	Name      string    `json:"name"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"` // when the user joined
+}
+
+// InviteResp - public shape of an invitation. The token is never listed, only returned once by Invite.
+type InviteResp struct {
+	ID        int64     `json:"id"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	InvitedBy int64     `json:"invited_by"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CreatedInviteResp - a new invitation with its token and the path that accepts it.
+type CreatedInviteResp struct {
+	InviteResp
+	Token string `json:"token"`
+	Path  string `json:"path"`
+}
+
+// toInviteResp - maps domain invitation to API response.
+func toInviteResp(i *model.WorkspaceInvite) InviteResp {
+	return InviteResp{
+		ID:        i.ID,
+		Email:     i.Email,
+		Role:      string(i.Role),
+		InvitedBy: i.InvitedBy,
+		ExpiresAt: i.ExpiresAt,
+		CreatedAt: i.CreatedAt,
+	}
+}
+
+// WorkspaceReq request body for creating or renaming a workspace.
+type WorkspaceReq struct {
+	Name string `json:"name" binding:"required,min=1,max=100"`
+}
+
+// MemberRoleReq request body for changing the role of a member.
+type MemberRoleReq struct {
+	Role string `json:"role" binding:"required,oneof=owner admin member viewer"`
+}
+
+// InviteReq request body for inviting a user by email.
+// `viewer` reads notes and tags, `member` also changes them, `admin` also manages members.
+type InviteReq struct {
+	Email string `json:"email" binding:"required,email"`
+	Role  string `json:"role" binding:"required,oneof=admin member viewer"`
+}
+
+// bindJSON binds the request body into req, writing the error response on failure.
+func bindJSON[T any](c *gin.Context, req *T) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		if vErr, as := model.AsValidationError(*req, err); as {
+			status, pub := model.ToHTTP(vErr)
+			c.AbortWithStatusJSON(status, pub)
+			return false
+		}
+		status, pub := model.ToHTTP(model.ErrBadRequest)
+		c.AbortWithStatusJSON(status, pub)
+		return false
+	}
+	return true
+}
+
+// parseIDParams reads the given int64 path params; aborts with 400 on failure.
+func parseIDParams(c *gin.Context, names ...string) ([]int64, bool) {
+	ids := make([]int64, len(names))
+	for i, name := range names {
+		id, err := strconv.ParseInt(c.Param(name), 10, 64)
+		if err != nil {
+			status, pub := model.ToHTTP(model.ErrBadRequest)
+			c.AbortWithStatusJSON(status, pub)
+			return nil, false
+		}
+		ids[i] = id
+	}
+	return ids, true
+}
+
+// Create (POST /workspaces) makes a workspace owned by the current user; 201 + WorkspaceResp.
+func (h *WorkspaceHandler) Create(c *gin.Context) {
+	userID := middleware.CurrentUserID(c)
+	var req WorkspaceReq
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	ctx := c.Request.Context()
+	ws, err := h.s.Create(ctx, userID, req.Name)
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	c.JSON(http.StatusCreated, toWorkspaceResp(ws))
+}
+
+// List (GET /workspaces) returns workspaces of the current user; 200 + []WorkspaceResp.
+func (h *WorkspaceHandler) List(c *gin.Context) {
+	userID := middleware.CurrentUserID(c)
+
+	ctx := c.Request.Context()
+	workspaces, err := h.s.List(ctx, userID)
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	out := make([]WorkspaceResp, 0, len(workspaces))
+	for i := range workspaces {
+		out = append(out, toWorkspaceResp(&workspaces[i]))
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+// GetByID (GET /workspaces/:id) returns a workspace of the current user; 200 + WorkspaceResp.
+func (h *WorkspaceHandler) GetByID(c *gin.Context) {
+	userID := middleware.CurrentUserID(c)
+	ids, ok := parseIDParams(c, "id")
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	ws, err := h.s.GetByID(ctx, userID, ids[0])
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	c.JSON(http.StatusOK, toWorkspaceResp(ws))
+}
+
+// Rename (PATCH /workspaces/:id) changes the name; admins and owners only; 200 + WorkspaceResp.
+func (h *WorkspaceHandler) Rename(c *gin.Context) {
+	userID := middleware.CurrentUserID(c)
+	ids, ok := parseIDParams(c, "id")
+	if !ok {
+		return
+	}
+	var req WorkspaceReq
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	ctx := c.Request.Context()
+	ws, err := h.s.Rename(ctx, userID, ids[0], req.Name)
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	c.JSON(http.StatusOK, toWorkspaceResp(ws))
+}
+
+// DeleteByID (DELETE /workspaces/:id) deletes the workspace with all its notes and tags;
+// owners only; 204 No Content.
+func (h *WorkspaceHandler) DeleteByID(c *gin.Context) {
+	userID := middleware.CurrentUserID(c)
+	ids, ok := parseIDParams(c, "id")
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := h.s.DeleteByID(ctx, userID, ids[0]); err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ListMembers (GET /workspaces/:id/members) returns members of the workspace; 200 + []MemberResp.
+func (h *WorkspaceHandler) ListMembers(c *gin.Context) {
+	userID := middleware.CurrentUserID(c)
+	ids, ok := parseIDParams(c, "id")
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	members, err := h.s.ListMembers(ctx, userID, ids[0])
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	out := make([]MemberResp, 0, len(members))
+	for _, m := range members {
+		out = append(out, MemberResp{UserID: m.UserID, Email: m.Email, Name: m.Name, Role: string(m.Role), CreatedAt: m.CreatedAt})
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+// SetMemberRole (PATCH /workspaces/:id/members/:user_id) changes the role of a member.
+// Admins manage members and viewers, owners everyone; the last owner can't be demoted (409); 204 No Content.
+func (h *WorkspaceHandler) SetMemberRole(c *gin.Context) {
+	userID := middleware.CurrentUserID(c)
+	ids, ok := parseIDParams(c, "id", "user_id")
+	if !ok {
+		return
+	}
+	var req MemberRoleReq
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := h.s.SetMemberRole(ctx, userID, ids[0], ids[1], model.WorkspaceRole(req.Role)); err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// RemoveMember (DELETE /workspaces/:id/members/:user_id) removes a member; with the current user's id
+// it leaves the workspace. The last owner can't leave (409); 204 No Content.
+func (h *WorkspaceHandler) RemoveMember(c *gin.Context) {
+	userID := middleware.CurrentUserID(c)
+	ids, ok := parseIDParams(c, "id", "user_id")
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := h.s.RemoveMember(ctx, userID, ids[0], ids[1]); err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// Invite (POST /workspaces/:id/invitations) invites a user by email; admins and owners only,
+// only owners invite admins; 201 + CreatedInviteResp. The token is shown only here.
+func (h *WorkspaceHandler) Invite(c *gin.Context) {
+	userID := middleware.CurrentUserID(c)
+	ids, ok := parseIDParams(c, "id")
+	if !ok {
+		return
+	}
+	var req InviteReq
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	ctx := c.Request.Context()
+	invite, token, err := h.s.Invite(ctx, userID, ids[0], req.Email, model.WorkspaceRole(req.Role))
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	c.JSON(http.StatusCreated, CreatedInviteResp{
+		InviteResp: toInviteResp(invite),
+		Token:      token,
+		Path:       "/invitations/" + token + "/accept",
+	})
+}
+
+// ListInvites (GET /workspaces/:id/invitations) returns pending invitations; 200 + []InviteResp.
+func (h *WorkspaceHandler) ListInvites(c *gin.Context) {
+	userID := middleware.CurrentUserID(c)
+	ids, ok := parseIDParams(c, "id")
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	invites, err := h.s.ListInvites(ctx, userID, ids[0])
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	out := make([]InviteResp, 0, len(invites))
+	for i := range invites {
+		out = append(out, toInviteResp(&invites[i]))
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+// RevokeInvite (DELETE /workspaces/:id/invitations/:invite_id) cancels a pending invitation; 204 No Content.
+func (h *WorkspaceHandler) RevokeInvite(c *gin.Context) {
+	userID := middleware.CurrentUserID(c)
+	ids, ok := parseIDParams(c, "id", "invite_id")
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := h.s.RevokeInvite(ctx, userID, ids[0], ids[1]); err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// AcceptInvite (POST /invitations/:token/accept) joins the current user to the workspace the token
+// invites to. The invitation must be addressed to the user's email (403); unknown, used, revoked
+// and expired tokens are 404; 200 + WorkspaceResp.
+func (h *WorkspaceHandler) AcceptInvite(c *gin.Context) {
+	userID := middleware.CurrentUserID(c)
+
+	ctx := c.Request.Context()
+	member, err := h.s.AcceptInvite(ctx, userID, c.Param("token"))
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	ws, err := h.s.GetByID(ctx, userID, member.WorkspaceID)
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	c.JSON(http.StatusOK, toWorkspaceResp(ws))
+}
@@ -38,6 +38,28 @@ func AuthMiddleware(authService service.AuthService) gin.HandlerFunc {
 	}
 }
 
+// AdminMiddleware lets through only administrators; must run after AuthMiddleware.
+func AdminMiddleware(authService service.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := CurrentUserID(c)
+		if c.IsAborted() {
+			return
+		}
+		isAdmin, err := authService.IsAdmin(c.Request.Context(), userID)
+		if err != nil {
+			status, pub := model.ToHTTP(err)
+			c.AbortWithStatusJSON(status, pub)
+			return
+		}
+		if !isAdmin {
+			status, pub := model.ToHTTP(model.ErrForbidden)
+			c.AbortWithStatusJSON(status, pub)
+			return
+		}
+		c.Next()
+	}
+}
+
 // CurrentUserID extracts user ID from context.
 // Aborts with an error if the ID is missing or of a wrong type.
 func CurrentUserID(c *gin.Context) int64 {
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Rasulikus/notebook/internal/model"
+	"github.com/Rasulikus/notebook/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// WorkspaceHeader selects the workspace a request works in; the :workspace_id path param
+// of routes mounted under /w/:workspace_id does the same and takes precedence.
+const WorkspaceHeader = "X-Workspace-ID"
+
+// WorkspaceMiddleware switches the request to the owner scope of the selected workspace
+// (see model.WithWorkspace); without one the request stays in the user's personal scope.
+// Non-members get 404, viewers can only read (403 for other methods). Must run after AuthMiddleware.
+func WorkspaceMiddleware(workspaceService service.WorkspaceService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		raw := c.Param("workspace_id")
+		if raw == "" {
+			raw = c.GetHeader(WorkspaceHeader)
+		}
+		if raw == "" {
+			c.Next()
+			return
+		}
+		workspaceID, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || workspaceID <= 0 {
+			status, pub := model.ToHTTP(model.ErrBadRequest)
+			c.AbortWithStatusJSON(status, pub)
+			return
+		}
+
+		userID := CurrentUserID(c)
+		if c.IsAborted() {
+			return
+		}
+		member, err := workspaceService.Membership(c.Request.Context(), userID, workspaceID)
+		if err != nil {
+			status, pub := model.ToHTTP(err)
+			c.AbortWithStatusJSON(status, pub)
+			return
+		}
+		if !member.Role.Allows(model.WorkspaceMember) && c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+			status, pub := model.ToHTTP(model.ErrForbidden)
+			c.AbortWithStatusJSON(status, pub)
+			return
+		}
+
+		c.Request = c.Request.WithContext(model.WithWorkspace(c.Request.Context(), member))
+		c.Next()
+	}
+}
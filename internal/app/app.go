@@ -1,22 +1,40 @@
 package app
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/Rasulikus/notebook/internal/api/handler"
 	"github.com/Rasulikus/notebook/internal/api/middleware"
 	"github.com/Rasulikus/notebook/internal/config"
+	"github.com/Rasulikus/notebook/internal/notify"
 	"github.com/Rasulikus/notebook/internal/repository"
+	aclRepository "github.com/Rasulikus/notebook/internal/repository/acl"
+	attachmentRepository "github.com/Rasulikus/notebook/internal/repository/attachment"
 	noteRepository "github.com/Rasulikus/notebook/internal/repository/note"
+	notebookRepository "github.com/Rasulikus/notebook/internal/repository/notebook"
+	reminderRepository "github.com/Rasulikus/notebook/internal/repository/reminder"
 	"github.com/Rasulikus/notebook/internal/repository/session"
+	shareRepository "github.com/Rasulikus/notebook/internal/repository/share"
 	tagRepository "github.com/Rasulikus/notebook/internal/repository/tag"
 	"github.com/Rasulikus/notebook/internal/repository/user"
+	workspaceRepository "github.com/Rasulikus/notebook/internal/repository/workspace"
+	"github.com/Rasulikus/notebook/internal/service/acl"
+	"github.com/Rasulikus/notebook/internal/service/attachment"
 	"github.com/Rasulikus/notebook/internal/service/auth"
 	"github.com/Rasulikus/notebook/internal/service/note"
+	"github.com/Rasulikus/notebook/internal/service/notebook"
+	"github.com/Rasulikus/notebook/internal/service/reminder"
+	"github.com/Rasulikus/notebook/internal/service/share"
 	"github.com/Rasulikus/notebook/internal/service/tag"
+	"github.com/Rasulikus/notebook/internal/service/workspace"
+	"github.com/Rasulikus/notebook/internal/storage"
 
 	"github.com/gin-gonic/gin"
 )
 
-func App(cfg *config.Config) *gin.Engine {
+// App wires the application and returns its router. Background workers run until ctx is cancelled.
+func App(ctx context.Context, cfg *config.Config) *gin.Engine {
 
 	db, err := repository.NewClient(cfg)
 	if err != nil {
@@ -36,10 +54,50 @@ func App(cfg *config.Config) *gin.Engine {
 	tagRepo := tagRepository.NewRepository(db.DB)
 	tagService := tag.NewService(tagRepo)
 	tagHandler := handler.NewTagHandler(tagService)
+	adminTagHandler := handler.NewAdminTagHandler(tagService)
+
+	notebookRepo := notebookRepository.NewRepository(db.DB)
+	notebookService := notebook.NewService(notebookRepo)
+	notebookHandler := handler.NewNotebookHandler(notebookService)
 
 	noteRepo := noteRepository.NewRepository(db.DB)
-	noteService := note.NewService(noteRepo, tagRepo)
+	noteService := note.NewService(noteRepo, tagRepo, notebookRepo)
 	noteHandler := handler.NewNoteHandler(noteService)
+	go noteService.RunTrashPurger(ctx, cfg.Trash.PurgeInterval, cfg.Trash.Retention)
+
+	var notifier notify.Notifier = notify.NewLogNotifier(nil)
+	if cfg.Reminder.WebhookURL != "" {
+		notifier = notify.NewWebhookNotifier(cfg.Reminder.WebhookURL, cfg.Reminder.WebhookSecret, cfg.Reminder.WebhookTimeout)
+	}
+	reminderRepo := reminderRepository.NewRepository(db.DB)
+	reminderService := reminder.NewService(reminderRepo, noteRepo, notifier)
+	reminderHandler := handler.NewReminderHandler(reminderService)
+	go reminderService.RunScheduler(ctx, cfg.Reminder.PollInterval)
+
+	attachmentStore, err := newBlobStore(ctx, cfg)
+	if err != nil {
+		panic(err)
+	}
+	attachmentRepo := attachmentRepository.NewRepository(db.DB)
+	attachmentService := attachment.NewService(attachmentRepo, noteRepo, attachmentStore, attachment.Limits{
+		MaxSize: cfg.Attachment.MaxSize,
+		Quota:   cfg.Attachment.Quota,
+	}, cfg.Attachment.ThumbSizes)
+	attachmentHandler := handler.NewAttachmentHandler(attachmentService, cfg.Attachment.MaxSize)
+	go attachmentService.RunCleaner(ctx, cfg.Attachment.CleanupInterval)
+	go attachmentService.RunThumbnailer(ctx, cfg.Attachment.ThumbPollInterval)
+
+	aclRepo := aclRepository.NewRepository(db.DB)
+	aclService := acl.NewService(aclRepo, noteRepo, tagRepo, userRepo)
+	accessHandler := handler.NewAccessHandler(aclService)
+
+	shareRepo := shareRepository.NewRepository(db.DB)
+	shareService := share.NewService(shareRepo, noteRepo)
+	shareHandler := handler.NewShareHandler(shareService)
+
+	workspaceRepo := workspaceRepository.NewRepository(db.DB)
+	workspaceService := workspace.NewService(workspaceRepo, userRepo, cfg.Workspace.InviteTTL)
+	workspaceHandler := handler.NewWorkspaceHandler(workspaceService)
 
 	router := gin.Default()
 	authApi := router.Group("/auth")
@@ -50,23 +108,151 @@ func App(cfg *config.Config) *gin.Engine {
 		authApi.POST("/logout", authHandler.Logout)
 	}
 
-	noteApi := router.Group("/notes", middleware.AuthMiddleware(authService))
+	// notes and tags work in the personal scope or in a workspace selected by the
+	// X-Workspace-ID header or by the /w/:workspace_id prefix
+	for _, root := range []*gin.RouterGroup{&router.RouterGroup, router.Group("/w/:workspace_id")} {
+		noteApi := root.Group("/notes", middleware.AuthMiddleware(authService), middleware.WorkspaceMiddleware(workspaceService))
+		{
+			noteApi.POST("", noteHandler.Create)
+			noteApi.GET("", noteHandler.List)
+			noteApi.GET("/search", noteHandler.Search)
+			noteApi.POST("/bulk/tags", noteHandler.UpdateTags)
+			noteApi.GET("/trash", noteHandler.ListTrash)
+			noteApi.GET("/shared-with-me", noteHandler.ListSharedWithMe)
+			noteApi.DELETE("/trash/:id", noteHandler.DeletePermanently)
+			noteApi.GET("/:id", noteHandler.GetByID)
+			noteApi.PATCH("/:id", noteHandler.UpdateByID)
+			noteApi.DELETE("/:id", noteHandler.DeleteByID)
+			noteApi.POST("/:id/restore", noteHandler.Restore)
+			noteApi.POST("/:id/grants", accessHandler.ShareNote)
+			noteApi.GET("/:id/grants", accessHandler.ListNoteGrants)
+			noteApi.POST("/:id/share", shareHandler.Create)
+			noteApi.GET("/:id/shares", shareHandler.ListByNote)
+			noteApi.POST("/:id/attachments", attachmentHandler.Upload)
+			noteApi.GET("/:id/attachments", attachmentHandler.ListByNote)
+			noteApi.POST("/:id/reminders", reminderHandler.Create)
+			noteApi.GET("/:id/reminders", reminderHandler.ListByNote)
+			noteApi.GET("/:id/tasks", noteHandler.ListTasks)
+			noteApi.PATCH("/:id/tasks/:index", noteHandler.SetTask)
+			noteApi.GET("/:id/links", noteHandler.ListLinks)
+			noteApi.GET("/:id/backlinks", noteHandler.ListBacklinks)
+			noteApi.GET("/:id/revisions", noteHandler.ListRevisions)
+			noteApi.GET("/:id/revisions/:rev", noteHandler.GetRevision)
+			noteApi.GET("/:id/revisions/:rev/diff", noteHandler.DiffRevision)
+			noteApi.POST("/:id/revisions/:rev/restore", noteHandler.RestoreRevision)
+		}
+
+		taskApi := root.Group("/tasks", middleware.AuthMiddleware(authService), middleware.WorkspaceMiddleware(workspaceService))
+		{
+			taskApi.GET("", noteHandler.ListAllTasks)
+		}
+
+		graphApi := root.Group("/graph", middleware.AuthMiddleware(authService), middleware.WorkspaceMiddleware(workspaceService))
+		{
+			graphApi.GET("", noteHandler.Graph)
+		}
+
+		tagApi := root.Group("/tags", middleware.AuthMiddleware(authService), middleware.WorkspaceMiddleware(workspaceService))
+		{
+			tagApi.POST("", tagHandler.Create)
+			tagApi.GET("", tagHandler.List)
+			tagApi.GET("/suggest", tagHandler.Suggest)
+			tagApi.GET("/:id", tagHandler.GetByID)
+			tagApi.PATCH("/:id", tagHandler.UpdateByID)
+			tagApi.DELETE("/:id", tagHandler.DeleteByID)
+			tagApi.POST("/:id/merge", tagHandler.Merge)
+			tagApi.POST("/:id/grants", accessHandler.ShareTag)
+			tagApi.GET("/:id/grants", accessHandler.ListTagGrants)
+		}
+	}
+
+	grantApi := router.Group("/grants", middleware.AuthMiddleware(authService))
+	{
+		grantApi.DELETE("/:id", accessHandler.Revoke)
+	}
+
+	shareApi := router.Group("/shares", middleware.AuthMiddleware(authService))
+	{
+		shareApi.GET("", shareHandler.List)
+		shareApi.DELETE("/:id", shareHandler.Revoke)
+	}
+	router.GET("/s/:token", shareHandler.Open)
+
+	attachmentApi := router.Group("/attachments", middleware.AuthMiddleware(authService))
+	{
+		attachmentApi.GET("/:id", attachmentHandler.Download)
+		attachmentApi.GET("/:id/thumb", attachmentHandler.Thumb)
+		attachmentApi.DELETE("/:id", attachmentHandler.DeleteByID)
+	}
+
+	reminderApi := router.Group("/reminders", middleware.AuthMiddleware(authService))
+	{
+		reminderApi.GET("", reminderHandler.List)
+		reminderApi.DELETE("/:id", reminderHandler.DeleteByID)
+	}
+
+	renderApi := router.Group("/render", middleware.AuthMiddleware(authService))
+	{
+		renderApi.POST("", noteHandler.Render)
+	}
+
+	workspaceApi := router.Group("/workspaces", middleware.AuthMiddleware(authService))
+	{
+		workspaceApi.POST("", workspaceHandler.Create)
+		workspaceApi.GET("", workspaceHandler.List)
+		workspaceApi.GET("/:id", workspaceHandler.GetByID)
+		workspaceApi.PATCH("/:id", workspaceHandler.Rename)
+		workspaceApi.DELETE("/:id", workspaceHandler.DeleteByID)
+		workspaceApi.GET("/:id/members", workspaceHandler.ListMembers)
+		workspaceApi.PATCH("/:id/members/:user_id", workspaceHandler.SetMemberRole)
+		workspaceApi.DELETE("/:id/members/:user_id", workspaceHandler.RemoveMember)
+		workspaceApi.POST("/:id/invitations", workspaceHandler.Invite)
+		workspaceApi.GET("/:id/invitations", workspaceHandler.ListInvites)
+		workspaceApi.DELETE("/:id/invitations/:invite_id", workspaceHandler.RevokeInvite)
+	}
+
+	invitationApi := router.Group("/invitations", middleware.AuthMiddleware(authService))
+	{
+		invitationApi.POST("/:token/accept", workspaceHandler.AcceptInvite)
+	}
+
+	adminApi := router.Group("/admin", middleware.AuthMiddleware(authService), middleware.AdminMiddleware(authService))
 	{
-		noteApi.POST("", noteHandler.Create)
-		noteApi.GET("", noteHandler.List)
-		noteApi.GET("/:id", noteHandler.GetByID)
-		noteApi.PATCH("/:id", noteHandler.UpdateByID)
-		noteApi.DELETE("/:id", noteHandler.DeleteByID)
+		adminApi.POST("/tags", adminTagHandler.Create)
+		adminApi.GET("/tags", adminTagHandler.List)
+		adminApi.GET("/tags/:id", adminTagHandler.GetByID)
+		adminApi.PATCH("/tags/:id", adminTagHandler.UpdateByID)
+		adminApi.DELETE("/tags/:id", adminTagHandler.DeleteByID)
+		adminApi.POST("/tags/:id/merge", adminTagHandler.Merge)
 	}
 
-	tagApi := router.Group("/tags", middleware.AuthMiddleware(authService))
+	notebookApi := router.Group("/notebooks", middleware.AuthMiddleware(authService))
 	{
-		tagApi.POST("", tagHandler.Create)
-		tagApi.GET("", tagHandler.List)
-		tagApi.GET("/:id", tagHandler.GetByID)
-		tagApi.PATCH("/:id", tagHandler.UpdateByID)
-		tagApi.DELETE("/:id", tagHandler.DeleteByID)
+		notebookApi.POST("", notebookHandler.Create)
+		notebookApi.GET("", notebookHandler.List)
+		notebookApi.GET("/:id", notebookHandler.GetByID)
+		notebookApi.PATCH("/:id", notebookHandler.UpdateByID)
+		notebookApi.DELETE("/:id", notebookHandler.DeleteByID)
 	}
 
 	return router
 }
+
+// newBlobStore picks the attachment storage configured by ATTACHMENT_STORAGE.
+func newBlobStore(ctx context.Context, cfg *config.Config) (storage.BlobStore, error) {
+	switch cfg.Attachment.Storage {
+	case "local":
+		return storage.NewLocalStore(cfg.Attachment.Dir)
+	case "s3":
+		return storage.NewS3Store(ctx, storage.S3Config{
+			Endpoint:  cfg.S3.Endpoint,
+			Region:    cfg.S3.Region,
+			Bucket:    cfg.S3.Bucket,
+			AccessKey: cfg.S3.AccessKey,
+			SecretKey: cfg.S3.SecretKey,
+			UseSSL:    cfg.S3.UseSSL,
+		})
+	default:
+		return nil, fmt.Errorf("unknown attachment storage %q", cfg.Attachment.Storage)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -24,13 +25,44 @@ const (
 	keyAuthRefreshTTL, defaultAuthRefreshTTL = "AUTH_REFRESH_TTL", "720h" // 30d
 	keyAuthSecret, defaultAuthSecret         = "AUTH_SECRET", "1420061f070b81aac84ceb449812770ab9d1f1d6b4c0aba33533ce6dde6f96fb"
 
+	keyTrashRetention, defaultTrashRetention         = "TRASH_RETENTION", "720h" // 30d
+	keyTrashPurgeInterval, defaultTrashPurgeInterval = "TRASH_PURGE_INTERVAL", "1h"
+
+	keyReminderPollInterval, defaultReminderPollInterval     = "REMINDER_POLL_INTERVAL", "30s"
+	keyReminderWebhookURL, defaultReminderWebhookURL         = "REMINDER_WEBHOOK_URL", "" // empty - reminders go to the log
+	keyReminderWebhookSecret, defaultReminderWebhookSecret   = "REMINDER_WEBHOOK_SECRET", ""
+	keyReminderWebhookTimeout, defaultReminderWebhookTimeout = "REMINDER_WEBHOOK_TIMEOUT", "10s"
+
+	keyAttachmentStorage, defaultAttachmentStorage                 = "ATTACHMENT_STORAGE", "local" // local | s3
+	keyAttachmentDir, defaultAttachmentDir                         = "ATTACHMENT_DIR", "data/attachments"
+	keyAttachmentMaxSize, defaultAttachmentMaxSize                 = "ATTACHMENT_MAX_SIZE", "10485760"    // 10 MiB
+	keyAttachmentQuota, defaultAttachmentQuota                     = "ATTACHMENT_USER_QUOTA", "104857600" // 100 MiB
+	keyAttachmentCleanupInterval, defaultAttachmentCleanupInterval = "ATTACHMENT_CLEANUP_INTERVAL", "10m"
+
+	keyThumbSizes, defaultThumbSizes               = "THUMB_SIZES", "128,256,512" // px, the longer side
+	keyThumbPollInterval, defaultThumbPollInterval = "THUMB_POLL_INTERVAL", "30s"
+
+	keyS3Endpoint, defaultS3Endpoint   = "S3_ENDPOINT", "localhost:9000"
+	keyS3Region, defaultS3Region       = "S3_REGION", "us-east-1"
+	keyS3Bucket, defaultS3Bucket       = "S3_BUCKET", "notebook"
+	keyS3AccessKey, defaultS3AccessKey = "S3_ACCESS_KEY", "minioadmin"
+	keyS3SecretKey, defaultS3SecretKey = "S3_SECRET_KEY", "minioadmin"
+	keyS3UseSSL, defaultS3UseSSL       = "S3_USE_SSL", "false"
+
+	keyWorkspaceInviteTTL, defaultWorkspaceInviteTTL = "WORKSPACE_INVITE_TTL", "168h" // 7d
+
 	LogDefaultValue = "%s is missing, using default value"
 )
 
 type Config struct {
-	HTTP HTTPConfig
-	Db   DbConfig
-	Auth AuthConfig
+	HTTP       HTTPConfig
+	Db         DbConfig
+	Auth       AuthConfig
+	Trash      TrashConfig
+	Reminder   ReminderConfig
+	Attachment AttachmentConfig
+	S3         S3Config
+	Workspace  WorkspaceConfig
 }
 
 type DbConfig struct {
@@ -56,6 +88,43 @@ type AuthConfig struct {
 	Secret     string
 }
 
+type TrashConfig struct {
+	Retention     time.Duration // how long deleted notes stay in trash
+	PurgeInterval time.Duration // how often expired notes are purged
+}
+
+type ReminderConfig struct {
+	PollInterval   time.Duration // how often due reminders are checked
+	WebhookURL     string        // where fired reminders are POSTed; empty - they are only logged
+	WebhookSecret  string        // signs webhook bodies, optional
+	WebhookTimeout time.Duration
+}
+
+type AttachmentConfig struct {
+	Storage         string // where contents are kept: "local" (Dir) or "s3"
+	Dir             string
+	MaxSize         int64 // bytes per file
+	Quota           int64 // bytes per user, 0 - unlimited
+	CleanupInterval time.Duration
+
+	ThumbSizes        []int // thumbnails of images are made in these sizes
+	ThumbPollInterval time.Duration
+}
+
+// S3Config points at an S3-compatible storage, e.g. a local MinIO.
+type S3Config struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	UseSSL    bool
+}
+
+type WorkspaceConfig struct {
+	InviteTTL time.Duration // how long an invitation can be accepted
+}
+
 func getEnv(key, defaultValue string) string {
 	value, ok := os.LookupEnv(key)
 	if !ok || value == "" {
@@ -83,6 +152,62 @@ func getEnvDuration(key, defaultValue string) time.Duration {
 	return d
 }
 
+func getEnvInt64(key, defaultValue string) int64 {
+	raw := getEnv(key, defaultValue)
+
+	if n, err := strconv.ParseInt(raw, 10, 64); err == nil && n >= 0 {
+		return n
+	}
+
+	log.Printf("invalid number for %s=%q, using default %s", key, raw, defaultValue)
+	n, err := strconv.ParseInt(defaultValue, 10, 64)
+	if err != nil {
+		log.Fatal("Cant parse number")
+	}
+	return n
+}
+
+func getEnvSizes(key, defaultValue string) []int {
+	parse := func(raw string) ([]int, bool) {
+		var sizes []int
+		for _, part := range strings.Split(raw, ",") {
+			n, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil || n <= 0 || n > 4096 {
+				return nil, false
+			}
+			sizes = append(sizes, n)
+		}
+		return sizes, true
+	}
+
+	raw := getEnv(key, defaultValue)
+	if sizes, ok := parse(raw); ok {
+		return sizes
+	}
+
+	log.Printf("invalid sizes for %s=%q, using default %s", key, raw, defaultValue)
+	sizes, ok := parse(defaultValue)
+	if !ok {
+		log.Fatal("Cant parse sizes")
+	}
+	return sizes
+}
+
+func getEnvBool(key, defaultValue string) bool {
+	raw := getEnv(key, defaultValue)
+
+	if b, err := strconv.ParseBool(raw); err == nil {
+		return b
+	}
+
+	log.Printf("invalid bool for %s=%q, using default %s", key, raw, defaultValue)
+	b, err := strconv.ParseBool(defaultValue)
+	if err != nil {
+		log.Fatal("Cant parse bool")
+	}
+	return b
+}
+
 func LoadConfig() *Config {
 	if err := godotenv.Load(); err != nil {
 		log.Printf("load .env: %v", err)
@@ -103,5 +228,30 @@ func LoadConfig() *Config {
 	cfg.Auth.RefreshTTL = getEnvDuration(keyAuthRefreshTTL, defaultAuthRefreshTTL)
 	cfg.Auth.Secret = getEnv(keyAuthSecret, defaultAuthSecret)
 
+	cfg.Trash.Retention = getEnvDuration(keyTrashRetention, defaultTrashRetention)
+	cfg.Trash.PurgeInterval = getEnvDuration(keyTrashPurgeInterval, defaultTrashPurgeInterval)
+
+	cfg.Reminder.PollInterval = getEnvDuration(keyReminderPollInterval, defaultReminderPollInterval)
+	cfg.Reminder.WebhookURL = getEnv(keyReminderWebhookURL, defaultReminderWebhookURL)
+	cfg.Reminder.WebhookSecret = getEnv(keyReminderWebhookSecret, defaultReminderWebhookSecret)
+	cfg.Reminder.WebhookTimeout = getEnvDuration(keyReminderWebhookTimeout, defaultReminderWebhookTimeout)
+
+	cfg.Attachment.Storage = getEnv(keyAttachmentStorage, defaultAttachmentStorage)
+	cfg.Attachment.Dir = getEnv(keyAttachmentDir, defaultAttachmentDir)
+	cfg.Attachment.MaxSize = getEnvInt64(keyAttachmentMaxSize, defaultAttachmentMaxSize)
+	cfg.Attachment.Quota = getEnvInt64(keyAttachmentQuota, defaultAttachmentQuota)
+	cfg.Attachment.CleanupInterval = getEnvDuration(keyAttachmentCleanupInterval, defaultAttachmentCleanupInterval)
+	cfg.Attachment.ThumbSizes = getEnvSizes(keyThumbSizes, defaultThumbSizes)
+	cfg.Attachment.ThumbPollInterval = getEnvDuration(keyThumbPollInterval, defaultThumbPollInterval)
+
+	cfg.S3.Endpoint = getEnv(keyS3Endpoint, defaultS3Endpoint)
+	cfg.S3.Region = getEnv(keyS3Region, defaultS3Region)
+	cfg.S3.Bucket = getEnv(keyS3Bucket, defaultS3Bucket)
+	cfg.S3.AccessKey = getEnv(keyS3AccessKey, defaultS3AccessKey)
+	cfg.S3.SecretKey = getEnv(keyS3SecretKey, defaultS3SecretKey)
+	cfg.S3.UseSSL = getEnvBool(keyS3UseSSL, defaultS3UseSSL)
+
+	cfg.Workspace.InviteTTL = getEnvDuration(keyWorkspaceInviteTTL, defaultWorkspaceInviteTTL)
+
 	return cfg
 }
@@ -0,0 +1,77 @@
+package markup
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Hashtags returns hashtags like #project-x found in text, lowercased, without "#"
+// and duplicates, in order of appearance. Their length is not limited here: whether a hashtag
+// makes a tag is decided by the tag name rules (model.CheckTagName). Fenced code blocks and inline code spans are skipped,
+// as are "#" inside words and URLs ("a#b", "/#anchor"), headings ("# Title") and tags without letters ("#123").
+func Hashtags(text string) []string {
+	var tags []string
+	seen := map[string]struct{}{}
+	eachProseLine(text, func(_ int, line string) {
+		for _, tag := range lineHashtags(line) {
+			if _, ok := seen[tag]; ok {
+				continue
+			}
+			seen[tag] = struct{}{}
+			tags = append(tags, tag)
+		}
+	})
+	return tags
+}
+
+// lineHashtags finds hashtags in a single line outside of inline code spans.
+func lineHashtags(line string) []string {
+	var tags []string
+	prev := rune(0)
+	for i := 0; i < len(line); {
+		r, size := utf8.DecodeRuneInString(line[i:])
+		switch {
+		case r == '`':
+			if end := codeSpanEnd(line, i); end > 0 {
+				i, prev = end, '`'
+				continue
+			}
+		case r == '#' && canStartHashtag(prev):
+			if tag, n := readHashtag(line[i+size:]); tag != "" {
+				tags = append(tags, tag)
+				i += size + n
+				prev = 0
+				continue
+			}
+		}
+		prev = r
+		i += size
+	}
+	return tags
+}
+
+// canStartHashtag reports whether "#" after prev begins a hashtag rather than being part of a word or URL.
+func canStartHashtag(prev rune) bool {
+	return prev == 0 || unicode.IsSpace(prev) || strings.ContainsRune(`([{<,;:!?"'*`, prev)
+}
+
+// readHashtag reads a hashtag body (letters, digits, "-" and "_") from the start of s
+// and returns it lowercased with the number of bytes consumed; "" if it is not a valid hashtag.
+func readHashtag(s string) (string, int) {
+	end := 0
+	hasLetter := false
+	for end < len(s) {
+		r, size := utf8.DecodeRuneInString(s[end:])
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_' {
+			break
+		}
+		hasLetter = hasLetter || unicode.IsLetter(r)
+		end += size
+	}
+	tag := strings.TrimRight(s[:end], "-_")
+	if !hasLetter || tag == "" {
+		return "", end
+	}
+	return strings.ToLower(tag), end
+}
@@ -0,0 +1,42 @@
+package markup
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Hashtags(t *testing.T) {
+	cases := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"empty", "", nil},
+		{"plain", "plan #work and #Home", []string{"work", "home"}},
+		{"duplicates", "#work #WORK #work", []string{"work"}},
+		{"dashes and underscores", "#project-x #snake_case", []string{"project-x", "snake_case"}},
+		{"trailing punctuation", "done: #work. #home, (#idea) #todo! #last-", []string{"work", "home", "idea", "todo", "last"}},
+		{"inline code span", "use `#define` and ``#x`` but #real", []string{"real"}},
+		{"unclosed code span", "a ` #open", []string{"open"}},
+		{"fenced block", "#before\n```\n#inside\n```\n#after", []string{"before", "after"}},
+		{"tilde fence", "~~~go\n#inside\n~~~\n#after", []string{"after"}},
+		{"indented fence", "  ```\n  #inside\n  ```", nil},
+		{"heading", "# Title\n## Sub #tag", []string{"tag"}},
+		{"heading without space", "#title", []string{"title"}},
+		{"url anchor", "see https://example.com/#anchor and page#section", nil},
+		{"inside word", "a#b c#d", nil},
+		{"digits only", "#123 #2024-01", nil},
+		{"digits with letters", "#2024plan", []string{"2024plan"}},
+		{"unicode", "#Заметки и #日本語 and #café", []string{"заметки", "日本語", "café"}},
+		{"after brackets and quotes", `[#one] "#two" *#three*`, []string{"one", "two", "three"}},
+		{"lone hash", "# and #  and #-", nil},
+		{"long", "#" + strings.Repeat("a", 60), []string{strings.Repeat("a", 60)}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, Hashtags(tc.text))
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package markup
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxLinkLen is the longest [[...]] reference in runes; titles of notes are shorter anyway.
+const maxLinkLen = 200
+
+// noteRefPrefix starts a reference to a note by id: [[note:123]].
+const noteRefPrefix = "note:"
+
+// WikiLinks returns references from [[Note Title]] and [[note:123]] links in text,
+// trimmed and without duplicates, in order of appearance. In [[Title|label]] only Title is the reference.
+// Links inside fenced code blocks and inline code spans are skipped.
+func WikiLinks(text string) []string {
+	var refs []string
+	seen := map[string]struct{}{}
+	eachProseLine(text, func(_ int, line string) {
+		for i := 0; i < len(line); {
+			switch {
+			case line[i] == '`':
+				if end := codeSpanEnd(line, i); end > 0 {
+					i = end
+					continue
+				}
+			case strings.HasPrefix(line[i:], "[["):
+				if ref, n := readWikiLink(line[i+2:]); n > 0 {
+					if _, ok := seen[ref]; !ok && ref != "" {
+						seen[ref] = struct{}{}
+						refs = append(refs, ref)
+					}
+					i += 2 + n
+					continue
+				}
+			}
+			i++
+		}
+	})
+	return refs
+}
+
+// readWikiLink reads a link body up to "]]" from the start of s and returns the reference
+// with the number of bytes consumed including "]]"; 0 if s does not hold a closed link.
+func readWikiLink(s string) (string, int) {
+	end := strings.Index(s, "]]")
+	if end < 0 {
+		return "", 0
+	}
+	body := s[:end]
+	if strings.ContainsAny(body, "[]") {
+		return "", 0
+	}
+	ref, _, _ := strings.Cut(body, "|")
+	ref = strings.Join(strings.Fields(ref), " ")
+	if utf8.RuneCountInString(ref) > maxLinkLen {
+		ref = ""
+	}
+	return ref, end + 2
+}
+
+// LinkNoteID returns the note id of a [[note:123]] reference; ok is false for references by title.
+func LinkNoteID(ref string) (id int64, ok bool) {
+	rest, found := strings.CutPrefix(ref, noteRefPrefix)
+	if !found {
+		return 0, false
+	}
+	id, err := strconv.ParseInt(strings.TrimSpace(rest), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,62 @@
+package markup
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_WikiLinks(t *testing.T) {
+	cases := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"empty", "", nil},
+		{"title", "see [[Shopping list]]", []string{"Shopping list"}},
+		{"by id", "see [[note:123]]", []string{"note:123"}},
+		{"id and title", "[[note:7]] and [[note 7]]", []string{"note:7", "note 7"}},
+		{"alias", "[[Shopping list|the list]]", []string{"Shopping list"}},
+		{"alias of id", "[[note:5|five]]", []string{"note:5"}},
+		{"spaces are collapsed", "[[  Shopping \t list  ]]", []string{"Shopping list"}},
+		{"duplicates", "[[a]] [[b]] [[ a ]] [[a|again]]", []string{"a", "b"}},
+		{"empty link", "[[]] [[ |alias]]", nil},
+		{"unclosed", "[[open and more text", nil},
+		{"unclosed before a link", "[[open [[Closed]]", []string{"Closed"}},
+		{"nested brackets", "[[a [b] c]]", nil},
+		{"link spans lines", "[[first\nline]]", nil},
+		{"inline code", "`[[Code]]` and [[Real]]", []string{"Real"}},
+		{"fenced block", "```\n[[Code]]\n```\n[[Real]]", []string{"Real"}},
+		{"several in a line", "[[one]][[two]]", []string{"one", "two"}},
+		{"too long", "[[" + strings.Repeat("a", maxLinkLen+1) + "]] [[ok]]", []string{"ok"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, WikiLinks(tc.text))
+		})
+	}
+}
+
+func Test_LinkNoteID(t *testing.T) {
+	cases := []struct {
+		ref  string
+		id   int64
+		isID bool
+	}{
+		{"note:123", 123, true},
+		{"note: 42", 42, true},
+		{"note:0", 0, false},
+		{"note:-1", 0, false},
+		{"note:abc", 0, false},
+		{"id:123", 0, false},
+		{"Shopping list", 0, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.ref, func(t *testing.T) {
+			id, ok := LinkNoteID(tc.ref)
+			require.Equal(t, tc.isID, ok)
+			require.Equal(t, tc.id, id)
+		})
+	}
+}
@@ -0,0 +1,62 @@
+// Package markup - helpers that read structure out of note text written in Markdown.
+package markup
+
+import "strings"
+
+// eachProseLine calls fn for every line of text outside fenced code blocks
+// with the line number counted from 0.
+func eachProseLine(text string, fn func(n int, line string)) {
+	fence := ""
+	for n, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimLeft(line, " \t")
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if f := codeFence(trimmed); f != "" {
+			fence = f
+			continue
+		}
+		fn(n, line)
+	}
+}
+
+// codeFence returns the opening fence ("```", "~~~" or longer) the line starts with, or "".
+func codeFence(line string) string {
+	for _, c := range []byte{'`', '~'} {
+		n := 0
+		for n < len(line) && line[n] == c {
+			n++
+		}
+		if n >= 3 {
+			return line[:n]
+		}
+	}
+	return ""
+}
+
+// codeSpanEnd returns the index after the code span that opens at start,
+// or 0 if the backtick run is never closed by a run of the same length.
+func codeSpanEnd(line string, start int) int {
+	n := 0
+	for start+n < len(line) && line[start+n] == '`' {
+		n++
+	}
+	for i := start + n; i < len(line); {
+		if line[i] != '`' {
+			i++
+			continue
+		}
+		m := 0
+		for i+m < len(line) && line[i+m] == '`' {
+			m++
+		}
+		if m == n {
+			return i + m
+		}
+		i += m
+	}
+	return 0
+}
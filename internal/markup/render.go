@@ -0,0 +1,74 @@
+package markup
+
+import (
+	"bytes"
+	"regexp"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+// Heading is an entry of the table of contents; ID is the anchor of the heading in the HTML.
+type Heading struct {
+	Level int
+	Text  string
+	ID    string
+}
+
+// Rendered is note text turned into HTML along with its table of contents.
+type Rendered struct {
+	HTML string
+	TOC  []Heading
+}
+
+// md renders CommonMark with GitHub extensions: tables, task lists, strikethrough and autolinks.
+// Raw HTML of the source is dropped, headings get ids to link to.
+var md = goldmark.New(
+	goldmark.WithExtensions(extension.GFM),
+	goldmark.WithParserOptions(parser.WithAutoHeadingID()),
+)
+
+// sanitizer keeps what md produces (heading ids, task list checkboxes, code languages)
+// and strips everything that could run scripts.
+var sanitizer = func() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[\w+#.-]+$`)).OnElements("code")
+	p.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
+	p.AllowAttrs("checked", "disabled").OnElements("input")
+	return p
+}()
+
+// Render converts Markdown to sanitized HTML and collects headings for a table of contents.
+func Render(source string) (*Rendered, error) {
+	src := []byte(source)
+	doc := md.Parser().Parse(text.NewReader(src))
+
+	toc := []Heading{}
+	err := ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		h, ok := n.(*ast.Heading)
+		if !ok || !entering {
+			return ast.WalkContinue, nil
+		}
+		heading := Heading{Level: h.Level, Text: string(h.Text(src))}
+		if id, ok := h.AttributeString("id"); ok {
+			if b, ok := id.([]byte); ok {
+				heading.ID = string(b)
+			}
+		}
+		toc = append(toc, heading)
+		return ast.WalkSkipChildren, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := md.Renderer().Render(&buf, src, doc); err != nil {
+		return nil, err
+	}
+	return &Rendered{HTML: sanitizer.Sanitize(buf.String()), TOC: toc}, nil
+}
@@ -0,0 +1,105 @@
+package markup
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Render_Sanitizes(t *testing.T) {
+	cases := []struct {
+		name    string
+		source  string
+		absent  []string
+		present []string
+	}{
+		{
+			name:    "raw script",
+			source:  "hello\n\n<script>alert(1)</script>\n\nworld",
+			absent:  []string{"<script", "alert(1)"},
+			present: []string{"<p>hello</p>", "<p>world</p>"},
+		},
+		{
+			name:   "inline event handler",
+			source: `<img src="x" onerror="alert(1)"> and <b onclick="alert(1)">bold</b>`,
+			absent: []string{"onerror", "onclick", "<img"},
+		},
+		{
+			name:    "javascript link",
+			source:  "[click](javascript:alert(1)) and <javascript:alert(1)>",
+			absent:  []string{"href", "<a"},
+			present: []string{"click"},
+		},
+		{
+			name:   "image with javascript src",
+			source: "![x](javascript:alert(1))",
+			absent: []string{"javascript:", "src="},
+		},
+		{
+			name:    "safe link",
+			source:  "[site](https://example.com)",
+			present: []string{`href="https://example.com"`, `rel="nofollow"`},
+		},
+		{
+			name:    "code language class",
+			source:  "```go\nfmt.Println(1)\n```",
+			present: []string{`<code class="language-go">`},
+		},
+		{
+			name:   "other classes",
+			source: "```go onclick=x\nx\n```\n\n`code`",
+			absent: []string{"onclick"},
+		},
+		{
+			name:    "task list",
+			source:  "- [x] done\n- [ ] todo",
+			present: []string{`<input checked="" disabled="" type="checkbox"`, `<input disabled="" type="checkbox"`},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := Render(tc.source)
+			require.NoError(t, err)
+			for _, s := range tc.absent {
+				require.NotContains(t, r.HTML, s)
+			}
+			for _, s := range tc.present {
+				require.Contains(t, r.HTML, s)
+			}
+		})
+	}
+}
+
+func Test_Render_Classes(t *testing.T) {
+	r, err := Render("```go\nx\n```")
+	require.NoError(t, err)
+	require.Contains(t, r.HTML, `class="language-go"`)
+
+	// only language-* classes on code survive, even if the renderer produced others
+	html := sanitizer.Sanitize(`<code class="evil">x</code><code class="language-c++">y</code><p class="language-go">z</p>`)
+	require.Equal(t, `<code>x</code><code class="language-c++">y</code><p>z</p>`, html)
+}
+
+func Test_Render_Checkboxes(t *testing.T) {
+	html := sanitizer.Sanitize(`<input type="text" value="x"><input type="checkbox" disabled="" onclick="x">`)
+	require.NotContains(t, html, `type="text"`)
+	require.NotContains(t, html, "onclick")
+	require.Contains(t, html, `<input type="checkbox" disabled="">`)
+}
+
+func Test_Render_TOC(t *testing.T) {
+	r, err := Render("# Intro\n\ntext\n\n## Setup *fast*\n\n### Intro\n\n```\n# not a heading\n```")
+	require.NoError(t, err)
+	require.Equal(t, []Heading{
+		{Level: 1, Text: "Intro", ID: "intro"},
+		{Level: 2, Text: "Setup fast", ID: "setup-fast"},
+		{Level: 3, Text: "Intro", ID: "intro-1"},
+	}, r.TOC)
+	for _, h := range r.TOC {
+		require.Contains(t, r.HTML, `id="`+h.ID+`"`)
+	}
+
+	r, err = Render("no headings")
+	require.NoError(t, err)
+	require.Empty(t, r.TOC)
+}
@@ -0,0 +1,49 @@
+package markup
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Task is a checklist item like "- [ ] buy milk" or "1. [x] done".
+// Index counts tasks of the text from 0, Line counts lines from 1.
+type Task struct {
+	Index int
+	Line  int
+	Text  string
+	Done  bool
+}
+
+// taskRe matches a list item with a checkbox; groups: prefix up to "[", mark, item text.
+var taskRe = regexp.MustCompile(`^(\s*(?:[-*+]|\d{1,9}[.)])\s+\[)([ xX])\](?:\s+(.*?))?\s*$`)
+
+// Tasks returns checklist items of text in order, skipping fenced code blocks.
+func Tasks(text string) []Task {
+	tasks := []Task{}
+	eachProseLine(text, func(n int, line string) {
+		m := taskRe.FindStringSubmatch(strings.TrimRight(line, "\r"))
+		if m == nil {
+			return
+		}
+		tasks = append(tasks, Task{Index: len(tasks), Line: n + 1, Text: m[3], Done: m[2] != " "})
+	})
+	return tasks
+}
+
+// SetTask marks the task with the index as done or not done and returns the new text;
+// everything else is left byte for byte. ok is false if there is no such task.
+func SetTask(text string, index int, done bool) (string, bool) {
+	tasks := Tasks(text)
+	if index < 0 || index >= len(tasks) {
+		return text, false
+	}
+	lines := strings.Split(text, "\n")
+	line := lines[tasks[index].Line-1]
+	loc := taskRe.FindStringSubmatchIndex(strings.TrimRight(line, "\r"))
+	mark := " "
+	if done {
+		mark = "x"
+	}
+	lines[tasks[index].Line-1] = line[:loc[4]] + mark + line[loc[5]:]
+	return strings.Join(lines, "\n"), true
+}
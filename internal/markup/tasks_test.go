@@ -0,0 +1,72 @@
+package markup
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Tasks(t *testing.T) {
+	cases := []struct {
+		name string
+		text string
+		want []Task
+	}{
+		{"empty", "", []Task{}},
+		{
+			name: "bullets and numbers",
+			text: "- [ ] one\n* [x] two\n+ [X] three\n1. [ ] four\n2) [x] five",
+			want: []Task{
+				{Index: 0, Line: 1, Text: "one"},
+				{Index: 1, Line: 2, Text: "two", Done: true},
+				{Index: 2, Line: 3, Text: "three", Done: true},
+				{Index: 3, Line: 4, Text: "four"},
+				{Index: 4, Line: 5, Text: "five", Done: true},
+			},
+		},
+		{
+			name: "nested and empty items",
+			text: "  - [ ] nested  \r\n- [x]",
+			want: []Task{{Index: 0, Line: 1, Text: "nested"}, {Index: 1, Line: 2, Done: true}},
+		},
+		{
+			name: "fenced block is skipped",
+			text: "```\n- [ ] code\n```\n- [ ] real\n~~~\n- [x] code\n~~~",
+			want: []Task{{Index: 0, Line: 4, Text: "real"}},
+		},
+		{
+			name: "not tasks",
+			text: "[ ] no bullet\n- [] no space\n- [y] other mark\n-[ ] no gap\ntext - [ ] inside",
+			want: []Task{},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, Tasks(tc.text))
+		})
+	}
+}
+
+func Test_SetTask(t *testing.T) {
+	const text = "# list\n- [ ] one\n```\n- [ ] code\n```\n* [X] two\r\n3. [x] three"
+	cases := []struct {
+		name  string
+		index int
+		done  bool
+		want  string
+		ok    bool
+	}{
+		{"check", 0, true, "# list\n- [x] one\n```\n- [ ] code\n```\n* [X] two\r\n3. [x] three", true},
+		{"uncheck uppercase", 1, false, "# list\n- [ ] one\n```\n- [ ] code\n```\n* [ ] two\r\n3. [x] three", true},
+		{"check already done", 2, true, "# list\n- [ ] one\n```\n- [ ] code\n```\n* [X] two\r\n3. [x] three", true},
+		{"negative index", -1, true, text, false},
+		{"index out of range", 3, true, text, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := SetTask(text, tc.index, tc.done)
+			require.Equal(t, tc.ok, ok)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package model
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// Role is what a user may do with a note.
+type Role string
+
+const (
+	RoleViewer Role = "viewer" // reads the note
+	RoleEditor Role = "editor" // also changes its title, text and checklist
+	RoleOwner  Role = "owner"  // everything, including deleting, organizing and sharing
+)
+
+var roleRank = map[Role]int{RoleViewer: 1, RoleEditor: 2, RoleOwner: 3}
+
+// Allows reports whether the role includes everything need does.
+func (r Role) Allows(need Role) bool {
+	return roleRank[r] >= roleRank[need] && roleRank[need] > 0
+}
+
+// AccessGrant gives another user a role on a single note or on all notes of the owner
+// that carry a tag. Exactly one of NoteID and TagID is set.
+type AccessGrant struct {
+	bun.BaseModel `bun:"table:access_grants,alias:access_grant"`
+	ID            int64     `json:"id" bun:"id,pk,autoincrement"`
+	CreatedAt     time.Time `json:"created_at" bun:"created_at,notnull,nullzero,default:current_timestamp"`
+	OwnerID       int64     `json:"owner_id" bun:"owner_id,notnull"`
+	GranteeID     int64     `json:"grantee_id" bun:"grantee_id,notnull"`
+	NoteID        int64     `json:"note_id" bun:"note_id,nullzero"`
+	TagID         int64     `json:"tag_id" bun:"tag_id,nullzero"`
+	Role          Role      `json:"role" bun:"role,notnull"` // viewer or editor
+
+	GranteeEmail string `json:"grantee_email" bun:"grantee_email,scanonly"`
+	GranteeName  string `json:"grantee_name" bun:"grantee_name,scanonly"`
+}
+
+// SharedNote is a note of another user the current user has access to.
+type SharedNote struct {
+	Note       *Note
+	Role       Role
+	OwnerEmail string
+	OwnerName  string
+}
@@ -0,0 +1,47 @@
+package model
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// Attachment is a file attached to a note. The content lives in a blob store under StorageKey;
+// the table keeps only its metadata.
+type Attachment struct {
+	bun.BaseModel `bun:"table:attachments,alias:attachment"`
+	ID            int64     `json:"id" bun:"id,pk,autoincrement"`
+	CreatedAt     time.Time `json:"created_at" bun:"created_at,notnull,nullzero,default:current_timestamp"`
+	NoteID        int64     `json:"note_id" bun:"note_id,nullzero"` // 0 - detached, the blob awaits cleanup
+	UserID        int64     `json:"user_id" bun:"user_id,notnull"`
+	Name          string    `json:"name" bun:"name,notnull"`
+	ContentType   string    `json:"content_type" bun:"content_type,notnull"` // sniffed from the content, not taken from the client
+	Size          int64     `json:"size" bun:"size,notnull"`
+	SHA256        string    `json:"sha256" bun:"sha256,notnull"` // hex digest of the content
+	StorageKey    string    `json:"-" bun:"storage_key,notnull"`
+	ThumbStatus   string    `json:"thumb_status" bun:"thumb_status,notnull,nullzero,default:'none'"`
+	ThumbAttempts int       `json:"-" bun:"thumb_attempts,notnull"` // failed tries of the thumbnail worker
+
+	Thumbs []*AttachmentThumb `json:"-" bun:"rel:has-many,join:id=attachment_id"`
+}
+
+// Thumbnail states of an attachment: only images get thumbnails, a background worker
+// makes them for pending ones.
+const (
+	ThumbNone    = "none"
+	ThumbPending = "pending"
+	ThumbReady   = "ready"
+	ThumbFailed  = "failed"
+)
+
+// AttachmentThumb is a preview of an image attachment that fits into a Size x Size square.
+type AttachmentThumb struct {
+	bun.BaseModel `bun:"table:attachment_thumbs,alias:thumb"`
+	AttachmentID  int64     `json:"attachment_id" bun:"attachment_id,pk"`
+	Size          int       `json:"size" bun:"size,pk"`
+	CreatedAt     time.Time `json:"created_at" bun:"created_at,notnull,nullzero,default:current_timestamp"`
+	ContentType   string    `json:"content_type" bun:"content_type,notnull"`
+	ByteSize      int64     `json:"byte_size" bun:"byte_size,notnull"`
+	SHA256        string    `json:"sha256" bun:"sha256,notnull"`
+	StorageKey    string    `json:"-" bun:"storage_key,notnull"`
+}
@@ -19,6 +19,12 @@ var (
 	ErrTagAlreadyExists   = errors.New("tag already exists")
 	ErrBadRequest         = errors.New("bad request")
 	ErrWrongCredentials   = errors.New("wrong credentials")
+	ErrPreconditionFailed = errors.New("precondition failed")
+	ErrTooLarge           = errors.New("too large")
+	ErrUnsupportedType    = errors.New("unsupported media type")
+	ErrQuotaExceeded      = errors.New("quota exceeded")
+	ErrPasswordRequired   = errors.New("password required")
+	ErrTooManyAttempts    = errors.New("too many attempts")
 )
 
 var tagMsg = map[string]string{
@@ -27,6 +33,7 @@ var tagMsg = map[string]string{
 	"max":      "maximum %s character(s)",
 	"len":      "exactly %s character(s)",
 	"email":    "invalid email",
+	"excludes": "must not contain \"%s\"",
 }
 
 type ValidationError struct {
@@ -57,8 +64,20 @@ func ToHTTP(err error) (int, PublicError) {
 		return http.StatusNotFound, PublicError{Code: "not_found", Message: "Resource not found"}
 	case errors.Is(err, ErrConflict):
 		return http.StatusConflict, PublicError{Code: "conflict", Message: "State conflict"}
+	case errors.Is(err, ErrPreconditionFailed):
+		return http.StatusPreconditionFailed, PublicError{Code: "precondition_failed", Message: "Resource was modified, reload it and retry"}
+	case errors.Is(err, ErrTooLarge):
+		return http.StatusRequestEntityTooLarge, PublicError{Code: "too_large", Message: "File is too large"}
+	case errors.Is(err, ErrUnsupportedType):
+		return http.StatusUnsupportedMediaType, PublicError{Code: "unsupported_type", Message: "File type is not supported"}
+	case errors.Is(err, ErrQuotaExceeded):
+		return http.StatusRequestEntityTooLarge, PublicError{Code: "quota_exceeded", Message: "Storage quota exceeded"}
 	case errors.Is(err, ErrBadRequest):
 		return http.StatusBadRequest, PublicError{Code: "bad_request", Message: "Bad request"}
+	case errors.Is(err, ErrPasswordRequired):
+		return http.StatusUnauthorized, PublicError{Code: "password_required", Message: "Missing or wrong password"}
+	case errors.Is(err, ErrTooManyAttempts):
+		return http.StatusTooManyRequests, PublicError{Code: "too_many_attempts", Message: "Too many attempts, try again later"}
 	case errors.Is(err, ErrWrongCredentials):
 		return http.StatusUnauthorized, PublicError{Code: "wrong_credentials", Message: "Invalid email or password"}
 	default:
@@ -99,6 +118,9 @@ func jsonFieldName(obj any, structField string) string {
 	}
 	if f, ok := t.FieldByName(structField); ok {
 		tag := f.Tag.Get("json")
+		if tag == "" {
+			tag = f.Tag.Get("form") // query params
+		}
 		if tag == "" || tag == "-" {
 			return strings.ToLower(structField)
 		}
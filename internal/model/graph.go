@@ -0,0 +1,11 @@
+package model
+
+// NoteGraph is a set of user's notes with their tags and the links between them,
+// loaded at once so a client can draw a knowledge graph.
+// Notes have only ID and Title filled; Links hold only links between notes of the set.
+type NoteGraph struct {
+	Notes    []Note
+	Tags     []Tag
+	NoteTags []NoteTag
+	Links    []NoteLink
+}
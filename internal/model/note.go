@@ -11,10 +11,92 @@ type Note struct {
 	ID            int64     `json:"id" bun:"id,pk,autoincrement"`
 	CreatedAt     time.Time `json:"created_at" bun:"created_at,notnull,nullzero,default:current_timestamp"`
 	UpdatedAt     time.Time `json:"updated_at" bun:"updated_at,notnull,nullzero,default:current_timestamp"`
+	DeletedAt     time.Time `json:"deleted_at" bun:"deleted_at,soft_delete,nullzero"` // set while the note is in trash
+	Version       int64     `json:"version" bun:"version,notnull,nullzero,default:1"` // grows by one on every update
 	Title         string    `json:"title" bun:"title,notnull"`
 	Text          string    `json:"text" bun:"text,"`
+	Pinned        bool      `json:"pinned" bun:"pinned,notnull"`
+	Archived      bool      `json:"archived" bun:"archived,notnull"`
+	Favorite      bool      `json:"favorite" bun:"favorite,notnull"`
+	AutoTags      bool      `json:"auto_tags" bun:"auto_tags,notnull"` // keep tags in sync with hashtags in Text
 
 	Tags []*Tag `bun:"m2m:notes_tags,join:Note=Tag"`
 
-	UserID int64 `json:"user_id" bun:"user_id,notnull"`
+	NotebookID  int64 `json:"notebook_id" bun:"notebook_id,nullzero"`   // 0 - not in a notebook
+	UserID      int64 `json:"user_id" bun:"user_id,notnull"`            // the author for notes of a workspace
+	WorkspaceID int64 `json:"workspace_id" bun:"workspace_id,nullzero"` // 0 - a personal note
+}
+
+// NoteUpdate is a partial update of a note; nil fields are left unchanged.
+// IfVersion, when non-zero, makes the update fail with ErrPreconditionFailed
+// unless the note still has this version.
+type NoteUpdate struct {
+	Title  *string
+	Text   *string
+	TagIDs *[]int64
+	// TagNames are tag paths attached along with TagIDs; missing root tags are created.
+	// Setting either TagIDs or TagNames replaces all tags of the note.
+	TagNames *[]string
+	Pinned   *bool
+	Archived *bool
+	Favorite *bool
+	// NotebookID moves the note to another notebook; 0 takes it out of any notebook.
+	NotebookID *int64
+	// AutoTags turns syncing of tags with hashtags in the text on or off.
+	// Turning it off keeps already extracted tags as manual ones.
+	AutoTags *bool
+	// KeepAutoTags leaves auto tags as they are even when the text changes: hashtags typed
+	// by another user must not create tags of the note's owner.
+	KeepAutoTags bool
+	IfVersion    int64
+}
+
+// ChangesContent reports whether the update touches title, text or tags (auto tags included),
+// i.e. whether the previous state is worth keeping as a revision.
+func (u *NoteUpdate) ChangesContent() bool {
+	return u.Title != nil || u.Text != nil || u.TagIDs != nil || u.TagNames != nil || u.AutoTags != nil
+}
+
+// TagMatchMode defines how NoteFilter.TagIDs are matched against note tags.
+type TagMatchMode string
+
+const (
+	TagMatchAny TagMatchMode = "any" // note has at least one of the tags
+	TagMatchAll TagMatchMode = "all" // note has every tag
+)
+
+// NoteFilter narrows and pages the list of user's notes.
+// Zero values mean "not set"; nil flags match notes in any state.
+// Cursor, when set, continues a previous page and takes precedence over Offset.
+type NoteFilter struct {
+	Limit  int
+	Offset int
+	Sort   Sort
+	Cursor string
+
+	Pinned   *bool
+	Archived *bool
+	Favorite *bool
+
+	// NotebookID limits notes to the notebook; with Recursive also to its sub-notebooks.
+	NotebookID int64
+	Recursive  bool
+
+	TagIDs        []int64
+	TagMode       TagMatchMode
+	ExcludeTagIDs []int64
+	// TagDescendants makes TagIDs and ExcludeTagIDs also match their descendant tags.
+	TagDescendants bool
+
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	UpdatedAfter  time.Time
+	UpdatedBefore time.Time
+}
+
+// NoteSearchHit is a note matched by full-text search with its rank and highlighted snippet.
+type NoteSearchHit struct {
+	Note    *Note
+	Rank    float64
+	Snippet string
 }
@@ -0,0 +1,18 @@
+package model
+
+import "github.com/uptrace/bun"
+
+// NoteLink is a [[Note Title]] or [[note:123]] reference from the text of one note to another.
+// Once resolved, the link keeps pointing to the same note even if it gets renamed.
+type NoteLink struct {
+	bun.BaseModel `bun:"table:note_links,alias:note_link"`
+	SourceID      int64  `json:"source_id" bun:"source_id,pk"`
+	Ref           string `json:"ref" bun:"ref,pk"`                   // as written in the text, without brackets
+	TargetID      int64  `json:"target_id" bun:"target_id,nullzero"` // 0 - dangling, no such note
+
+	SourceTitle string `json:"source_title" bun:"source_title,scanonly"` // filled only for backlinks
+	TargetTitle string `json:"target_title" bun:"target_title,scanonly"` // filled only for links of a note
+}
+
+// Dangling reports whether the link points to no (visible) note.
+func (l NoteLink) Dangling() bool { return l.TargetID == 0 }
@@ -0,0 +1,20 @@
+package model
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// NoteRevision is a snapshot of note content taken right before an update overwrote it.
+// Rev numbers start at 1 and grow by one per update of the note.
+type NoteRevision struct {
+	bun.BaseModel `bun:"table:note_revisions"`
+	ID            int64     `json:"id" bun:"id,pk,autoincrement"`
+	NoteID        int64     `json:"note_id" bun:"note_id,notnull"`
+	Rev           int       `json:"rev" bun:"rev,notnull"`
+	Title         string    `json:"title" bun:"title,notnull"`
+	Text          string    `json:"text" bun:"text,"`
+	TagIDs        []int64   `json:"tag_ids" bun:"tag_ids,array"`
+	CreatedAt     time.Time `json:"created_at" bun:"created_at,notnull,nullzero,default:current_timestamp"`
+}
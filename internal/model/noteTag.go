@@ -8,4 +8,11 @@ type NoteTag struct {
 	Note          *Note `bun:"rel:belongs-to,join:note_id=id"`
 	TagID         int64 `json:"tag_id" bun:"tag_id,pk"`
 	Tag           *Tag  `bun:"rel:belongs-to,join:tag_id=id"`
+	// Source tells how the tag got attached: TagSourceManual or TagSourceAuto.
+	Source string `json:"source" bun:"source,notnull,nullzero,default:'manual'"`
 }
+
+const (
+	TagSourceManual = "manual" // attached by the user
+	TagSourceAuto   = "auto"   // extracted from a hashtag in the note text
+)
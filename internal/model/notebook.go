@@ -0,0 +1,34 @@
+package model
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// Notebook is a folder for notes; notebooks nest through ParentID (0 - root level).
+type Notebook struct {
+	bun.BaseModel `bun:"table:notebooks"`
+	ID            int64     `json:"id" bun:"id,pk,autoincrement"`
+	CreatedAt     time.Time `json:"created_at" bun:"created_at,notnull,nullzero,default:current_timestamp"`
+	UpdatedAt     time.Time `json:"updated_at" bun:"updated_at,notnull,nullzero,default:current_timestamp"`
+	Name          string    `json:"name" bun:"name,notnull"`
+	ParentID      int64     `json:"parent_id" bun:"parent_id,nullzero"`
+
+	UserID int64 `json:"user_id" bun:"user_id,notnull"`
+}
+
+// NotebookUpdate is a partial update of a notebook; nil fields are left unchanged.
+type NotebookUpdate struct {
+	Name *string
+	// ParentID moves the notebook with its sub-notebooks under another notebook; 0 moves it to the root.
+	ParentID *int64
+}
+
+// NotebookDeletePolicy defines what happens to notes of a deleted notebook and its sub-notebooks.
+type NotebookDeletePolicy string
+
+const (
+	NotebookDeleteMoveToRoot NotebookDeletePolicy = "move_to_root" // notes stay, without a notebook
+	NotebookDeleteTrash      NotebookDeletePolicy = "trash"        // notes are moved to trash
+)
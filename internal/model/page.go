@@ -0,0 +1,9 @@
+package model
+
+// Page is one page of a keyset-paginated list.
+// NextCursor is empty when the sort order cannot be used for cursor pagination.
+type Page[T any] struct {
+	Items      []T
+	NextCursor string
+	HasMore    bool
+}
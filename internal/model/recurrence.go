@@ -0,0 +1,222 @@
+package model
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequency is how often a recurring reminder repeats.
+type Frequency string
+
+const (
+	FreqDaily   Frequency = "DAILY"
+	FreqWeekly  Frequency = "WEEKLY"
+	FreqMonthly Frequency = "MONTHLY"
+)
+
+// Recurrence is the supported subset of RFC 5545 RRULE: FREQ (DAILY, WEEKLY, MONTHLY),
+// INTERVAL, BYDAY (weekly only), COUNT and UNTIL. Weeks start on Monday.
+type Recurrence struct {
+	Freq     Frequency
+	Interval int            // repeat every Interval days, weeks or months; at least 1
+	ByDay    []time.Weekday // weekly only; empty means the weekday of the first occurrence
+	Count    int            // total number of occurrences, 0 - unlimited
+	Until    time.Time      // no occurrences after it, zero - unlimited
+	// UntilDate marks an UNTIL given as a date: occurrences on that whole day count,
+	// in the time zone of the series.
+	UntilDate bool
+}
+
+var rruleDays = map[string]time.Weekday{
+	"MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday, "TH": time.Thursday,
+	"FR": time.Friday, "SA": time.Saturday, "SU": time.Sunday,
+}
+
+// rruleUntilLayouts are the accepted forms of UNTIL: UTC date-time or a date.
+var rruleUntilLayouts = []string{"20060102T150405Z", "20060102"}
+
+// ParseRecurrence parses "daily", "weekly", "monthly" or an RRULE like
+// "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR;COUNT=10" (an "RRULE:" prefix is allowed).
+// An empty rule gives nil: the reminder fires once. Errors are ValidationErrors for the "rule" field.
+func ParseRecurrence(rule string) (*Recurrence, error) {
+	rule = strings.ToUpper(strings.TrimSpace(rule))
+	if rule == "" {
+		return nil, nil
+	}
+	switch Frequency(rule) {
+	case FreqDaily, FreqWeekly, FreqMonthly:
+		return &Recurrence{Freq: Frequency(rule), Interval: 1}, nil
+	}
+
+	invalid := func(format string, args ...any) error {
+		return &ValidationError{Fields: map[string]string{"rule": fmt.Sprintf(format, args...)}}
+	}
+	r := &Recurrence{Interval: 1}
+	for _, part := range strings.Split(strings.TrimPrefix(rule, "RRULE:"), ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return nil, invalid("invalid part %s", part)
+		}
+		switch key {
+		case "FREQ":
+			r.Freq = Frequency(value)
+			if r.Freq != FreqDaily && r.Freq != FreqWeekly && r.Freq != FreqMonthly {
+				return nil, invalid("FREQ must be one of: DAILY, WEEKLY, MONTHLY")
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 || n > 1000 {
+				return nil, invalid("INTERVAL must be between 1 and 1000")
+			}
+			r.Interval = n
+		case "BYDAY":
+			for _, d := range strings.Split(value, ",") {
+				day, ok := rruleDays[d]
+				if !ok {
+					return nil, invalid("unknown BYDAY day %s", d)
+				}
+				if !slices.Contains(r.ByDay, day) {
+					r.ByDay = append(r.ByDay, day)
+				}
+			}
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, invalid("COUNT must be a positive number")
+			}
+			r.Count = n
+		case "UNTIL":
+			for i, layout := range rruleUntilLayouts {
+				if t, err := time.Parse(layout, value); err == nil {
+					r.Until, r.UntilDate = t, i == 1
+					break
+				}
+			}
+			if r.Until.IsZero() {
+				return nil, invalid("UNTIL must look like 20250131T000000Z or 20250131")
+			}
+		default:
+			return nil, invalid("%s is not supported", key)
+		}
+	}
+	switch {
+	case r.Freq == "":
+		return nil, invalid("FREQ is required")
+	case len(r.ByDay) > 0 && r.Freq != FreqWeekly:
+		return nil, invalid("BYDAY is supported only with FREQ=WEEKLY")
+	case r.Count > 0 && !r.Until.IsZero():
+		return nil, invalid("COUNT and UNTIL can't be used together")
+	}
+	return r, nil
+}
+
+// String formats the recurrence as an RRULE without defaults, e.g. "FREQ=WEEKLY;BYDAY=MO,FR".
+func (r *Recurrence) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, 0, len(r.ByDay))
+		for _, d := range r.ByDay {
+			days = append(days, strings.ToUpper(d.String()[:2]))
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	switch {
+	case r.UntilDate:
+		parts = append(parts, "UNTIL="+r.Until.Format(rruleUntilLayouts[1]))
+	case !r.Until.IsZero():
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format(rruleUntilLayouts[0]))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Next returns the first occurrence strictly after the given time for a series that starts at start,
+// or zero time if the series ends before that (COUNT is left to the caller, who knows how many fired).
+// Occurrences keep the wall clock time of start in its location, so a daily 09:00 stays 09:00 across DST.
+func (r *Recurrence) Next(start, after time.Time) time.Time {
+	if after.Before(start) {
+		return start
+	}
+	var next time.Time
+	switch r.Freq {
+	case FreqDaily:
+		next = r.nextDaily(start, after)
+	case FreqWeekly:
+		next = r.nextWeekly(start, after)
+	case FreqMonthly:
+		next = r.nextMonthly(start, after)
+	}
+	if !r.Until.IsZero() && r.afterUntil(next) {
+		return time.Time{}
+	}
+	return next
+}
+
+// afterUntil reports whether an occurrence at t is past UNTIL; a date-only UNTIL
+// takes in the whole day in the location of t.
+func (r *Recurrence) afterUntil(t time.Time) bool {
+	if r.UntilDate {
+		return civilDays(t) > civilDays(r.Until)
+	}
+	return t.After(r.Until)
+}
+
+func (r *Recurrence) nextDaily(start, after time.Time) time.Time {
+	k := int(after.Sub(start).Hours()/24) / r.Interval
+	for k > 0 && start.AddDate(0, 0, k*r.Interval).After(after) {
+		k--
+	}
+	for {
+		if next := start.AddDate(0, 0, k*r.Interval); next.After(after) {
+			return next
+		}
+		k++
+	}
+}
+
+func (r *Recurrence) nextWeekly(start, after time.Time) time.Time {
+	byDay := r.ByDay
+	if len(byDay) == 0 {
+		byDay = []time.Weekday{start.Weekday()}
+	}
+	// weeks are counted from the Monday of the first occurrence
+	firstMonday := civilDays(start) - (int(start.Weekday())+6)%7
+	from := after.In(start.Location())
+	for i := 0; i <= 7*r.Interval; i++ {
+		day := time.Date(from.Year(), from.Month(), from.Day()+i,
+			start.Hour(), start.Minute(), start.Second(), start.Nanosecond(), start.Location())
+		week := (civilDays(day) - firstMonday) / 7
+		if week%r.Interval == 0 && slices.Contains(byDay, day.Weekday()) && day.After(after) && !day.Before(start) {
+			return day
+		}
+	}
+	return time.Time{}
+}
+
+func (r *Recurrence) nextMonthly(start, after time.Time) time.Time {
+	from := after.In(start.Location())
+	months := (from.Year()-start.Year())*12 + int(from.Month()-start.Month())
+	k := max(months/r.Interval-1, 0)
+	// months without the day of start (e.g. the 31st) are skipped, as RRULE does; 48 tries cover any interval
+	for tries := 0; tries < 48; tries++ {
+		next := start.AddDate(0, k*r.Interval, 0)
+		if next.Day() == start.Day() && next.After(after) {
+			return next
+		}
+		k++
+	}
+	return time.Time{}
+}
+
+// civilDays is the number of days from the zero date to the calendar date of t in its location.
+func civilDays(t time.Time) int {
+	return int(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC).Unix() / 86400)
+}
@@ -0,0 +1,114 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseRecurrence(t *testing.T) {
+	cases := []struct {
+		rule    string
+		want    string // canonical form, "" for no recurrence
+		wantErr bool
+	}{
+		{rule: ""},
+		{rule: "daily", want: "FREQ=DAILY"},
+		{rule: " Weekly ", want: "FREQ=WEEKLY"},
+		{rule: "RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR,MO;COUNT=10", want: "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR;COUNT=10"},
+		{rule: "FREQ=MONTHLY;INTERVAL=1", want: "FREQ=MONTHLY"},
+		{rule: "FREQ=DAILY;UNTIL=20250131T120000Z", want: "FREQ=DAILY;UNTIL=20250131T120000Z"},
+		{rule: "FREQ=DAILY;UNTIL=20250131", want: "FREQ=DAILY;UNTIL=20250131"},
+		{rule: "yearly", wantErr: true},
+		{rule: "INTERVAL=2", wantErr: true},
+		{rule: "FREQ=HOURLY", wantErr: true},
+		{rule: "FREQ=DAILY;INTERVAL=0", wantErr: true},
+		{rule: "FREQ=DAILY;BYDAY=MO", wantErr: true},
+		{rule: "FREQ=WEEKLY;BYDAY=XX", wantErr: true},
+		{rule: "FREQ=DAILY;COUNT=0", wantErr: true},
+		{rule: "FREQ=DAILY;COUNT=2;UNTIL=20250131", wantErr: true},
+		{rule: "FREQ=DAILY;UNTIL=31.01.2025", wantErr: true},
+		{rule: "FREQ=DAILY;BYMONTHDAY=31", wantErr: true},
+		{rule: "FREQ=DAILY;COUNT", wantErr: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.rule, func(t *testing.T) {
+			r, err := ParseRecurrence(tc.rule)
+			if tc.wantErr {
+				var vErr *ValidationError
+				require.ErrorAs(t, err, &vErr)
+				require.Contains(t, vErr.Fields, "rule")
+				return
+			}
+			require.NoError(t, err)
+			if tc.want == "" {
+				require.Nil(t, r)
+				return
+			}
+			require.Equal(t, tc.want, r.String())
+			again, err := ParseRecurrence(r.String())
+			require.NoError(t, err)
+			require.Equal(t, r, again, "the canonical form parses back to the same rule")
+		})
+	}
+}
+
+func Test_Recurrence_Next(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	utc := func(s string) time.Time {
+		tm, err := time.Parse("2006-01-02 15:04", s)
+		require.NoError(t, err)
+		return tm
+	}
+	in := func(loc *time.Location, s string) time.Time {
+		tm, err := time.ParseInLocation("2006-01-02 15:04", s, loc)
+		require.NoError(t, err)
+		return tm
+	}
+
+	cases := []struct {
+		name  string
+		rule  string
+		start time.Time
+		after time.Time
+		want  time.Time // zero - the series is over
+	}{
+		{"before start", "daily", utc("2025-01-10 09:00"), utc("2025-01-01 00:00"), utc("2025-01-10 09:00")},
+		{"daily", "daily", utc("2025-01-01 09:00"), utc("2025-01-01 09:00"), utc("2025-01-02 09:00")},
+		{"daily skips missed", "daily", utc("2025-01-01 09:00"), utc("2025-01-05 12:00"), utc("2025-01-06 09:00")},
+		{"daily interval", "FREQ=DAILY;INTERVAL=3", utc("2025-01-01 09:00"), utc("2025-01-02 09:00"), utc("2025-01-04 09:00")},
+		{"daily over spring DST", "daily", in(berlin, "2025-03-29 09:00"), in(berlin, "2025-03-29 09:00"), in(berlin, "2025-03-30 09:00")},
+		{"daily over autumn DST", "daily", in(berlin, "2025-10-25 09:00"), in(berlin, "2025-10-25 10:00"), in(berlin, "2025-10-26 09:00")},
+		{"weekly same weekday", "weekly", utc("2025-01-06 08:00"), utc("2025-01-06 08:00"), utc("2025-01-13 08:00")},
+		{"weekly by days", "FREQ=WEEKLY;BYDAY=MO,FR", utc("2025-01-06 08:00"), utc("2025-01-06 08:00"), utc("2025-01-10 08:00")},
+		{"weekly interval skips a week", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR", utc("2025-01-06 08:00"), utc("2025-01-10 08:00"), utc("2025-01-20 08:00")},
+		{"weekly interval week start", "FREQ=WEEKLY;INTERVAL=2;BYDAY=SU", utc("2025-01-06 08:00"), utc("2025-01-06 08:00"), utc("2025-01-12 08:00")},
+		{"weekly over DST", "weekly", in(berlin, "2025-03-24 07:30"), in(berlin, "2025-03-24 07:30"), in(berlin, "2025-03-31 07:30")},
+		{"monthly", "monthly", utc("2025-01-15 10:00"), utc("2025-01-15 10:00"), utc("2025-02-15 10:00")},
+		{"monthly on the 31st skips short months", "monthly", utc("2025-01-31 10:00"), utc("2025-01-31 10:00"), utc("2025-03-31 10:00")},
+		{"monthly on the 31st after March", "monthly", utc("2025-01-31 10:00"), utc("2025-03-31 10:00"), utc("2025-05-31 10:00")},
+		{"monthly on the 29th in a leap year", "FREQ=MONTHLY;INTERVAL=12", utc("2024-02-29 10:00"), utc("2024-02-29 10:00"), utc("2028-02-29 10:00")},
+		{"until date-time", "FREQ=DAILY;UNTIL=20250103T000000Z", utc("2025-01-01 09:00"), utc("2025-01-01 09:00"), utc("2025-01-02 09:00")},
+		{"until date-time is over", "FREQ=DAILY;UNTIL=20250103T000000Z", utc("2025-01-01 09:00"), utc("2025-01-02 09:00"), time.Time{}},
+		{"until date includes the day", "FREQ=DAILY;UNTIL=20250103", utc("2025-01-01 09:00"), utc("2025-01-02 09:00"), utc("2025-01-03 09:00")},
+		{"until date is over", "FREQ=DAILY;UNTIL=20250103", utc("2025-01-01 09:00"), utc("2025-01-03 09:00"), time.Time{}},
+		{"until date in the series time zone", "FREQ=DAILY;UNTIL=20250103", in(newYork, "2025-01-01 23:30"), in(newYork, "2025-01-02 23:30"), in(newYork, "2025-01-03 23:30")},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := ParseRecurrence(tc.rule)
+			require.NoError(t, err)
+			got := r.Next(tc.start, tc.after)
+			if tc.want.IsZero() {
+				require.True(t, got.IsZero(), "got %s", got)
+				return
+			}
+			require.True(t, tc.want.Equal(got), "want %s, got %s", tc.want, got)
+			require.Equal(t, tc.start.In(tc.start.Location()).Hour(), got.In(tc.start.Location()).Hour(), "wall clock time is kept")
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package model
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// Reminder fires at RemindAt for a note of the user. A recurring reminder (non-empty Rule)
+// moves RemindAt to the next occurrence after firing; a one-off one becomes Done.
+type Reminder struct {
+	bun.BaseModel `bun:"table:reminders,alias:reminder"`
+	ID            int64     `json:"id" bun:"id,pk,autoincrement"`
+	CreatedAt     time.Time `json:"created_at" bun:"created_at,notnull,nullzero,default:current_timestamp"`
+	UpdatedAt     time.Time `json:"updated_at" bun:"updated_at,notnull,nullzero,default:current_timestamp"`
+	NoteID        int64     `json:"note_id" bun:"note_id,notnull"`
+	UserID        int64     `json:"user_id" bun:"user_id,notnull"`
+	RemindAt      time.Time `json:"remind_at" bun:"remind_at,notnull"` // next time to fire
+	StartsAt      time.Time `json:"starts_at" bun:"starts_at,notnull"` // first occurrence, the rule counts from it
+	Rule          string    `json:"rule" bun:"rule,notnull"`           // "" - one-off, otherwise a Recurrence in RRULE form
+	Timezone      string    `json:"timezone" bun:"timezone,notnull"`   // IANA zone the rule is evaluated in
+	FiredCount    int       `json:"fired_count" bun:"fired_count,notnull"`
+	LastFiredAt   time.Time `json:"last_fired_at" bun:"last_fired_at,nullzero"`
+	Done          bool      `json:"done" bun:"done,notnull"` // fired for the last time
+
+	NoteTitle string `json:"note_title" bun:"note_title,scanonly"` // filled only for due reminders
+}
+
+// ReminderFilter pages the list of user's reminders.
+// Upcoming limits it to reminders that are still going to fire, soonest first.
+type ReminderFilter struct {
+	Upcoming bool
+	Limit    int
+	Offset   int
+}
@@ -0,0 +1,32 @@
+package model
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// NoteShare is a public read-only link to a note. Only a hash of the token is stored,
+// the token itself is shown once, when the share is created.
+type NoteShare struct {
+	bun.BaseModel  `bun:"table:note_shares,alias:share"`
+	ID             int64     `json:"id" bun:"id,pk,autoincrement"`
+	CreatedAt      time.Time `json:"created_at" bun:"created_at,notnull,nullzero,default:current_timestamp"`
+	NoteID         int64     `json:"note_id" bun:"note_id,notnull"`
+	UserID         int64     `json:"user_id" bun:"user_id,notnull"`
+	TokenHash      []byte    `json:"-" bun:"token_hash,type:bytea,unique,notnull"`
+	PasswordHash   string    `json:"-" bun:"password_hash,nullzero"`       // bcrypt; empty - no password
+	ExpiresAt      time.Time `json:"expires_at" bun:"expires_at,nullzero"` // zero - never expires
+	RevokedAt      time.Time `json:"revoked_at" bun:"revoked_at,nullzero"`
+	Views          int64     `json:"views" bun:"views,notnull"`
+	LastViewedAt   time.Time `json:"last_viewed_at" bun:"last_viewed_at,nullzero"`
+	FailedAttempts int       `json:"-" bun:"failed_attempts,notnull"` // password attempts since the last view or lockout
+	LockedUntil    time.Time `json:"-" bun:"locked_until,nullzero"`   // zero - not locked
+
+	NoteTitle string `json:"note_title" bun:"note_title,scanonly"`
+}
+
+// Active reports whether the share still opens the note at now.
+func (s NoteShare) Active(now time.Time) bool {
+	return s.RevokedAt.IsZero() && (s.ExpiresAt.IsZero() || now.Before(s.ExpiresAt))
+}
@@ -0,0 +1,65 @@
+package model
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Sortable fields of list endpoints. Only these names may appear in a sort spec.
+var (
+	NoteSortFields = []string{"pinned", "created_at", "updated_at", "title", "id"}
+	TagSortFields  = []string{"name", "path", "note_count", "id"}
+)
+
+// SortField is one field of a sort spec.
+type SortField struct {
+	Name string
+	Desc bool
+}
+
+// Sort is a parsed sort spec like "-updated_at,title": fields are applied
+// in order, a leading "-" means descending.
+type Sort []SortField
+
+// ParseSort parses a comma-separated sort spec and checks every field against allowed.
+// Unknown or repeated fields produce a ValidationError for the "sort" param.
+func ParseSort(raw string, allowed []string) (Sort, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+	parts := strings.Split(raw, ",")
+	sort := make(Sort, 0, len(parts))
+	seen := make(map[string]bool, len(parts))
+	for _, p := range parts {
+		p = strings.TrimSpace(p)
+		field := SortField{Name: strings.TrimPrefix(p, "-"), Desc: strings.HasPrefix(p, "-")}
+		if !slices.Contains(allowed, field.Name) {
+			return nil, &ValidationError{Fields: map[string]string{
+				"sort": fmt.Sprintf("unknown field %q, allowed: %s", field.Name, strings.Join(allowed, ", ")),
+			}}
+		}
+		if seen[field.Name] {
+			return nil, &ValidationError{Fields: map[string]string{
+				"sort": fmt.Sprintf("field %q is repeated", field.Name),
+			}}
+		}
+		seen[field.Name] = true
+		sort = append(sort, field)
+	}
+	return sort, nil
+}
+
+// String formats the sort back to its spec form.
+func (s Sort) String() string {
+	parts := make([]string, 0, len(s))
+	for _, f := range s {
+		if f.Desc {
+			parts = append(parts, "-"+f.Name)
+		} else {
+			parts = append(parts, f.Name)
+		}
+	}
+	return strings.Join(parts, ",")
+}
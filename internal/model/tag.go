@@ -1,11 +1,63 @@
 package model
 
-import "github.com/uptrace/bun"
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/uptrace/bun"
+)
 
 type Tag struct {
 	bun.BaseModel `bun:"table:tags"`
 	ID            int64  `json:"id" bun:"id,pk,autoincrement"`
 	Name          string `json:"name" bun:"name,notnull"`
+	ParentID      int64  `json:"parent_id" bun:"parent_id,nullzero"` // 0 - root tag
+	Path          string `json:"path" bun:"path,notnull"`            // names from the root joined by TagPathSep
+
+	UserID      int64 `json:"user_id" bun:"user_id,nullzero"`           // 0 - a global tag
+	WorkspaceID int64 `json:"workspace_id" bun:"workspace_id,nullzero"` // 0 - not a tag of a workspace
+
+	NoteCount int64  `json:"note_count" bun:"note_count,scanonly"` // filled only by queries that count notes
+	Source    string `json:"source" bun:"source,scanonly"`         // how the tag is attached, filled only for tags of a note
+}
+
+// TagPathSep separates tag names in a path: "work/clients/acme".
+const TagPathSep = "/"
+
+// Limits of a tag name, the same as CreateTagReq in the API checks.
+const (
+	TagNameMinLen = 3
+	TagNameMaxLen = 50
+)
+
+// CheckTagName tells what is wrong with the name of a new tag, "" if nothing:
+// it must be TagNameMinLen to TagNameMaxLen characters long without TagPathSep.
+func CheckTagName(name string) string {
+	if n := utf8.RuneCountInString(name); n < TagNameMinLen || n > TagNameMaxLen {
+		return fmt.Sprintf("tag %q must be %d to %d characters long", name, TagNameMinLen, TagNameMaxLen)
+	}
+	if strings.Contains(name, TagPathSep) {
+		return fmt.Sprintf("tag %q can't contain %q", name, TagPathSep)
+	}
+	return ""
+}
+
+// TagPath builds the path of a tag named name under a parent with parentPath ("" for root tags).
+func TagPath(parentPath, name string) string {
+	if parentPath == "" {
+		return name
+	}
+	return parentPath + TagPathSep + name
+}
 
-	UserID int64 `json:"user_id" bun:"user_id,nullzero"`
+// TagFilter pages the list of user's tags.
+// Cursor, when set, continues a previous page and takes precedence over Offset.
+// ParentID, when set, limits tags to children of that tag; 0 means root tags.
+type TagFilter struct {
+	Limit    int
+	Offset   int
+	Sort     Sort
+	Cursor   string
+	ParentID *int64
 }
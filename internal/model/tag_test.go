@@ -0,0 +1,28 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CheckTagName(t *testing.T) {
+	cases := []struct {
+		name string
+		ok   bool
+	}{
+		{"go", false},
+		{"sql", true},
+		{"日本語", true},
+		{strings.Repeat("a", TagNameMaxLen), true},
+		{strings.Repeat("a", TagNameMaxLen+1), false},
+		{strings.Repeat("я", TagNameMaxLen), true},
+		{"work/acme", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.ok, CheckTagName(tc.name) == "", CheckTagName(tc.name))
+		})
+	}
+}
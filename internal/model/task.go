@@ -0,0 +1,19 @@
+package model
+
+// NoteTask is a checklist item ("- [ ] text") found in the text of a note.
+// Index counts tasks of the note from 0, Line counts lines of its text from 1.
+type NoteTask struct {
+	NoteID    int64
+	NoteTitle string
+	Index     int
+	Line      int
+	Text      string
+	Done      bool
+}
+
+// TaskFilter narrows and pages checklist items across user's notes; nil Done matches any.
+type TaskFilter struct {
+	Done   *bool
+	Limit  int
+	Offset int
+}
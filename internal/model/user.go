@@ -15,4 +15,5 @@ type User struct {
 	Email         string    `json:"email" bun:"email,unique,notnull"`
 	PasswordHash  string    `json:"-" bun:"password_hash,notnull"`
 	Name          string    `json:"name" bun:"name,notnull"`
+	IsAdmin       bool      `json:"is_admin" bun:"is_admin,notnull"` // manages global tags
 }
@@ -0,0 +1,104 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// WorkspaceRole is what a member may do in a workspace.
+type WorkspaceRole string
+
+const (
+	WorkspaceViewer WorkspaceRole = "viewer" // reads notes and tags of the workspace
+	WorkspaceMember WorkspaceRole = "member" // also creates and changes them
+	WorkspaceAdmin  WorkspaceRole = "admin"  // also renames the workspace, invites and manages members
+	WorkspaceOwner  WorkspaceRole = "owner"  // everything, including managing admins and deleting the workspace
+)
+
+var workspaceRoleRank = map[WorkspaceRole]int{WorkspaceViewer: 1, WorkspaceMember: 2, WorkspaceAdmin: 3, WorkspaceOwner: 4}
+
+// Allows reports whether the role includes everything need does.
+func (r WorkspaceRole) Allows(need WorkspaceRole) bool {
+	return workspaceRoleRank[r] >= workspaceRoleRank[need] && workspaceRoleRank[need] > 0
+}
+
+// Valid reports whether r is one of the workspace roles.
+func (r WorkspaceRole) Valid() bool {
+	_, ok := workspaceRoleRank[r]
+	return ok
+}
+
+// NoteRole is the role a member with r has on every note of the workspace.
+func (r WorkspaceRole) NoteRole() Role {
+	if r.Allows(WorkspaceMember) {
+		return RoleOwner
+	}
+	return RoleViewer
+}
+
+// Workspace is a team space that owns notes and tags instead of a single user.
+type Workspace struct {
+	bun.BaseModel `bun:"table:workspaces,alias:workspace"`
+	ID            int64     `json:"id" bun:"id,pk,autoincrement"`
+	CreatedAt     time.Time `json:"created_at" bun:"created_at,notnull,nullzero,default:current_timestamp"`
+	Name          string    `json:"name" bun:"name,notnull"`
+
+	Role WorkspaceRole `json:"role" bun:"role,scanonly"` // role of the current user, filled by queries of user's workspaces
+}
+
+// WorkspaceMembership is a user's role in a workspace.
+type WorkspaceMembership struct {
+	bun.BaseModel `bun:"table:workspace_members,alias:member"`
+	WorkspaceID   int64         `json:"workspace_id" bun:"workspace_id,pk"`
+	UserID        int64         `json:"user_id" bun:"user_id,pk"`
+	CreatedAt     time.Time     `json:"created_at" bun:"created_at,notnull,nullzero,default:current_timestamp"`
+	Role          WorkspaceRole `json:"role" bun:"role,notnull"`
+
+	Email string `json:"email" bun:"email,scanonly"`
+	Name  string `json:"name" bun:"name,scanonly"`
+}
+
+// WorkspaceInvite invites a user by email to join a workspace with a role. Only a hash of the
+// token is stored, the token itself is shown once, when the invite is created.
+type WorkspaceInvite struct {
+	bun.BaseModel `bun:"table:workspace_invites,alias:invite"`
+	ID            int64         `json:"id" bun:"id,pk,autoincrement"`
+	CreatedAt     time.Time     `json:"created_at" bun:"created_at,notnull,nullzero,default:current_timestamp"`
+	WorkspaceID   int64         `json:"workspace_id" bun:"workspace_id,notnull"`
+	InvitedBy     int64         `json:"invited_by" bun:"invited_by,notnull"`
+	Email         string        `json:"email" bun:"email,notnull"`
+	Role          WorkspaceRole `json:"role" bun:"role,notnull"` // admin, member or viewer
+	TokenHash     []byte        `json:"-" bun:"token_hash,type:bytea,unique,notnull"`
+	ExpiresAt     time.Time     `json:"expires_at" bun:"expires_at,notnull"`
+	AcceptedAt    time.Time     `json:"accepted_at" bun:"accepted_at,nullzero"`
+	RevokedAt     time.Time     `json:"revoked_at" bun:"revoked_at,nullzero"`
+}
+
+// Pending reports whether the invite can still be accepted at now.
+func (i WorkspaceInvite) Pending(now time.Time) bool {
+	return i.AcceptedAt.IsZero() && i.RevokedAt.IsZero() && now.Before(i.ExpiresAt)
+}
+
+type workspaceCtxKey struct{}
+
+// WithWorkspace makes m the owner scope of ctx: notes and tags are then read and written
+// in m's workspace instead of among personal ones of the user.
+func WithWorkspace(ctx context.Context, m *WorkspaceMembership) context.Context {
+	return context.WithValue(ctx, workspaceCtxKey{}, m)
+}
+
+// WorkspaceFrom returns the membership selected by WithWorkspace, nil in the personal scope.
+func WorkspaceFrom(ctx context.Context) *WorkspaceMembership {
+	m, _ := ctx.Value(workspaceCtxKey{}).(*WorkspaceMembership)
+	return m
+}
+
+// WorkspaceID returns the id of the workspace selected in ctx, 0 in the personal scope.
+func WorkspaceID(ctx context.Context) int64 {
+	if m := WorkspaceFrom(ctx); m != nil {
+		return m.WorkspaceID
+	}
+	return 0
+}
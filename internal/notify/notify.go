@@ -0,0 +1,98 @@
+// Package notify delivers fired reminders to users.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Rasulikus/notebook/internal/model"
+)
+
+// Notifier delivers a reminder that has just fired.
+type Notifier interface {
+	Notify(ctx context.Context, rem *model.Reminder) error
+}
+
+// LogNotifier writes fired reminders to the log; useful in development and as a fallback.
+type LogNotifier struct {
+	logger *log.Logger
+}
+
+// NewLogNotifier - constructor; a nil logger means the standard one.
+func NewLogNotifier(logger *log.Logger) *LogNotifier {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &LogNotifier{logger: logger}
+}
+
+func (n *LogNotifier) Notify(_ context.Context, rem *model.Reminder) error {
+	n.logger.Printf("reminder %d: user %d, note %d %q, due at %s",
+		rem.ID, rem.UserID, rem.NoteID, rem.NoteTitle, rem.RemindAt.Format(time.RFC3339))
+	return nil
+}
+
+// WebhookNotifier POSTs fired reminders as JSON to a URL. With a secret, the body is signed
+// with HMAC-SHA256 in the X-Signature header ("sha256=<hex>") so the receiver can verify it.
+type WebhookNotifier struct {
+	url    string
+	secret []byte
+	client *http.Client
+}
+
+// NewWebhookNotifier - constructor; timeout limits every delivery.
+func NewWebhookNotifier(url, secret string, timeout time.Duration) *WebhookNotifier {
+	return &WebhookNotifier{url: url, secret: []byte(secret), client: &http.Client{Timeout: timeout}}
+}
+
+// webhookPayload is the body of a webhook call.
+type webhookPayload struct {
+	ReminderID int64     `json:"reminder_id"`
+	UserID     int64     `json:"user_id"`
+	NoteID     int64     `json:"note_id"`
+	NoteTitle  string    `json:"note_title"`
+	RemindAt   time.Time `json:"remind_at"`
+	Rule       string    `json:"rule,omitempty"`
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, rem *model.Reminder) error {
+	body, err := json.Marshal(webhookPayload{
+		ReminderID: rem.ID,
+		UserID:     rem.UserID,
+		NoteID:     rem.NoteID,
+		NoteTitle:  rem.NoteTitle,
+		RemindAt:   rem.RemindAt,
+		Rule:       rem.Rule,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(n.secret) > 0 {
+		mac := hmac.New(sha256.New, n.secret)
+		mac.Write(body)
+		req.Header.Set("X-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook %s: unexpected status %s", n.url, resp.Status)
+	}
+	return nil
+}
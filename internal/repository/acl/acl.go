@@ -0,0 +1,72 @@
+package acl
+
+import (
+	"context"
+
+	"github.com/Rasulikus/notebook/internal/model"
+	"github.com/uptrace/bun"
+)
+
+type Repo struct {
+	db *bun.DB
+}
+
+func NewRepository(db *bun.DB) *Repo {
+	return &Repo{db: db}
+}
+
+// Grant gives the grantee a role on the note or the tag; granting it again only changes the role.
+func (r *Repo) Grant(ctx context.Context, grant *model.AccessGrant) error {
+	target := "CONFLICT (note_id, grantee_id) WHERE note_id IS NOT NULL DO UPDATE"
+	if grant.TagID != 0 {
+		target = "CONFLICT (tag_id, grantee_id) WHERE tag_id IS NOT NULL DO UPDATE"
+	}
+	_, err := r.db.NewInsert().
+		Model(grant).
+		On(target).
+		Set("role = EXCLUDED.role").
+		Returning("*").
+		Exec(ctx)
+	return err
+}
+
+// List returns grants of the owner on the note or, with a zero noteID, on the tag,
+// with the email and name of each grantee.
+func (r *Repo) List(ctx context.Context, ownerID, noteID, tagID int64) ([]model.AccessGrant, error) {
+	grants := []model.AccessGrant{}
+	q := r.db.NewSelect().
+		Model(&grants).
+		ColumnExpr("access_grant.*").
+		ColumnExpr("grantee.email AS grantee_email, grantee.name AS grantee_name").
+		Join("JOIN users AS grantee ON grantee.id = access_grant.grantee_id").
+		Where("access_grant.owner_id = ?", ownerID).
+		Order("access_grant.id")
+	if noteID != 0 {
+		q.Where("access_grant.note_id = ?", noteID)
+	} else {
+		q.Where("access_grant.tag_id = ?", tagID)
+	}
+	if err := q.Scan(ctx); err != nil {
+		return nil, err
+	}
+	return grants, nil
+}
+
+// Revoke removes a grant given by the owner.
+func (r *Repo) Revoke(ctx context.Context, ownerID, id int64) error {
+	res, err := r.db.NewDelete().
+		Model((*model.AccessGrant)(nil)).
+		Where("id = ? AND owner_id = ?", id, ownerID).
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+	aff, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if aff == 0 {
+		return model.ErrNotFound
+	}
+	return nil
+}
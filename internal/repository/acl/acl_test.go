@@ -0,0 +1,101 @@
+package acl
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/Rasulikus/notebook/internal/model"
+	testdb "github.com/Rasulikus/notebook/internal/repository/test_db"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+)
+
+func TestMain(m *testing.M) {
+	testdb.RecreateTables()
+	code := m.Run()
+	testdb.CloseDB()
+	os.Exit(code)
+}
+
+type testSuite struct {
+	db      *bun.DB
+	aclRepo *Repo
+	ctx     context.Context
+}
+
+func setupTestSuite(t *testing.T) *testSuite {
+	t.Helper()
+	var suite testSuite
+	suite.db = testdb.DB()
+	suite.aclRepo = NewRepository(suite.db)
+	suite.ctx = context.Background()
+	return &suite
+}
+
+func insertUser(t *testing.T, ts *testSuite, email string) *model.User {
+	t.Helper()
+	u := &model.User{Email: email, PasswordHash: "x", Name: email}
+	err := ts.db.NewInsert().Model(u).Scan(ts.ctx, u)
+	require.NoError(t, err)
+	require.NotZero(t, u.ID)
+	return u
+}
+
+func Test_Repo_Grant(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	owner := insertUser(t, ts, "owner@mail.ru")
+	friend := insertUser(t, ts, "friend@mail.ru")
+	note := &model.Note{Title: "note", UserID: owner.ID}
+	_, err := ts.db.NewInsert().Model(note).Returning("id").Exec(ts.ctx)
+	require.NoError(t, err)
+	tag := &model.Tag{Name: "work", Path: "work", UserID: owner.ID}
+	_, err = ts.db.NewInsert().Model(tag).Returning("id").Exec(ts.ctx)
+	require.NoError(t, err)
+
+	g := &model.AccessGrant{OwnerID: owner.ID, GranteeID: friend.ID, NoteID: note.ID, Role: model.RoleViewer}
+	require.NoError(t, ts.aclRepo.Grant(ts.ctx, g))
+	require.NotZero(t, g.ID)
+
+	again := &model.AccessGrant{OwnerID: owner.ID, GranteeID: friend.ID, NoteID: note.ID, Role: model.RoleEditor}
+	require.NoError(t, ts.aclRepo.Grant(ts.ctx, again))
+	require.Equal(t, g.ID, again.ID, "granting again changes the role")
+
+	byTag := &model.AccessGrant{OwnerID: owner.ID, GranteeID: friend.ID, TagID: tag.ID, Role: model.RoleViewer}
+	require.NoError(t, ts.aclRepo.Grant(ts.ctx, byTag))
+
+	list, err := ts.aclRepo.List(ts.ctx, owner.ID, note.ID, 0)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	require.Equal(t, model.RoleEditor, list[0].Role)
+	require.Equal(t, friend.Email, list[0].GranteeEmail)
+
+	list, err = ts.aclRepo.List(ts.ctx, owner.ID, 0, tag.ID)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	require.Equal(t, byTag.ID, list[0].ID)
+
+	list, err = ts.aclRepo.List(ts.ctx, friend.ID, note.ID, 0)
+	require.NoError(t, err)
+	require.Empty(t, list)
+}
+
+func Test_Repo_Revoke(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	owner := insertUser(t, ts, "owner@mail.ru")
+	friend := insertUser(t, ts, "friend@mail.ru")
+	note := &model.Note{Title: "note", UserID: owner.ID}
+	_, err := ts.db.NewInsert().Model(note).Returning("id").Exec(ts.ctx)
+	require.NoError(t, err)
+
+	g := &model.AccessGrant{OwnerID: owner.ID, GranteeID: friend.ID, NoteID: note.ID, Role: model.RoleViewer}
+	require.NoError(t, ts.aclRepo.Grant(ts.ctx, g))
+
+	err = ts.aclRepo.Revoke(ts.ctx, friend.ID, g.ID)
+	require.ErrorIs(t, err, model.ErrNotFound, "only the owner revokes")
+	require.NoError(t, ts.aclRepo.Revoke(ts.ctx, owner.ID, g.ID))
+	err = ts.aclRepo.Revoke(ts.ctx, owner.ID, g.ID)
+	require.ErrorIs(t, err, model.ErrNotFound)
+}
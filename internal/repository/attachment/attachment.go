@@ -0,0 +1,222 @@
+package attachment
+
+import (
+	"context"
+
+	"github.com/Rasulikus/notebook/internal/model"
+	"github.com/Rasulikus/notebook/internal/repository"
+	"github.com/uptrace/bun"
+)
+
+type Repo struct {
+	db *bun.DB
+}
+
+func NewRepository(db *bun.DB) *Repo {
+	return &Repo{db: db}
+}
+
+// Create saves an attachment of the user's note. A positive quota limits the total size of
+// user's attachments: the check and the insert run under a lock on the user, so concurrent
+// uploads can't overrun it together.
+func (r *Repo) Create(ctx context.Context, att *model.Attachment, quota int64) error {
+	return r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewSelect().
+			Model((*model.User)(nil)).
+			Column("id").
+			Where("id = ?", att.UserID).
+			For("UPDATE").
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		exists, err := tx.NewSelect().
+			Model((*model.Note)(nil)).
+			Where("id = ? AND ?", att.NoteID, repository.Owned(ctx, "", att.UserID)).
+			Exists(ctx)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return model.ErrNotFound
+		}
+
+		if quota > 0 {
+			var used int64
+			err = tx.NewSelect().
+				Model((*model.Attachment)(nil)).
+				ColumnExpr("COALESCE(SUM(size), 0)").
+				Where("user_id = ? AND note_id IS NOT NULL", att.UserID).
+				Scan(ctx, &used)
+			if err != nil {
+				return err
+			}
+			if used+att.Size > quota {
+				return model.ErrQuotaExceeded
+			}
+		}
+
+		_, err = tx.NewInsert().Model(att).Returning("*").Exec(ctx)
+		return err
+	})
+}
+
+// ListByNote returns attachments of the user's note in upload order.
+func (r *Repo) ListByNote(ctx context.Context, userID, noteID int64) ([]model.Attachment, error) {
+	attachments := []model.Attachment{}
+	err := r.db.NewSelect().
+		Model(&attachments).
+		Where("attachment.user_id = ? AND attachment.note_id = ?", userID, noteID).
+		Order("attachment.id").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+// GetByID returns the user's attachment unless its note is in trash.
+func (r *Repo) GetByID(ctx context.Context, userID, id int64) (*model.Attachment, error) {
+	att := new(model.Attachment)
+	err := r.db.NewSelect().
+		Model(att).
+		Join("JOIN notes AS note ON note.id = attachment.note_id AND note.deleted_at IS NULL").
+		Where("attachment.id = ? AND attachment.user_id = ?", id, userID).
+		Scan(ctx)
+	if err != nil {
+		return nil, repository.IsNoRowsError(err)
+	}
+	return att, nil
+}
+
+// DeleteByID detaches the user's attachment from its note and returns it with its thumbnails;
+// the row and the blobs are then removed with the other orphans. Attachments of notes in trash are not found.
+func (r *Repo) DeleteByID(ctx context.Context, userID, id int64) (*model.Attachment, error) {
+	att := new(model.Attachment)
+	res, err := r.db.NewUpdate().
+		Model(att).
+		Set("note_id = NULL").
+		Where("id = ? AND user_id = ?", id, userID).
+		Where("note_id IN (SELECT id FROM notes WHERE deleted_at IS NULL)").
+		Returning("*").
+		Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	aff, err := res.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if aff == 0 {
+		return nil, model.ErrNotFound
+	}
+	err = r.db.NewSelect().
+		Model(&att.Thumbs).
+		Where("thumb.attachment_id = ?", att.ID).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return att, nil
+}
+
+// ListOrphans returns up to limit attachments left without a note, either deleted
+// one by one or orphaned when their note was removed for good, with their thumbnails.
+func (r *Repo) ListOrphans(ctx context.Context, limit int) ([]model.Attachment, error) {
+	var attachments []model.Attachment
+	err := r.db.NewSelect().
+		Model(&attachments).
+		Relation("Thumbs").
+		Where("attachment.note_id IS NULL").
+		Order("attachment.id").
+		Limit(limit).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+// DeleteOrphans removes rows of orphaned attachments whose blobs are already gone.
+func (r *Repo) DeleteOrphans(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := r.db.NewDelete().
+		Model((*model.Attachment)(nil)).
+		Where("id IN (?) AND note_id IS NULL", bun.In(ids)).
+		Exec(ctx)
+	return err
+}
+
+// ListPendingThumbs returns up to limit attachments still waiting for thumbnails, oldest first,
+// starting after the attachment with id afterID.
+func (r *Repo) ListPendingThumbs(ctx context.Context, afterID int64, limit int) ([]model.Attachment, error) {
+	var attachments []model.Attachment
+	err := r.db.NewSelect().
+		Model(&attachments).
+		Where("attachment.thumb_status = ? AND attachment.note_id IS NOT NULL", model.ThumbPending).
+		Where("attachment.id > ?", afterID).
+		Order("attachment.id").
+		Limit(limit).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+// SaveThumbs stores thumbnails of the attachment, replacing ones of the same sizes, and sets its thumb status.
+func (r *Repo) SaveThumbs(ctx context.Context, id int64, thumbs []*model.AttachmentThumb, status string) error {
+	return r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if len(thumbs) > 0 {
+			_, err := tx.NewInsert().
+				Model(&thumbs).
+				On("CONFLICT (attachment_id, size) DO UPDATE").
+				Set("content_type = EXCLUDED.content_type").
+				Set("byte_size = EXCLUDED.byte_size").
+				Set("sha256 = EXCLUDED.sha256").
+				Set("storage_key = EXCLUDED.storage_key").
+				Exec(ctx)
+			if err != nil {
+				return err
+			}
+		}
+		_, err := tx.NewUpdate().
+			Model((*model.Attachment)(nil)).
+			Set("thumb_status = ?", status).
+			Where("id = ?", id).
+			Exec(ctx)
+		return err
+	})
+}
+
+// FailThumbs counts a failed try to make thumbnails of the attachment. Once maxAttempts tries
+// have failed the attachment is marked failed and reported so.
+func (r *Repo) FailThumbs(ctx context.Context, id int64, maxAttempts int) (bool, error) {
+	var status string
+	err := r.db.NewUpdate().
+		Model((*model.Attachment)(nil)).
+		Set("thumb_attempts = thumb_attempts + 1").
+		Set("thumb_status = CASE WHEN thumb_attempts + 1 >= ? THEN ? ELSE thumb_status END", maxAttempts, model.ThumbFailed).
+		Where("id = ?", id).
+		Returning("thumb_status").
+		Scan(ctx, &status)
+	if err != nil {
+		return false, repository.IsNoRowsError(err)
+	}
+	return status == model.ThumbFailed, nil
+}
+
+// GetThumb returns the thumbnail of the given size of an attachment.
+func (r *Repo) GetThumb(ctx context.Context, id int64, size int) (*model.AttachmentThumb, error) {
+	thumb := new(model.AttachmentThumb)
+	err := r.db.NewSelect().
+		Model(thumb).
+		Where("thumb.attachment_id = ? AND thumb.size = ?", id, size).
+		Scan(ctx)
+	if err != nil {
+		return nil, repository.IsNoRowsError(err)
+	}
+	return thumb, nil
+}
@@ -0,0 +1,245 @@
+package attachment
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/Rasulikus/notebook/internal/model"
+	testdb "github.com/Rasulikus/notebook/internal/repository/test_db"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+)
+
+func TestMain(m *testing.M) {
+	testdb.RecreateTables()
+	code := m.Run()
+	testdb.CloseDB()
+	os.Exit(code)
+}
+
+type testSuite struct {
+	db             *bun.DB
+	attachmentRepo *Repo
+	ctx            context.Context
+}
+
+func setupTestSuite(t *testing.T) *testSuite {
+	t.Helper()
+	var suite testSuite
+	suite.db = testdb.DB()
+	suite.attachmentRepo = NewRepository(suite.db)
+	suite.ctx = context.Background()
+	return &suite
+}
+
+func ensureUser(t *testing.T, db *bun.DB, ctx context.Context) *model.User {
+	t.Helper()
+	u := &model.User{Email: "test@mail.ru", PasswordHash: "x", Name: "test"}
+	err := db.NewInsert().Model(u).Scan(ctx, u)
+	require.NoError(t, err)
+	require.NotZero(t, u.ID)
+	return u
+}
+
+func insertNote(t *testing.T, ts *testSuite, userID int64, title string) *model.Note {
+	t.Helper()
+	n := &model.Note{Title: title, UserID: userID}
+	_, err := ts.db.NewInsert().Model(n).Returning("id").Exec(ts.ctx)
+	require.NoError(t, err)
+	return n
+}
+
+func newAttachment(userID, noteID int64, name string, size int64) *model.Attachment {
+	return &model.Attachment{
+		NoteID:      noteID,
+		UserID:      userID,
+		Name:        name,
+		ContentType: "image/png",
+		Size:        size,
+		SHA256:      "00",
+		StorageKey:  fmt.Sprintf("%d/%d/%s", userID, noteID, name),
+	}
+}
+
+func Test_Repo_Create(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	user := ensureUser(t, ts.db, ts.ctx)
+	note := insertNote(t, ts, user.ID, "note")
+
+	a := newAttachment(user.ID, note.ID, "a.png", 60)
+	require.NoError(t, ts.attachmentRepo.Create(ts.ctx, a, 100))
+	require.NotZero(t, a.ID)
+	require.NotZero(t, a.CreatedAt)
+
+	err := ts.attachmentRepo.Create(ts.ctx, newAttachment(user.ID, note.ID, "b.png", 50), 100)
+	require.ErrorIs(t, err, model.ErrQuotaExceeded)
+	require.NoError(t, ts.attachmentRepo.Create(ts.ctx, newAttachment(user.ID, note.ID, "b.png", 40), 100))
+	require.NoError(t, ts.attachmentRepo.Create(ts.ctx, newAttachment(user.ID, note.ID, "c.png", 1000), 0), "0 - no quota")
+
+	err = ts.attachmentRepo.Create(ts.ctx, newAttachment(user.ID, note.ID+100, "d.png", 1), 0)
+	require.ErrorIs(t, err, model.ErrNotFound)
+
+	list, err := ts.attachmentRepo.ListByNote(ts.ctx, user.ID, note.ID)
+	require.NoError(t, err)
+	require.Len(t, list, 3)
+	require.Equal(t, "a.png", list[0].Name)
+}
+
+func Test_Repo_GetByID(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	user := ensureUser(t, ts.db, ts.ctx)
+	note := insertNote(t, ts, user.ID, "note")
+	a := newAttachment(user.ID, note.ID, "a.png", 10)
+	require.NoError(t, ts.attachmentRepo.Create(ts.ctx, a, 0))
+
+	got, err := ts.attachmentRepo.GetByID(ts.ctx, user.ID, a.ID)
+	require.NoError(t, err)
+	require.Equal(t, a.StorageKey, got.StorageKey)
+
+	_, err = ts.attachmentRepo.GetByID(ts.ctx, user.ID+1, a.ID)
+	require.ErrorIs(t, err, model.ErrNotFound)
+
+	_, err = ts.db.NewUpdate().Model((*model.Note)(nil)).Set("deleted_at = now()").Where("id = ?", note.ID).Exec(ts.ctx)
+	require.NoError(t, err)
+	_, err = ts.attachmentRepo.GetByID(ts.ctx, user.ID, a.ID)
+	require.ErrorIs(t, err, model.ErrNotFound, "attachments of notes in trash are hidden")
+	_, err = ts.attachmentRepo.DeleteByID(ts.ctx, user.ID, a.ID)
+	require.ErrorIs(t, err, model.ErrNotFound)
+}
+
+func Test_Repo_Orphans(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	user := ensureUser(t, ts.db, ts.ctx)
+	kept := insertNote(t, ts, user.ID, "kept")
+	purged := insertNote(t, ts, user.ID, "purged")
+
+	a := newAttachment(user.ID, kept.ID, "a.png", 10)
+	b := newAttachment(user.ID, kept.ID, "b.png", 10)
+	c := newAttachment(user.ID, purged.ID, "c.png", 10)
+	for _, att := range []*model.Attachment{a, b, c} {
+		require.NoError(t, ts.attachmentRepo.Create(ts.ctx, att, 0))
+	}
+
+	deleted, err := ts.attachmentRepo.DeleteByID(ts.ctx, user.ID, a.ID)
+	require.NoError(t, err)
+	require.Equal(t, a.StorageKey, deleted.StorageKey)
+	_, err = ts.attachmentRepo.DeleteByID(ts.ctx, user.ID, a.ID)
+	require.ErrorIs(t, err, model.ErrNotFound)
+
+	_, err = ts.db.NewDelete().Model((*model.Note)(nil)).ForceDelete().Where("id = ?", purged.ID).Exec(ts.ctx)
+	require.NoError(t, err)
+
+	orphans, err := ts.attachmentRepo.ListOrphans(ts.ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, orphans, 2)
+	require.Equal(t, a.ID, orphans[0].ID)
+	require.Equal(t, c.ID, orphans[1].ID, "attachments of purged notes become orphans")
+
+	require.NoError(t, ts.attachmentRepo.Create(ts.ctx, newAttachment(user.ID, kept.ID, "d.png", 20), 40),
+		"orphans don't count towards the quota")
+
+	require.NoError(t, ts.attachmentRepo.DeleteOrphans(ts.ctx, []int64{a.ID, b.ID, c.ID}))
+	orphans, err = ts.attachmentRepo.ListOrphans(ts.ctx, 10)
+	require.NoError(t, err)
+	require.Empty(t, orphans)
+
+	list, err := ts.attachmentRepo.ListByNote(ts.ctx, user.ID, kept.ID)
+	require.NoError(t, err)
+	require.Len(t, list, 2, "attached rows are never deleted as orphans")
+}
+
+func Test_Repo_Thumbs(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	user := ensureUser(t, ts.db, ts.ctx)
+	note := insertNote(t, ts, user.ID, "note")
+
+	image := newAttachment(user.ID, note.ID, "a.png", 10)
+	image.ThumbStatus = model.ThumbPending
+	pdf := newAttachment(user.ID, note.ID, "b.pdf", 10)
+	for _, att := range []*model.Attachment{image, pdf} {
+		require.NoError(t, ts.attachmentRepo.Create(ts.ctx, att, 0))
+	}
+	require.Equal(t, model.ThumbNone, pdf.ThumbStatus)
+
+	pending, err := ts.attachmentRepo.ListPendingThumbs(ts.ctx, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	require.Equal(t, image.ID, pending[0].ID)
+
+	thumb := func(size int, digest string) *model.AttachmentThumb {
+		return &model.AttachmentThumb{
+			AttachmentID: image.ID,
+			Size:         size,
+			ContentType:  "image/png",
+			ByteSize:     1,
+			SHA256:       digest,
+			StorageKey:   fmt.Sprintf("%s_thumb%d", image.StorageKey, size),
+		}
+	}
+	thumbs := []*model.AttachmentThumb{thumb(128, "old"), thumb(256, "b")}
+	require.NoError(t, ts.attachmentRepo.SaveThumbs(ts.ctx, image.ID, thumbs, model.ThumbReady))
+	require.NoError(t, ts.attachmentRepo.SaveThumbs(ts.ctx, image.ID, []*model.AttachmentThumb{thumb(128, "new")}, model.ThumbReady))
+
+	got, err := ts.attachmentRepo.GetThumb(ts.ctx, image.ID, 128)
+	require.NoError(t, err)
+	require.Equal(t, "new", got.SHA256, "thumbs of the same size are replaced")
+	_, err = ts.attachmentRepo.GetThumb(ts.ctx, image.ID, 512)
+	require.ErrorIs(t, err, model.ErrNotFound)
+
+	pending, err = ts.attachmentRepo.ListPendingThumbs(ts.ctx, 0, 10)
+	require.NoError(t, err)
+	require.Empty(t, pending)
+
+	deleted, err := ts.attachmentRepo.DeleteByID(ts.ctx, user.ID, image.ID)
+	require.NoError(t, err)
+	require.Len(t, deleted.Thumbs, 2)
+
+	orphans, err := ts.attachmentRepo.ListOrphans(ts.ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, orphans, 1)
+	require.Len(t, orphans[0].Thumbs, 2, "thumbnail blobs are removed with the orphan")
+}
+
+func Test_Repo_FailThumbs(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	user := ensureUser(t, ts.db, ts.ctx)
+	note := insertNote(t, ts, user.ID, "note")
+
+	first := newAttachment(user.ID, note.ID, "a.png", 10)
+	second := newAttachment(user.ID, note.ID, "b.png", 10)
+	for _, att := range []*model.Attachment{first, second} {
+		att.ThumbStatus = model.ThumbPending
+		require.NoError(t, ts.attachmentRepo.Create(ts.ctx, att, 0))
+	}
+
+	pending, err := ts.attachmentRepo.ListPendingThumbs(ts.ctx, first.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	require.Equal(t, second.ID, pending[0].ID, "attachments up to afterID are skipped")
+
+	failed, err := ts.attachmentRepo.FailThumbs(ts.ctx, first.ID, 2)
+	require.NoError(t, err)
+	require.False(t, failed)
+	pending, err = ts.attachmentRepo.ListPendingThumbs(ts.ctx, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 2, "the attachment stays pending until it runs out of attempts")
+	require.Equal(t, 1, pending[0].ThumbAttempts)
+
+	failed, err = ts.attachmentRepo.FailThumbs(ts.ctx, first.ID, 2)
+	require.NoError(t, err)
+	require.True(t, failed)
+	pending, err = ts.attachmentRepo.ListPendingThumbs(ts.ctx, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	require.Equal(t, second.ID, pending[0].ID)
+
+	_, err = ts.attachmentRepo.FailThumbs(ts.ctx, first.ID+second.ID, 2)
+	require.ErrorIs(t, err, model.ErrNotFound)
+}
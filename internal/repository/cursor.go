@@ -0,0 +1,206 @@
+package repository
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+
+	"github.com/Rasulikus/notebook/internal/model"
+	"github.com/uptrace/bun"
+)
+
+// SortKey is one column of an ORDER BY used for keyset pagination.
+// Expr must be a trusted SQL expression, never user input.
+type SortKey struct {
+	Expr string
+	Desc bool
+}
+
+// Keyset orders a list by Keys followed by IDExpr as a unique tie-breaker,
+// so that every row has a stable position a cursor can point to.
+type Keyset struct {
+	Keys   []SortKey
+	IDExpr string
+	IDDesc bool
+}
+
+// cursor is the payload hidden inside an opaque cursor string.
+// Sort holds the signature of the ordering the cursor was issued for.
+type cursor struct {
+	Sort   string `json:"s"`
+	Values []any  `json:"v"`
+	ID     int64  `json:"id"`
+}
+
+// signature is a short hash identifying the ordering,
+// so a cursor can't be replayed against another one.
+func (k Keyset) signature() string {
+	parts := make([]string, 0, len(k.Keys)+1)
+	for _, key := range k.Keys {
+		parts = append(parts, signed(key.Expr, key.Desc))
+	}
+	parts = append(parts, signed(k.IDExpr, k.IDDesc))
+	h := fnv.New32a()
+	h.Write([]byte(strings.Join(parts, ",")))
+	return strconv.FormatUint(uint64(h.Sum32()), 36)
+}
+
+// EncodeCursor builds an opaque cursor pointing right after the row
+// with the given sort values and id.
+func (k Keyset) EncodeCursor(values []any, id int64) (string, error) {
+	b, err := json.Marshal(cursor{Sort: k.signature(), Values: values, ID: id})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// decodeCursor parses a cursor issued by EncodeCursor for the same keyset.
+func (k Keyset) decodeCursor(raw string) (*cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var c cursor
+	if err := dec.Decode(&c); err != nil {
+		return nil, err
+	}
+	if c.Sort != k.signature() || len(c.Values) != len(k.Keys) {
+		return nil, fmt.Errorf("cursor was issued for another sort order")
+	}
+	for i, v := range c.Values {
+		if n, ok := v.(json.Number); ok {
+			if iv, err := n.Int64(); err == nil {
+				c.Values[i] = iv
+			} else if fv, err := n.Float64(); err == nil {
+				c.Values[i] = fv
+			}
+		}
+	}
+	return &c, nil
+}
+
+// Apply orders the query by the keyset and, if rawCursor is set, restricts it
+// to rows strictly after the cursor position. Returns a ValidationError for malformed cursors.
+func (k Keyset) Apply(q *bun.SelectQuery, rawCursor string) error {
+	if rawCursor != "" {
+		c, err := k.decodeCursor(rawCursor)
+		if err != nil {
+			return &model.ValidationError{Fields: map[string]string{"cursor": "invalid cursor"}}
+		}
+		k.applyAfter(q, c)
+	}
+	for _, key := range k.Keys {
+		q.OrderExpr(key.Expr + direction(key.Desc))
+	}
+	q.OrderExpr(k.IDExpr + direction(k.IDDesc))
+	return nil
+}
+
+// applyAfter adds the "row comes after cursor" condition expanded for mixed directions:
+// (k1 > v1) OR (k1 = v1 AND k2 > v2) OR ... OR (k1 = v1 AND ... AND id > cid).
+func (k Keyset) applyAfter(q *bun.SelectQuery, c *cursor) {
+	var (
+		ors  []string
+		args []any
+	)
+	for i := 0; i <= len(k.Keys); i++ {
+		var ands []string
+		for j := 0; j < i; j++ {
+			ands = append(ands, k.Keys[j].Expr+" = ?")
+			args = append(args, c.Values[j])
+		}
+		if i < len(k.Keys) {
+			ands = append(ands, k.Keys[i].Expr+comparison(k.Keys[i].Desc))
+			args = append(args, c.Values[i])
+		} else {
+			ands = append(ands, k.IDExpr+comparison(k.IDDesc))
+			args = append(args, c.ID)
+		}
+		ors = append(ors, "("+strings.Join(ands, " AND ")+")")
+	}
+	q.Where("("+strings.Join(ors, " OR ")+")", args...)
+}
+
+// SortColumn maps a public sort field to a trusted SQL expression
+// and to the row value a cursor stores for it.
+type SortColumn[T any] struct {
+	Expr  string
+	Value func(row *T) any
+}
+
+// NewKeyset builds a keyset from a validated sort spec. The id column is always
+// appended as a tie-breaker; an explicit "id" field ends the spec since it is unique.
+// The returned func extracts cursor values of a row for NewPage.
+func NewKeyset[T any](sort model.Sort, columns map[string]SortColumn[T], idExpr string, idOf func(row *T) int64) (Keyset, func(row *T) ([]any, int64), error) {
+	k := Keyset{IDExpr: idExpr}
+	var values []func(row *T) any
+	for i, f := range sort {
+		if f.Name == "id" {
+			k.IDDesc = f.Desc
+			break
+		}
+		col, ok := columns[f.Name]
+		if !ok {
+			return Keyset{}, nil, fmt.Errorf("no sort column for field %q", f.Name)
+		}
+		if i == 0 {
+			k.IDDesc = f.Desc
+		}
+		k.Keys = append(k.Keys, SortKey{Expr: col.Expr, Desc: f.Desc})
+		values = append(values, col.Value)
+	}
+	cursorOf := func(row *T) ([]any, int64) {
+		vs := make([]any, 0, len(values))
+		for _, v := range values {
+			vs = append(vs, v(row))
+		}
+		return vs, idOf(row)
+	}
+	return k, cursorOf, nil
+}
+
+// NewPage builds a page from rows fetched with limit+1: the extra row only signals
+// that there is a next page. cursorOf returns sort values and id of a row.
+func NewPage[T any](k Keyset, rows []T, limit int, cursorOf func(*T) ([]any, int64)) (*model.Page[T], error) {
+	page := &model.Page[T]{Items: rows}
+	if len(rows) <= limit {
+		return page, nil
+	}
+	page.Items = rows[:limit]
+	page.HasMore = true
+	values, id := cursorOf(&page.Items[limit-1])
+	next, err := k.EncodeCursor(values, id)
+	if err != nil {
+		return nil, err
+	}
+	page.NextCursor = next
+	return page, nil
+}
+
+func signed(expr string, desc bool) string {
+	if desc {
+		return "-" + expr
+	}
+	return expr
+}
+
+func direction(desc bool) string {
+	if desc {
+		return " DESC"
+	}
+	return " ASC"
+}
+
+func comparison(desc bool) string {
+	if desc {
+		return " < ?"
+	}
+	return " > ?"
+}
@@ -0,0 +1,129 @@
+package note
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Rasulikus/notebook/internal/model"
+	"github.com/Rasulikus/notebook/internal/repository"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/schema"
+)
+
+// grantRoleSQL yields the strongest role the user (first ?) has on the personal note aliased "note"
+// through grants of its owner, on the note itself or on one of its tags; NULL without any.
+// A grant on a tag covers its descendant tags too. Notes of a workspace are shared through
+// its membership, grants don't apply to them. Use it through grantRole.
+var grantRoleSQL = `(SELECT CASE WHEN count(*) = 0 THEN NULL
+		WHEN bool_or(g.role = 'editor') THEN 'editor' ELSE 'viewer' END
+	FROM access_grants AS g
+	WHERE g.grantee_id = ? AND g.owner_id = note.user_id AND note.workspace_id IS NULL
+		AND (g.note_id = note.id OR EXISTS (SELECT 1 FROM notes_tags AS nt
+			WHERE nt.note_id = note.id AND nt.tag_id IN (` + repository.SubtreeSQL("tags") + `))))`
+
+// grantedNotesSQL selects ids of notes the user (?) may have a grant on, through the tag
+// subtrees too; grantRoleSQL then checks that the grant comes from the owner of the note.
+// Use it through grantedNotes.
+var grantedNotesSQL = `SELECT g.note_id FROM access_grants AS g WHERE g.grantee_id = ? AND g.note_id IS NOT NULL
+	UNION
+	SELECT nt.note_id FROM notes_tags AS nt WHERE nt.tag_id IN (` + repository.SubtreeSQL("tags") + `)`
+
+// grantRole returns grantRoleSQL for the user as a query argument.
+func grantRole(userID int64) schema.QueryWithArgs {
+	return bun.SafeQuery(grantRoleSQL, userID, bun.Safe("g.tag_id"))
+}
+
+// grantedNotes returns grantedNotesSQL for the user as a query argument.
+func grantedNotes(userID int64) schema.QueryWithArgs {
+	grantedTags := bun.SafeQuery("SELECT g.tag_id FROM access_grants AS g WHERE g.grantee_id = ? AND g.tag_id IS NOT NULL", userID)
+	return bun.SafeQuery(grantedNotesSQL, userID, grantedTags)
+}
+
+// Access returns the owner of the note and the role the user has on it: owner for own notes,
+// otherwise the strongest granted one. Inside a workspace only its notes are accessible, with
+// the role following the user's membership. Notes in trash and notes the user has no access to are ErrNotFound.
+func (r *repo) Access(ctx context.Context, userID, noteID int64) (int64, model.Role, error) {
+	q := r.db.NewSelect().
+		Model((*model.Note)(nil)).
+		ColumnExpr("note.user_id").
+		Where("note.id = ?", noteID)
+	if m := model.WorkspaceFrom(ctx); m != nil {
+		q.ColumnExpr("CASE WHEN ? THEN ? END", repository.Owned(ctx, "note", userID), m.Role.NoteRole())
+	} else {
+		q.ColumnExpr("CASE WHEN ? THEN 'owner' ELSE ? END", repository.Owned(ctx, "note", userID), grantRole(userID))
+	}
+
+	var ownerID int64
+	var role sql.NullString
+	err := q.Scan(ctx, &ownerID, &role)
+	if err != nil {
+		return 0, "", repository.IsNoRowsError(err)
+	}
+	if !role.Valid {
+		return 0, "", model.ErrNotFound
+	}
+	return ownerID, model.Role(role.String), nil
+}
+
+// ListSharedWith returns notes of other users shared with the user, directly or through
+// their tags, recently updated first.
+func (r *repo) ListSharedWith(ctx context.Context, userID int64, limit, offset int) ([]model.SharedNote, error) {
+	var rows []struct {
+		ID         int64  `bun:"id"`
+		Role       string `bun:"role"`
+		OwnerEmail string `bun:"owner_email"`
+		OwnerName  string `bun:"owner_name"`
+	}
+	err := r.db.NewSelect().
+		Model((*model.Note)(nil)).
+		ColumnExpr("note.id").
+		ColumnExpr("? AS role", grantRole(userID)).
+		ColumnExpr("owner.email AS owner_email, owner.name AS owner_name").
+		Join("JOIN users AS owner ON owner.id = note.user_id").
+		Where("note.id IN (?)", grantedNotes(userID)).
+		Where("note.user_id <> ?", userID).
+		Where("? IS NOT NULL", grantRole(userID)).
+		OrderExpr("note.updated_at DESC, note.id DESC").
+		Limit(limit).
+		Offset(offset).
+		Scan(ctx, &rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return []model.SharedNote{}, nil
+	}
+
+	ids := make([]int64, len(rows))
+	for i, row := range rows {
+		ids[i] = row.ID
+	}
+	var notes []model.Note
+	err = r.db.NewSelect().
+		Model(&notes).
+		Relation("Tags", withTagSource).
+		Where("note.id IN (?)", bun.In(ids)).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[int64]*model.Note, len(notes))
+	for i := range notes {
+		byID[notes[i].ID] = &notes[i]
+	}
+
+	shared := make([]model.SharedNote, 0, len(rows))
+	for _, row := range rows {
+		note, ok := byID[row.ID]
+		if !ok {
+			continue // moved to trash in between
+		}
+		shared = append(shared, model.SharedNote{
+			Note:       note,
+			Role:       model.Role(row.Role),
+			OwnerEmail: row.OwnerEmail,
+			OwnerName:  row.OwnerName,
+		})
+	}
+	return shared, nil
+}
@@ -0,0 +1,117 @@
+package note
+
+import (
+	"testing"
+
+	"github.com/Rasulikus/notebook/internal/model"
+	testdb "github.com/Rasulikus/notebook/internal/repository/test_db"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Repo_Access(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	owner := ensureUser(t, ts.db, ts.ctx)
+	friend := &model.User{Email: "friend@mail.ru", PasswordHash: "x", Name: "friend"}
+	_, err := ts.db.NewInsert().Model(friend).Exec(ts.ctx)
+	require.NoError(t, err)
+
+	work := &model.Tag{Name: "work", UserID: owner.ID}
+	require.NoError(t, ts.tagRepo.Create(ts.ctx, work))
+	direct, err := ts.noteRepo.Create(ts.ctx, &model.Note{Title: "direct", UserID: owner.ID}, nil)
+	require.NoError(t, err)
+	tagged, err := ts.noteRepo.Create(ts.ctx, &model.Note{Title: "tagged", UserID: owner.ID}, []*model.Tag{work})
+	require.NoError(t, err)
+	private, err := ts.noteRepo.Create(ts.ctx, &model.Note{Title: "private", UserID: owner.ID}, nil)
+	require.NoError(t, err)
+
+	grants := []*model.AccessGrant{
+		{OwnerID: owner.ID, GranteeID: friend.ID, NoteID: direct.ID, Role: model.RoleViewer},
+		{OwnerID: owner.ID, GranteeID: friend.ID, NoteID: tagged.ID, Role: model.RoleViewer},
+		{OwnerID: owner.ID, GranteeID: friend.ID, TagID: work.ID, Role: model.RoleEditor},
+	}
+	for _, g := range grants {
+		_, err := ts.db.NewInsert().Model(g).Exec(ts.ctx)
+		require.NoError(t, err)
+	}
+
+	ownerID, role, err := ts.noteRepo.Access(ts.ctx, owner.ID, private.ID)
+	require.NoError(t, err)
+	require.Equal(t, owner.ID, ownerID)
+	require.Equal(t, model.RoleOwner, role)
+
+	ownerID, role, err = ts.noteRepo.Access(ts.ctx, friend.ID, direct.ID)
+	require.NoError(t, err)
+	require.Equal(t, owner.ID, ownerID)
+	require.Equal(t, model.RoleViewer, role)
+
+	_, role, err = ts.noteRepo.Access(ts.ctx, friend.ID, tagged.ID)
+	require.NoError(t, err)
+	require.Equal(t, model.RoleEditor, role, "the strongest grant wins")
+
+	_, _, err = ts.noteRepo.Access(ts.ctx, friend.ID, private.ID)
+	require.ErrorIs(t, err, model.ErrNotFound)
+
+	require.NoError(t, ts.noteRepo.DeleteByID(ts.ctx, owner.ID, direct.ID, 0))
+	_, _, err = ts.noteRepo.Access(ts.ctx, friend.ID, direct.ID)
+	require.ErrorIs(t, err, model.ErrNotFound, "notes in trash are not shared")
+
+	shared, err := ts.noteRepo.ListSharedWith(ts.ctx, friend.ID, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, shared, 1)
+	require.Equal(t, tagged.ID, shared[0].Note.ID)
+	require.Equal(t, model.RoleEditor, shared[0].Role)
+	require.Equal(t, owner.Email, shared[0].OwnerEmail)
+	require.Len(t, shared[0].Note.Tags, 1)
+
+	shared, err = ts.noteRepo.ListSharedWith(ts.ctx, owner.ID, 10, 0)
+	require.NoError(t, err)
+	require.Empty(t, shared, "own notes are not listed")
+}
+
+func Test_Repo_Access_TagSubtree(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	owner := ensureUser(t, ts.db, ts.ctx)
+	friend := &model.User{Email: "friend@mail.ru", PasswordHash: "x", Name: "friend"}
+	_, err := ts.db.NewInsert().Model(friend).Exec(ts.ctx)
+	require.NoError(t, err)
+
+	work := &model.Tag{Name: "work", UserID: owner.ID}
+	require.NoError(t, ts.tagRepo.Create(ts.ctx, work))
+	clients := &model.Tag{Name: "clients", ParentID: work.ID, UserID: owner.ID}
+	require.NoError(t, ts.tagRepo.Create(ts.ctx, clients))
+	acme := &model.Tag{Name: "acme", ParentID: clients.ID, UserID: owner.ID}
+	require.NoError(t, ts.tagRepo.Create(ts.ctx, acme))
+	home := &model.Tag{Name: "home", UserID: owner.ID}
+	require.NoError(t, ts.tagRepo.Create(ts.ctx, home))
+
+	deep, err := ts.noteRepo.Create(ts.ctx, &model.Note{Title: "deep", UserID: owner.ID}, []*model.Tag{acme})
+	require.NoError(t, err)
+	other, err := ts.noteRepo.Create(ts.ctx, &model.Note{Title: "other", UserID: owner.ID}, []*model.Tag{home})
+	require.NoError(t, err)
+
+	grant := &model.AccessGrant{OwnerID: owner.ID, GranteeID: friend.ID, TagID: clients.ID, Role: model.RoleViewer}
+	_, err = ts.db.NewInsert().Model(grant).Exec(ts.ctx)
+	require.NoError(t, err)
+
+	_, role, err := ts.noteRepo.Access(ts.ctx, friend.ID, deep.ID)
+	require.NoError(t, err)
+	require.Equal(t, model.RoleViewer, role, "a grant on a tag covers its descendants")
+	_, _, err = ts.noteRepo.Access(ts.ctx, friend.ID, other.ID)
+	require.ErrorIs(t, err, model.ErrNotFound)
+
+	shared, err := ts.noteRepo.ListSharedWith(ts.ctx, friend.ID, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, shared, 1)
+	require.Equal(t, deep.ID, shared[0].Note.ID)
+
+	grant.TagID = work.ID
+	grant.ID = 0
+	grant.Role = model.RoleEditor
+	_, err = ts.db.NewInsert().Model(grant).Exec(ts.ctx)
+	require.NoError(t, err)
+	_, role, err = ts.noteRepo.Access(ts.ctx, friend.ID, deep.ID)
+	require.NoError(t, err)
+	require.Equal(t, model.RoleEditor, role, "the strongest grant up the tree wins")
+}
@@ -0,0 +1,62 @@
+package note
+
+import (
+	"context"
+
+	"github.com/Rasulikus/notebook/internal/markup"
+	"github.com/Rasulikus/notebook/internal/model"
+	"github.com/uptrace/bun"
+)
+
+// withTagSource loads note tags together with the way they were attached (Tag.Source).
+func withTagSource(q *bun.SelectQuery) *bun.SelectQuery {
+	return q.ColumnExpr("tag.*").ColumnExpr("note_tag.source")
+}
+
+// syncAutoTags makes auto tags of the note match hashtags in text: tags of hashtags that are gone
+// are detached, new ones are attached (and created as root tags if missing). Hashtags that are
+// not valid tag names (model.CheckTagName) are skipped. Manually attached tags are never touched,
+// even when the text has the same hashtag.
+func syncAutoTags(ctx context.Context, tx bun.Tx, userID, noteID int64, text string) error {
+	var names []string
+	for _, name := range markup.Hashtags(text) {
+		if model.CheckTagName(name) == "" {
+			names = append(names, name)
+		}
+	}
+	tagIDs, err := resolveTagNames(ctx, tx, userID, names)
+	if err != nil {
+		return err
+	}
+
+	q := tx.NewDelete().
+		Model((*model.NoteTag)(nil)).
+		Where("note_id = ? AND source = ?", noteID, model.TagSourceAuto)
+	if len(tagIDs) > 0 {
+		q.Where("tag_id NOT IN (?)", bun.In(tagIDs))
+	}
+	if _, err := q.Exec(ctx); err != nil {
+		return err
+	}
+	for _, tagID := range tagIDs {
+		_, err = tx.NewInsert().
+			Model(&model.NoteTag{NoteID: noteID, TagID: tagID, Source: model.TagSourceAuto}).
+			Column("note_id", "tag_id", "source").
+			On("CONFLICT (note_id, tag_id) DO NOTHING").
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// keepAutoTags turns auto tags of the note into manual ones, so they stay when syncing is off.
+func keepAutoTags(ctx context.Context, tx bun.Tx, noteID int64) error {
+	_, err := tx.NewUpdate().
+		Model((*model.NoteTag)(nil)).
+		Set("source = ?", model.TagSourceManual).
+		Where("note_id = ? AND source = ?", noteID, model.TagSourceAuto).
+		Exec(ctx)
+	return err
+}
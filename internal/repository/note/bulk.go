@@ -0,0 +1,80 @@
+package note
+
+import (
+	"context"
+
+	"github.com/Rasulikus/notebook/internal/model"
+	"github.com/Rasulikus/notebook/internal/repository"
+	"github.com/uptrace/bun"
+)
+
+// UpdateTags adds and removes tags on several user's notes in one transaction.
+// Every note gets a revision and a new version, like with UpdateByID;
+// if any of the notes is missing nothing is changed and ErrNotFound is returned.
+func (r *repo) UpdateTags(ctx context.Context, userID int64, noteIDs, add, remove []int64) ([]model.Note, error) {
+	noteIDs = repository.UniqueIDs(noteIDs)
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		var notes []model.Note
+		err := tx.NewSelect().
+			Model(&notes).
+			Where("id IN (?) AND ?", bun.In(noteIDs), repository.Owned(ctx, "", userID)).
+			Order("id").
+			For("UPDATE").
+			Scan(ctx)
+		if err != nil {
+			return err
+		}
+		if len(notes) != len(noteIDs) {
+			return model.ErrNotFound
+		}
+		for i := range notes {
+			if err := snapshotRevision(ctx, tx, &notes[i]); err != nil {
+				return err
+			}
+		}
+
+		if len(remove) > 0 {
+			_, err = tx.NewDelete().
+				Model((*model.NoteTag)(nil)).
+				Where("note_id IN (?)", bun.In(noteIDs)).
+				Where("tag_id IN (?)", bun.In(remove)).
+				Exec(ctx)
+			if err != nil {
+				return err
+			}
+		}
+		if len(add) > 0 {
+			_, err = tx.NewRaw(`INSERT INTO notes_tags (note_id, tag_id)
+				SELECT n.id, t.id FROM notes AS n CROSS JOIN tags AS t
+				WHERE n.id IN (?) AND t.id IN (?)
+				ON CONFLICT (note_id, tag_id) DO UPDATE SET source = ?`, bun.In(noteIDs), bun.In(add), model.TagSourceManual).
+				Exec(ctx)
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err = tx.NewUpdate().
+			Model((*model.Note)(nil)).
+			Set("updated_at = now()").
+			Set("version = version + 1").
+			Where("id IN (?)", bun.In(noteIDs)).
+			Exec(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	notes := []model.Note{}
+	err = r.db.NewSelect().
+		Model(&notes).
+		Relation("Tags", withTagSource).
+		Where("id IN (?) AND ?", bun.In(noteIDs), repository.Owned(ctx, "", userID)).
+		Order("id").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return notes, nil
+}
@@ -0,0 +1,59 @@
+package note
+
+import (
+	"context"
+
+	"github.com/Rasulikus/notebook/internal/model"
+	"github.com/Rasulikus/notebook/internal/repository"
+	"github.com/uptrace/bun"
+)
+
+// Graph loads up to filter.Limit of user's notes matching the filter (oldest first),
+// tags attached to them and links between them. Sorting, paging and cursors of the filter are ignored.
+func (r *repo) Graph(ctx context.Context, userID int64, filter *model.NoteFilter) (*model.NoteGraph, error) {
+	g := &model.NoteGraph{Notes: []model.Note{}, Tags: []model.Tag{}, NoteTags: []model.NoteTag{}, Links: []model.NoteLink{}}
+	q := r.db.NewSelect().
+		Model(&g.Notes).
+		Column("id", "title").
+		Where("?", repository.Owned(ctx, "note", userID)).
+		Order("note.id").
+		Limit(filter.Limit)
+	applyNoteFilter(q, filter)
+	if err := q.Scan(ctx); err != nil {
+		return nil, err
+	}
+	if len(g.Notes) == 0 {
+		return g, nil
+	}
+
+	ids := make([]int64, 0, len(g.Notes))
+	for _, n := range g.Notes {
+		ids = append(ids, n.ID)
+	}
+	err := r.db.NewSelect().
+		Model(&g.NoteTags).
+		Column("note_id", "tag_id", "source").
+		Where("note_id IN (?)", bun.In(ids)).
+		Order("note_id", "tag_id").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = r.db.NewSelect().
+		Model(&g.Tags).
+		Where("id IN (SELECT tag_id FROM notes_tags WHERE note_id IN (?))", bun.In(ids)).
+		Order("path", "id").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = r.db.NewSelect().
+		Model(&g.Links).
+		Where("source_id IN (?) AND target_id IN (?)", bun.In(ids), bun.In(ids)).
+		Order("source_id", "ref").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return g, nil
+}
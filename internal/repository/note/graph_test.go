@@ -0,0 +1,42 @@
+package note
+
+import (
+	"testing"
+
+	"github.com/Rasulikus/notebook/internal/model"
+	testdb "github.com/Rasulikus/notebook/internal/repository/test_db"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Repo_Graph(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	user := ensureUser(t, ts.db, ts.ctx)
+	work := &model.Tag{Name: "work", UserID: user.ID}
+	require.NoError(t, ts.tagRepo.Create(ts.ctx, work))
+
+	a, err := ts.noteRepo.Create(ts.ctx, &model.Note{Title: "a", Text: "see [[b]] and [[c]]", UserID: user.ID}, []*model.Tag{work})
+	require.NoError(t, err)
+	b, err := ts.noteRepo.Create(ts.ctx, &model.Note{Title: "b", UserID: user.ID}, []*model.Tag{work})
+	require.NoError(t, err)
+	_, err = ts.noteRepo.Create(ts.ctx, &model.Note{Title: "c", UserID: user.ID}, nil)
+	require.NoError(t, err)
+
+	g, err := ts.noteRepo.Graph(ts.ctx, user.ID, &model.NoteFilter{Limit: 100})
+	require.NoError(t, err)
+	require.Len(t, g.Notes, 3)
+	require.Len(t, g.Tags, 1)
+	require.Len(t, g.NoteTags, 2)
+	require.Len(t, g.Links, 2)
+
+	g, err = ts.noteRepo.Graph(ts.ctx, user.ID, &model.NoteFilter{Limit: 100, TagIDs: []int64{work.ID}})
+	require.NoError(t, err)
+	require.Len(t, g.Notes, 2)
+	require.Len(t, g.Links, 1, "only links inside the filtered set")
+	require.Equal(t, a.ID, g.Links[0].SourceID)
+	require.Equal(t, b.ID, g.Links[0].TargetID)
+
+	g, err = ts.noteRepo.Graph(ts.ctx, 9999999, &model.NoteFilter{Limit: 100})
+	require.NoError(t, err)
+	require.Empty(t, g.Notes)
+}
@@ -0,0 +1,135 @@
+package note
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/Rasulikus/notebook/internal/markup"
+	"github.com/Rasulikus/notebook/internal/model"
+	"github.com/Rasulikus/notebook/internal/repository"
+	"github.com/uptrace/bun"
+)
+
+// syncLinks stores [[...]] references from the text of the note, resolving them to user's notes.
+// Links that are already resolved keep their target, so renaming the target doesn't break them;
+// dangling ones are resolved again.
+func syncLinks(ctx context.Context, tx bun.Tx, userID, noteID int64, text string) error {
+	refs := markup.WikiLinks(text)
+	q := tx.NewDelete().
+		Model((*model.NoteLink)(nil)).
+		Where("source_id = ?", noteID)
+	if len(refs) > 0 {
+		q.Where("ref NOT IN (?)", bun.In(refs))
+	}
+	if _, err := q.Exec(ctx); err != nil {
+		return err
+	}
+
+	for _, ref := range refs {
+		targetID, err := resolveLink(ctx, tx, userID, ref)
+		if err != nil {
+			return err
+		}
+		_, err = tx.NewInsert().
+			Model(&model.NoteLink{SourceID: noteID, Ref: ref, TargetID: targetID}).
+			On("CONFLICT (source_id, ref) DO UPDATE").
+			Set("target_id = COALESCE(note_link.target_id, EXCLUDED.target_id)").
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolveLink finds the user's note a reference points to: by id for [[note:123]],
+// otherwise the oldest note with the title, case-insensitively. 0 means there is no such note.
+func resolveLink(ctx context.Context, tx bun.Tx, userID int64, ref string) (int64, error) {
+	q := tx.NewSelect().
+		Model((*model.Note)(nil)).
+		Column("id").
+		Where("?", repository.Owned(ctx, "", userID))
+	if id, ok := markup.LinkNoteID(ref); ok {
+		q.Where("id = ?", id)
+	} else {
+		q.Where("lower(title) = lower(?)", ref).Order("id").Limit(1)
+	}
+	var id int64
+	err := q.Scan(ctx, &id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	return id, err
+}
+
+// resolveDangling points user's dangling links written as [[title]] to the note,
+// once a note with that title appears.
+func resolveDangling(ctx context.Context, tx bun.Tx, userID, noteID int64, title string) error {
+	_, err := tx.NewUpdate().
+		Model((*model.NoteLink)(nil)).
+		Set("target_id = ?", noteID).
+		Where("note_link.target_id IS NULL AND lower(note_link.ref) = lower(?)", title).
+		Where("note_link.source_id IN (SELECT id FROM notes WHERE ?)", repository.Owned(ctx, "", userID)).
+		Exec(ctx)
+	return err
+}
+
+// ListLinks returns references from the user's note ordered by ref. Links to missing
+// or trashed notes are dangling (TargetID = 0).
+func (r *repo) ListLinks(ctx context.Context, userID, noteID int64) ([]model.NoteLink, error) {
+	if err := r.ensureNote(ctx, userID, noteID); err != nil {
+		return nil, err
+	}
+
+	links := []model.NoteLink{}
+	err := r.db.NewSelect().
+		Model(&links).
+		ColumnExpr("note_link.source_id, note_link.ref").
+		ColumnExpr("target_note.id AS target_id, target_note.title AS target_title").
+		Join("LEFT JOIN notes AS target_note ON target_note.id = note_link.target_id AND target_note.deleted_at IS NULL").
+		Where("note_link.source_id = ?", noteID).
+		Order("note_link.ref").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+// ListBacklinks returns links from other user's notes (not in trash) to the note, ordered by their title.
+func (r *repo) ListBacklinks(ctx context.Context, userID, noteID int64) ([]model.NoteLink, error) {
+	if err := r.ensureNote(ctx, userID, noteID); err != nil {
+		return nil, err
+	}
+
+	links := []model.NoteLink{}
+	err := r.db.NewSelect().
+		Model(&links).
+		ColumnExpr("note_link.*").
+		ColumnExpr("source_note.title AS source_title").
+		Join("JOIN notes AS source_note ON source_note.id = note_link.source_id AND source_note.deleted_at IS NULL").
+		Where("note_link.target_id = ?", noteID).
+		Where("?", repository.Owned(ctx, "source_note", userID)).
+		Order("source_note.title", "note_link.source_id").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+// ensureNote returns ErrNotFound unless the user has the note.
+func (r *repo) ensureNote(ctx context.Context, userID, noteID int64) error {
+	exists, err := r.db.NewSelect().
+		Model((*model.Note)(nil)).
+		Where("id = ? AND ?", noteID, repository.Owned(ctx, "", userID)).
+		Exists(ctx)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return model.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package note
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Rasulikus/notebook/internal/model"
+	testdb "github.com/Rasulikus/notebook/internal/repository/test_db"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Repo_Links(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	user := ensureUser(t, ts.db, ts.ctx)
+	target, err := ts.noteRepo.Create(ts.ctx, &model.Note{Title: "Go Notes", UserID: user.ID}, nil)
+	require.NoError(t, err)
+
+	text := fmt.Sprintf("see [[go notes]], [[note:%d]] and [[Later]], not `[[code]]`", target.ID)
+	source, err := ts.noteRepo.Create(ts.ctx, &model.Note{Title: "source", Text: text, UserID: user.ID}, nil)
+	require.NoError(t, err)
+
+	links, err := ts.noteRepo.ListLinks(ts.ctx, user.ID, source.ID)
+	require.NoError(t, err)
+	require.Len(t, links, 3)
+	byRef := map[string]model.NoteLink{}
+	for _, l := range links {
+		byRef[l.Ref] = l
+	}
+	require.Equal(t, target.ID, byRef["go notes"].TargetID, "titles match case-insensitively")
+	require.Equal(t, target.ID, byRef[fmt.Sprintf("note:%d", target.ID)].TargetID)
+	require.True(t, byRef["Later"].Dangling())
+
+	title := "Renamed"
+	_, err = ts.noteRepo.UpdateByID(ts.ctx, user.ID, target.ID, &model.NoteUpdate{Title: &title})
+	require.NoError(t, err)
+	backlinks, err := ts.noteRepo.ListBacklinks(ts.ctx, user.ID, target.ID)
+	require.NoError(t, err)
+	require.Len(t, backlinks, 2, "links survive renaming of the target")
+	require.Equal(t, "source", backlinks[0].SourceTitle)
+
+	later, err := ts.noteRepo.Create(ts.ctx, &model.Note{Title: "later", UserID: user.ID}, nil)
+	require.NoError(t, err)
+	backlinks, err = ts.noteRepo.ListBacklinks(ts.ctx, user.ID, later.ID)
+	require.NoError(t, err)
+	require.Len(t, backlinks, 1, "dangling link is resolved by a new note")
+
+	text = "only [[Later]]"
+	_, err = ts.noteRepo.UpdateByID(ts.ctx, user.ID, source.ID, &model.NoteUpdate{Text: &text})
+	require.NoError(t, err)
+	backlinks, err = ts.noteRepo.ListBacklinks(ts.ctx, user.ID, target.ID)
+	require.NoError(t, err)
+	require.Empty(t, backlinks, "links removed from the text are dropped")
+
+	_, err = ts.noteRepo.ListLinks(ts.ctx, 9999999, source.ID)
+	require.ErrorIs(t, err, model.ErrNotFound)
+}
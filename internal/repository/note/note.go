@@ -3,6 +3,10 @@ package note
 import (
 	"context"
 	"fmt"
+	"html"
+	"slices"
+	"strings"
+	"unicode"
 
 	"github.com/Rasulikus/notebook/internal/model"
 	"github.com/Rasulikus/notebook/internal/repository"
@@ -17,21 +21,41 @@ func NewRepository(db *bun.DB) *repo {
 	return &repo{db: db}
 }
 
+// Create inserts the note and attaches tags. A tag without ID is looked up by its Name
+// as a path and created for the user in the same transaction if missing.
+// With note.AutoTags hashtags of the text are attached as auto tags too.
+// [[...]] links of the text are stored, and dangling links to the new title get resolved.
 func (r *repo) Create(ctx context.Context, note *model.Note, tags []*model.Tag) (*model.Note, error) {
 	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
-		_, err := tx.NewInsert().Model(note).Returning("*").Exec(ctx)
+		_, err := tx.NewInsert().Model(note).Returning("id").Exec(ctx)
 		if err != nil {
 			return err
 		}
-		if len(tags) == 0 {
-			return nil
+		if err := syncLinks(ctx, tx, note.UserID, note.ID, note.Text); err != nil {
+			return err
+		}
+		if err := resolveDangling(ctx, tx, note.UserID, note.ID, note.Title); err != nil {
+			return err
 		}
+		var tagIDs []int64
+		var names []string
 		for _, tag := range tags {
-			if tag == nil || tag.ID == 0 {
-				return fmt.Errorf("invalid tag: nil or id=0")
+			switch {
+			case tag == nil || tag.ID == 0 && tag.Name == "":
+				return fmt.Errorf("invalid tag: nil or without id and name")
+			case tag.ID == 0:
+				names = append(names, tag.Name)
+			default:
+				tagIDs = append(tagIDs, tag.ID)
 			}
+		}
+		resolved, err := resolveTagNames(ctx, tx, note.UserID, names)
+		if err != nil {
+			return err
+		}
+		for _, tagID := range append(tagIDs, resolved...) {
 			_, err = tx.NewInsert().
-				Model(&model.NoteTag{NoteID: note.ID, TagID: tag.ID}).
+				Model(&model.NoteTag{NoteID: note.ID, TagID: tagID}).
 				Column("note_id", "tag_id").
 				On("CONFLICT (note_id, tag_id) DO NOTHING").
 				Exec(ctx)
@@ -39,6 +63,9 @@ func (r *repo) Create(ctx context.Context, note *model.Note, tags []*model.Tag)
 				return err
 			}
 		}
+		if note.AutoTags {
+			return syncAutoTags(ctx, tx, note.UserID, note.ID, note.Text)
+		}
 		return nil
 	})
 	if err != nil {
@@ -47,42 +74,249 @@ func (r *repo) Create(ctx context.Context, note *model.Note, tags []*model.Tag)
 	return r.GetByID(ctx, note.UserID, note.ID)
 }
 
-func (r *repo) List(ctx context.Context, userID int64, limit, offset int, order string) ([]model.Note, error) {
+// noteSortColumns maps sort fields from model.NoteSortFields to SQL.
+var noteSortColumns = map[string]repository.SortColumn[model.Note]{
+	"created_at": {Expr: "note.created_at", Value: func(n *model.Note) any { return n.CreatedAt }},
+	"updated_at": {Expr: "note.updated_at", Value: func(n *model.Note) any { return n.UpdatedAt }},
+	"title":      {Expr: "note.title", Value: func(n *model.Note) any { return n.Title }},
+	"pinned":     {Expr: "note.pinned", Value: func(n *model.Note) any { return n.Pinned }},
+}
+
+// List returns a page of user's notes matching the filter, ordered by filter.Sort
+// with the note id as a tie-breaker so cursors always point to a unique position.
+func (r *repo) List(ctx context.Context, userID int64, filter *model.NoteFilter) (*model.Page[model.Note], error) {
+	keyset, cursorOf, err := repository.NewKeyset(filter.Sort, noteSortColumns, "note.id", noteID)
+	if err != nil {
+		return nil, err
+	}
+
 	var notes []model.Note
-	err := r.db.NewSelect().
+	q := r.db.NewSelect().
 		Model(&notes).
-		Where("user_id = ?", userID).
-		Relation("Tags").
-		Order(order).
+		Where("?", repository.Owned(ctx, "note", userID)).
+		Relation("Tags", withTagSource)
+	applyNoteFilter(q, filter)
+	if err := keyset.Apply(q, filter.Cursor); err != nil {
+		return nil, err
+	}
+	if filter.Cursor == "" {
+		q.Offset(filter.Offset)
+	}
+	err = q.Limit(filter.Limit + 1).Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return repository.NewPage(keyset, notes, filter.Limit, cursorOf)
+}
+
+func noteID(n *model.Note) int64 { return n.ID }
+
+// applyNoteFilter adds flag, notebook, tag and date conditions of the filter to a notes select.
+func applyNoteFilter(q *bun.SelectQuery, filter *model.NoteFilter) {
+	if filter.Pinned != nil {
+		q.Where("note.pinned = ?", *filter.Pinned)
+	}
+	if filter.Archived != nil {
+		q.Where("note.archived = ?", *filter.Archived)
+	}
+	if filter.Favorite != nil {
+		q.Where("note.favorite = ?", *filter.Favorite)
+	}
+	if filter.NotebookID != 0 {
+		if filter.Recursive {
+			q.Where("note.notebook_id IN ("+repository.SubtreeSQL("notebooks")+")", filter.NotebookID)
+		} else {
+			q.Where("note.notebook_id = ?", filter.NotebookID)
+		}
+	}
+	if len(filter.TagIDs) > 0 {
+		switch {
+		case filter.TagMode == model.TagMatchAll && filter.TagDescendants:
+			// every tag must match by itself or by one of its descendants
+			for _, tagID := range repository.UniqueIDs(filter.TagIDs) {
+				q.Where("note.id IN (SELECT nt.note_id FROM notes_tags AS nt WHERE nt.tag_id IN ("+
+					repository.SubtreeSQL("tags")+"))", tagID)
+			}
+		case filter.TagMode == model.TagMatchAll:
+			q.Where(`note.id IN (
+				SELECT nt.note_id FROM notes_tags AS nt
+				WHERE nt.tag_id IN (?)
+				GROUP BY nt.note_id
+				HAVING count(DISTINCT nt.tag_id) = ?)`, bun.In(filter.TagIDs), len(repository.UniqueIDs(filter.TagIDs)))
+		default:
+			q.Where("note.id IN (SELECT nt.note_id FROM notes_tags AS nt WHERE nt.tag_id IN ("+tagSetSQL(filter)+"))", bun.In(filter.TagIDs))
+		}
+	}
+	if len(filter.ExcludeTagIDs) > 0 {
+		q.Where("note.id NOT IN (SELECT nt.note_id FROM notes_tags AS nt WHERE nt.tag_id IN ("+tagSetSQL(filter)+"))", bun.In(filter.ExcludeTagIDs))
+	}
+	if !filter.CreatedAfter.IsZero() {
+		q.Where("note.created_at >= ?", filter.CreatedAfter)
+	}
+	if !filter.CreatedBefore.IsZero() {
+		q.Where("note.created_at < ?", filter.CreatedBefore)
+	}
+	if !filter.UpdatedAfter.IsZero() {
+		q.Where("note.updated_at >= ?", filter.UpdatedAfter)
+	}
+	if !filter.UpdatedBefore.IsZero() {
+		q.Where("note.updated_at < ?", filter.UpdatedBefore)
+	}
+}
+
+// tagSetSQL expands a "?" list of tag ids to their subtrees if the filter asks for descendants.
+func tagSetSQL(filter *model.NoteFilter) string {
+	if filter.TagDescendants {
+		return repository.SubtreeSQL("tags")
+	}
+	return "?"
+}
+
+// snippetStart and snippetStop delimit matches in ts_headline output. ts_headline copies note text
+// as is, so matches are marked with control characters (removed from the text beforehand) and
+// turned into <mark> tags by highlightSnippet only after the text is HTML-escaped.
+const (
+	snippetStart = "\x01"
+	snippetStop  = "\x02"
+)
+
+// headlineOptions configures ts_headline output for search snippets.
+const headlineOptions = "StartSel=" + snippetStart + ", StopSel=" + snippetStop + ", MinWords=10, MaxWords=30, MaxFragments=2"
+
+// Search finds user's notes by title and text using the search_vector column.
+// Every word of the query is matched as a prefix; results are ordered by rank.
+func (r *repo) Search(ctx context.Context, userID int64, query string, limit, offset int) ([]model.NoteSearchHit, error) {
+	tsQuery := toPrefixTSQuery(query)
+	if tsQuery == "" {
+		return []model.NoteSearchHit{}, nil
+	}
+
+	var ranked []struct {
+		ID      int64   `bun:"id"`
+		Rank    float64 `bun:"rank"`
+		Snippet string  `bun:"snippet"`
+	}
+	err := r.db.NewSelect().
+		Model((*model.Note)(nil)).
+		Column("id").
+		ColumnExpr("ts_rank(note.search_vector, q.query) AS rank").
+		ColumnExpr("ts_headline('simple', translate(coalesce(note.text, ''), ?, ''), q.query, ?) AS snippet",
+			snippetStart+snippetStop, headlineOptions).
+		Join("CROSS JOIN to_tsquery('simple', ?) AS q(query)", tsQuery).
+		Where("?", repository.Owned(ctx, "note", userID)).
+		Where("note.search_vector @@ q.query").
+		OrderExpr("rank DESC, note.id DESC").
 		Limit(limit).
 		Offset(offset).
+		Scan(ctx, &ranked)
+	if err != nil {
+		return nil, err
+	}
+	if len(ranked) == 0 {
+		return []model.NoteSearchHit{}, nil
+	}
+
+	ids := make([]int64, 0, len(ranked))
+	for _, h := range ranked {
+		ids = append(ids, h.ID)
+	}
+	var notes []model.Note
+	err = r.db.NewSelect().
+		Model(&notes).
+		Relation("Tags", withTagSource).
+		Where("id IN (?)", bun.In(ids)).
 		Scan(ctx)
 	if err != nil {
 		return nil, err
 	}
-	return notes, nil
+	byID := make(map[int64]*model.Note, len(notes))
+	for i := range notes {
+		byID[notes[i].ID] = &notes[i]
+	}
+
+	hits := make([]model.NoteSearchHit, 0, len(ranked))
+	for _, h := range ranked {
+		n, ok := byID[h.ID]
+		if !ok {
+			continue
+		}
+		hits = append(hits, model.NoteSearchHit{Note: n, Rank: h.Rank, Snippet: highlightSnippet(h.Snippet)})
+	}
+	return hits, nil
+}
+
+// highlightSnippet HTML-escapes ts_headline output and wraps the matches in <mark> tags.
+func highlightSnippet(snippet string) string {
+	escaped := html.EscapeString(snippet)
+	return strings.NewReplacer(snippetStart, "<mark>", snippetStop, "</mark>").Replace(escaped)
+}
+
+// toPrefixTSQuery turns free user input into a to_tsquery expression
+// where every word must match as a prefix: "go lang" -> "go:* & lang:*".
+// Everything except letters and digits is dropped, so the result is always a valid tsquery.
+func toPrefixTSQuery(query string) string {
+	words := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, w := range words {
+		words[i] = w + ":*"
+	}
+	return strings.Join(words, " & ")
 }
 
 func (r *repo) GetByID(ctx context.Context, userID, id int64) (*model.Note, error) {
 	note := new(model.Note)
-	err := r.db.NewSelect().Model(note).Relation("Tags").Where("id = ? AND user_id = ?", id, userID).Scan(ctx)
+	err := r.db.NewSelect().Model(note).Relation("Tags", withTagSource).Where("id = ? AND ?", id, repository.Owned(ctx, "", userID)).Scan(ctx)
 	if err != nil {
 		return nil, repository.IsNoRowsError(err)
 	}
 	return note, nil
 }
 
-func (r *repo) UpdateByID(ctx context.Context, userID, id int64, title, text *string, tagsIDs *[]int64) (*model.Note, error) {
+// UpdateByID applies partial changes to the note and bumps its version. If title, text or tags
+// change, the previous content is saved as a revision in the same transaction, so it can be undone.
+// Links of a new text are stored again. New tags replace only manually attached ones; auto tags follow hashtags of the text while
+// the note has AutoTags on.
+func (r *repo) UpdateByID(ctx context.Context, userID, id int64, upd *model.NoteUpdate) (*model.Note, error) {
 	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		current, err := lockNote(ctx, tx, userID, id)
+		if err != nil {
+			return err
+		}
+		if upd.IfVersion != 0 && current.Version != upd.IfVersion {
+			return model.ErrPreconditionFailed
+		}
+		if upd.ChangesContent() {
+			if err := snapshotRevision(ctx, tx, current); err != nil {
+				return err
+			}
+		}
+
 		q := tx.NewUpdate().
 			Model((*model.Note)(nil)).
 			Set("updated_at = now()").
-			Where("id = ? AND user_id = ?", id, userID)
-		if title != nil {
-			q.Set("title = ?", *title)
+			Set("version = version + 1").
+			Where("id = ? AND ?", id, repository.Owned(ctx, "", userID))
+		if upd.Title != nil {
+			q.Set("title = ?", *upd.Title)
+		}
+		if upd.Text != nil {
+			q.Set("text = ?", *upd.Text)
+		}
+		if upd.Pinned != nil {
+			q.Set("pinned = ?", *upd.Pinned)
+		}
+		if upd.Archived != nil {
+			q.Set("archived = ?", *upd.Archived)
 		}
-		if text != nil {
-			q.Set("text = ?", *text)
+		if upd.Favorite != nil {
+			q.Set("favorite = ?", *upd.Favorite)
+		}
+		if upd.NotebookID != nil {
+			q.Set("notebook_id = ?", bun.NullZero(*upd.NotebookID))
+		}
+		if upd.AutoTags != nil {
+			q.Set("auto_tags = ?", *upd.AutoTags)
 		}
 		res, err := q.Exec(ctx)
 		if err != nil {
@@ -96,23 +330,45 @@ func (r *repo) UpdateByID(ctx context.Context, userID, id int64, title, text *st
 		if aff == 0 {
 			return model.ErrNotFound
 		}
-		if tagsIDs != nil {
+		if upd.Text != nil {
+			if err := syncLinks(ctx, tx, userID, id, *upd.Text); err != nil {
+				return err
+			}
+		}
+		if upd.Title != nil {
+			if err := resolveDangling(ctx, tx, userID, id, *upd.Title); err != nil {
+				return err
+			}
+		}
+		if upd.TagIDs != nil || upd.TagNames != nil {
+			var tagIDs []int64
+			if upd.TagIDs != nil {
+				tagIDs = *upd.TagIDs
+			}
+			if upd.TagNames != nil {
+				resolved, err := resolveTagNames(ctx, tx, userID, *upd.TagNames)
+				if err != nil {
+					return err
+				}
+				tagIDs = append(slices.Clone(tagIDs), resolved...)
+			}
 			_, err = tx.NewDelete().
 				Table("notes_tags").
-				Where("note_id = ?", id).
+				Where("note_id = ? AND source = ?", id, model.TagSourceManual).
 				Exec(ctx)
 			if err != nil {
 				return err
 			}
-			for _, tagID := range *tagsIDs {
+			for _, tagID := range tagIDs {
 				if tagID == 0 {
 					return fmt.Errorf("invalid tag")
 				}
 
+				// a tag attached by hand is no longer removed with its hashtag
 				_, err = tx.NewInsert().
-					Model(&model.NoteTag{NoteID: id, TagID: tagID}).
-					Column("note_id", "tag_id").
-					On("CONFLICT (note_id, tag_id) DO NOTHING").
+					Model(&model.NoteTag{NoteID: id, TagID: tagID, Source: model.TagSourceManual}).
+					Column("note_id", "tag_id", "source").
+					On("CONFLICT (note_id, tag_id) DO UPDATE SET source = EXCLUDED.source").
 					Exec(ctx)
 				if err != nil {
 					return err
@@ -120,6 +376,20 @@ func (r *repo) UpdateByID(ctx context.Context, userID, id int64, title, text *st
 			}
 		}
 
+		autoTags := current.AutoTags
+		if upd.AutoTags != nil {
+			autoTags = *upd.AutoTags
+		}
+		switch {
+		case autoTags && !upd.KeepAutoTags && (upd.ChangesContent() || !current.AutoTags):
+			text := current.Text
+			if upd.Text != nil {
+				text = *upd.Text
+			}
+			return syncAutoTags(ctx, tx, userID, id, text)
+		case current.AutoTags && !autoTags:
+			return keepAutoTags(ctx, tx, id)
+		}
 		return nil
 	})
 	if err != nil {
@@ -133,8 +403,14 @@ func (r *repo) UpdateByID(ctx context.Context, userID, id int64, title, text *st
 	return note, nil
 }
 
-func (r *repo) DeleteByID(ctx context.Context, userID, id int64) error {
-	res, err := r.db.NewDelete().Model((*model.Note)(nil)).Where("id = ? AND user_id = ?", id, userID).Exec(ctx)
+// DeleteByID moves the note to trash (soft delete); it can be restored until purged.
+// A non-zero ifVersion makes it fail with ErrPreconditionFailed if the note has another version.
+func (r *repo) DeleteByID(ctx context.Context, userID, id, ifVersion int64) error {
+	q := r.db.NewDelete().Model((*model.Note)(nil)).Where("id = ? AND ?", id, repository.Owned(ctx, "", userID))
+	if ifVersion != 0 {
+		q.Where("version = ?", ifVersion)
+	}
+	res, err := q.Exec(ctx)
 	if err != nil {
 		return err
 	}
@@ -142,17 +418,26 @@ func (r *repo) DeleteByID(ctx context.Context, userID, id int64) error {
 	if err != nil {
 		return err
 	}
-	if aff == 0 {
-		return model.ErrNotFound
+	if aff > 0 {
+		return nil
 	}
-	return nil
+	if ifVersion != 0 {
+		exists, err := r.db.NewSelect().Model((*model.Note)(nil)).Where("id = ? AND ?", id, repository.Owned(ctx, "", userID)).Exists(ctx)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return model.ErrPreconditionFailed
+		}
+	}
+	return model.ErrNotFound
 }
 
 func (r *repo) DeleteTags(ctx context.Context, userID, id int64) error {
 	res, err := r.db.NewDelete().
 		TableExpr("notes_tags AS nt").
 		Where("nt.note_id = ?", id).
-		Where("EXISTS (SELECT 1 FROM notes n WHERE n.id = nt.note_id AND n.user_id = ?)", userID).
+		Where("EXISTS (SELECT 1 FROM notes n WHERE n.id = nt.note_id AND ?)", repository.Owned(ctx, "n", userID)).
 		Exec(ctx)
 	if err != nil {
 		return err
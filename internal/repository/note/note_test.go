@@ -152,17 +152,147 @@ func Test_Repo_List(t *testing.T) {
 	insertNote(t, ts.db, ts.ctx, user.ID, "n2", "note 2")
 	insertNote(t, ts.db, ts.ctx, user.ID, "n3", "note 3")
 
-	list, err := ts.noteRepo.List(ts.ctx, user.ID, 20, 0, "")
+	list, err := ts.noteRepo.List(ts.ctx, user.ID, &model.NoteFilter{Limit: 20})
 	require.NoError(t, err)
-	require.Equal(t, len(list), 3)
+	require.Equal(t, len(list.Items), 3)
+	require.False(t, list.HasMore)
 
-	listWithLimit, err := ts.noteRepo.List(ts.ctx, user.ID, 1, 0, "")
+	listWithLimit, err := ts.noteRepo.List(ts.ctx, user.ID, &model.NoteFilter{Limit: 1})
 	require.NoError(t, err)
-	require.Equal(t, len(listWithLimit), 1)
+	require.Equal(t, len(listWithLimit.Items), 1)
+	require.True(t, listWithLimit.HasMore)
 
-	listWithOffset, err := ts.noteRepo.List(ts.ctx, user.ID, 20, 1, "")
+	listWithOffset, err := ts.noteRepo.List(ts.ctx, user.ID, &model.NoteFilter{Limit: 20, Offset: 1})
 	require.NoError(t, err)
-	require.Equal(t, len(listWithOffset), 2)
+	require.Equal(t, len(listWithOffset.Items), 2)
+}
+
+func Test_Repo_List_Sort(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	user := ensureUser(t, ts.db, ts.ctx)
+	n1 := insertNote(t, ts.db, ts.ctx, user.ID, "a", "note 1")
+	n2 := insertNote(t, ts.db, ts.ctx, user.ID, "b", "note 2")
+	n3 := insertNote(t, ts.db, ts.ctx, user.ID, "a", "note 3")
+
+	sort, err := model.ParseSort("-title,id", model.NoteSortFields)
+	require.NoError(t, err)
+	list, err := ts.noteRepo.List(ts.ctx, user.ID, &model.NoteFilter{Limit: 20, Sort: sort})
+	require.NoError(t, err)
+	require.Len(t, list.Items, 3)
+	require.Equal(t, []int64{n2.ID, n1.ID, n3.ID}, []int64{list.Items[0].ID, list.Items[1].ID, list.Items[2].ID})
+
+	_, err = model.ParseSort("title; DROP TABLE notes", model.NoteSortFields)
+	var vErr *model.ValidationError
+	require.ErrorAs(t, err, &vErr)
+	require.Contains(t, vErr.Fields["sort"], "allowed: created_at, updated_at, title, id")
+}
+
+func Test_Repo_List_Cursor(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	user := ensureUser(t, ts.db, ts.ctx)
+	n1 := insertNote(t, ts.db, ts.ctx, user.ID, "b", "note 1")
+	n2 := insertNote(t, ts.db, ts.ctx, user.ID, "a", "note 2")
+	n3 := insertNote(t, ts.db, ts.ctx, user.ID, "b", "note 3")
+	titleDesc := model.Sort{{Name: "title", Desc: true}}
+
+	first, err := ts.noteRepo.List(ts.ctx, user.ID, &model.NoteFilter{Limit: 2, Sort: titleDesc})
+	require.NoError(t, err)
+	require.True(t, first.HasMore)
+	require.NotEmpty(t, first.NextCursor)
+	require.Equal(t, []int64{n3.ID, n1.ID}, []int64{first.Items[0].ID, first.Items[1].ID})
+
+	// a note created between page fetches must not shift the next page
+	insertNote(t, ts.db, ts.ctx, user.ID, "c", "note 4")
+
+	second, err := ts.noteRepo.List(ts.ctx, user.ID, &model.NoteFilter{Limit: 2, Sort: titleDesc, Cursor: first.NextCursor})
+	require.NoError(t, err)
+	require.False(t, second.HasMore)
+	require.Empty(t, second.NextCursor)
+	require.Len(t, second.Items, 1)
+	require.Equal(t, n2.ID, second.Items[0].ID)
+
+	_, err = ts.noteRepo.List(ts.ctx, user.ID, &model.NoteFilter{Limit: 2, Sort: model.Sort{{Name: "created_at"}}, Cursor: first.NextCursor})
+	var vErr *model.ValidationError
+	require.ErrorAs(t, err, &vErr, "cursor issued for another order")
+
+	_, err = ts.noteRepo.List(ts.ctx, user.ID, &model.NoteFilter{Limit: 2, Sort: titleDesc, Cursor: "garbage"})
+	require.ErrorAs(t, err, &vErr)
+}
+
+func Test_Repo_List_Filter(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	user := ensureUser(t, ts.db, ts.ctx)
+	work := &model.Tag{Name: "work", UserID: user.ID}
+	urgent := &model.Tag{Name: "urgent", UserID: user.ID}
+	hidden := &model.Tag{Name: "hidden", UserID: user.ID}
+	_, err := ts.tagRepo.CreateTags(ts.ctx, []*model.Tag{work, urgent, hidden})
+	require.NoError(t, err)
+
+	nWork, err := ts.noteRepo.Create(ts.ctx, &model.Note{Title: "work", UserID: user.ID}, []*model.Tag{work})
+	require.NoError(t, err)
+	nBoth, err := ts.noteRepo.Create(ts.ctx, &model.Note{Title: "both", UserID: user.ID}, []*model.Tag{work, urgent})
+	require.NoError(t, err)
+	nHidden, err := ts.noteRepo.Create(ts.ctx, &model.Note{Title: "hidden", UserID: user.ID}, []*model.Tag{work, hidden})
+	require.NoError(t, err)
+	old := insertNote(t, ts.db, ts.ctx, user.ID, "old", "no tags")
+	_, err = ts.db.NewUpdate().Model((*model.Note)(nil)).
+		Set("created_at = ?", time.Now().Add(-48*time.Hour)).
+		Where("id = ?", old.ID).
+		Exec(ts.ctx)
+	require.NoError(t, err)
+
+	ids := func(notes []model.Note) []int64 {
+		out := make([]int64, 0, len(notes))
+		for _, n := range notes {
+			out = append(out, n.ID)
+		}
+		return out
+	}
+
+	cases := []struct {
+		name   string
+		filter model.NoteFilter
+		want   []int64
+	}{
+		{
+			name:   "any of tags",
+			filter: model.NoteFilter{TagIDs: []int64{urgent.ID, hidden.ID}, TagMode: model.TagMatchAny},
+			want:   []int64{nBoth.ID, nHidden.ID},
+		},
+		{
+			name:   "all of tags",
+			filter: model.NoteFilter{TagIDs: []int64{work.ID, urgent.ID}, TagMode: model.TagMatchAll},
+			want:   []int64{nBoth.ID},
+		},
+		{
+			name:   "exclude tags",
+			filter: model.NoteFilter{TagIDs: []int64{work.ID}, ExcludeTagIDs: []int64{hidden.ID}},
+			want:   []int64{nWork.ID, nBoth.ID},
+		},
+		{
+			name:   "created before",
+			filter: model.NoteFilter{CreatedBefore: time.Now().Add(-24 * time.Hour)},
+			want:   []int64{old.ID},
+		},
+		{
+			name:   "created after",
+			filter: model.NoteFilter{CreatedAfter: time.Now().Add(-24 * time.Hour), ExcludeTagIDs: []int64{work.ID}},
+			want:   []int64{},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			filter := tc.filter
+			filter.Limit = 20
+			filter.Sort = model.Sort{{Name: "id"}}
+			got, err := ts.noteRepo.List(ts.ctx, user.ID, &filter)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, ids(got.Items))
+		})
+	}
 }
 
 func Test_Repo_GetByID(t *testing.T) {
@@ -213,7 +343,7 @@ func Test_Repo_Update(t *testing.T) {
 	require.WithinDuration(t, hourEarlie, newNote.UpdatedAt, time.Second)
 	require.Len(t, newNote.Tags, len(tags))
 
-	updNote, err := ts.noteRepo.UpdateByID(ts.ctx, n.UserID, n.ID, &updTitle, &updText, &[]int64{updTags[0].ID})
+	updNote, err := ts.noteRepo.UpdateByID(ts.ctx, n.UserID, n.ID, &model.NoteUpdate{Title: &updTitle, Text: &updText, TagIDs: &[]int64{updTags[0].ID}})
 	require.NoError(t, err, "update note error")
 	require.Len(t, updNote.Tags, len(updTags))
 	require.Equal(t, updTags[0].ID, updNote.Tags[0].ID)
@@ -226,7 +356,7 @@ func Test_Repo_DeleteByID(t *testing.T) {
 	user := ensureUser(t, ts.db, ts.ctx)
 	n := insertNote(t, ts.db, ts.ctx, user.ID, "n1", "note 1")
 
-	err := ts.noteRepo.DeleteByID(ts.ctx, n.UserID, n.ID)
+	err := ts.noteRepo.DeleteByID(ts.ctx, n.UserID, n.ID, 0)
 	require.NoError(t, err)
 
 	got, err := ts.noteRepo.GetByID(ts.ctx, n.UserID, n.ID)
@@ -252,3 +382,346 @@ func Test_Repo_DeleteTags(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, 0, cnt)
 }
+
+func Test_Repo_Search(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	user := ensureUser(t, ts.db, ts.ctx)
+	n1 := insertNote(t, ts.db, ts.ctx, user.ID, "golang tips", "how to write tests")
+	n2 := insertNote(t, ts.db, ts.ctx, user.ID, "shopping", "buy milk and golang book")
+	insertNote(t, ts.db, ts.ctx, user.ID, "travel", "pack the bags")
+
+	hits, err := ts.noteRepo.Search(ts.ctx, user.ID, "gola", 20, 0)
+	require.NoError(t, err)
+	require.Len(t, hits, 2)
+	require.Equal(t, n1.ID, hits[0].Note.ID, "title match must rank higher")
+	require.Equal(t, n2.ID, hits[1].Note.ID)
+	require.Contains(t, hits[1].Snippet, "<mark>golang</mark>")
+
+	hits, err = ts.noteRepo.Search(ts.ctx, user.ID, "golang milk", 20, 0)
+	require.NoError(t, err)
+	require.Len(t, hits, 1)
+	require.Equal(t, n2.ID, hits[0].Note.ID)
+
+	hits, err = ts.noteRepo.Search(ts.ctx, 9999999, "golang", 20, 0)
+	require.NoError(t, err)
+	require.Empty(t, hits, "there are no notes with this user_id")
+
+	hits, err = ts.noteRepo.Search(ts.ctx, user.ID, "&|!", 20, 0)
+	require.NoError(t, err)
+	require.Empty(t, hits)
+}
+
+func Test_Repo_Search_EscapesSnippet(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	user := ensureUser(t, ts.db, ts.ctx)
+	insertNote(t, ts.db, ts.ctx, user.ID, "xss", "<img src=x onerror=alert(1)> if a < b then golang & \x01more")
+
+	hits, err := ts.noteRepo.Search(ts.ctx, user.ID, "golang", 20, 0)
+	require.NoError(t, err)
+	require.Len(t, hits, 1)
+	require.NotContains(t, hits[0].Snippet, "<img")
+	require.NotContains(t, hits[0].Snippet, "\x01")
+	require.Contains(t, hits[0].Snippet, "a &lt; b")
+	require.Contains(t, hits[0].Snippet, "<mark>golang</mark> &amp;")
+}
+
+func Test_Repo_UpdateByID_Version(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	user := ensureUser(t, ts.db, ts.ctx)
+	n := insertNote(t, ts.db, ts.ctx, user.ID, "n1", "note 1")
+	require.Equal(t, int64(1), n.Version)
+
+	title := "v2"
+	upd, err := ts.noteRepo.UpdateByID(ts.ctx, user.ID, n.ID, &model.NoteUpdate{Title: &title, IfVersion: 1})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), upd.Version)
+
+	title = "stale"
+	_, err = ts.noteRepo.UpdateByID(ts.ctx, user.ID, n.ID, &model.NoteUpdate{Title: &title, IfVersion: 1})
+	require.ErrorIs(t, err, model.ErrPreconditionFailed)
+
+	got, err := ts.noteRepo.GetByID(ts.ctx, user.ID, n.ID)
+	require.NoError(t, err)
+	require.Equal(t, "v2", got.Title, "stale update must not be applied")
+	require.Equal(t, int64(2), got.Version)
+}
+
+func Test_Repo_DeleteByID_Version(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	user := ensureUser(t, ts.db, ts.ctx)
+	n := insertNote(t, ts.db, ts.ctx, user.ID, "n1", "note 1")
+
+	err := ts.noteRepo.DeleteByID(ts.ctx, user.ID, n.ID, 5)
+	require.ErrorIs(t, err, model.ErrPreconditionFailed)
+
+	err = ts.noteRepo.DeleteByID(ts.ctx, user.ID, 9999999, 1)
+	require.ErrorIs(t, err, model.ErrNotFound)
+
+	require.NoError(t, ts.noteRepo.DeleteByID(ts.ctx, user.ID, n.ID, 1))
+}
+
+func Test_Repo_List_Flags(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	user := ensureUser(t, ts.db, ts.ctx)
+	plain := insertNote(t, ts.db, ts.ctx, user.ID, "plain", "")
+	pinned := insertNote(t, ts.db, ts.ctx, user.ID, "pinned", "")
+	archived := insertNote(t, ts.db, ts.ctx, user.ID, "archived", "")
+
+	yes, no := true, false
+	_, err := ts.noteRepo.UpdateByID(ts.ctx, user.ID, pinned.ID, &model.NoteUpdate{Pinned: &yes, Favorite: &yes})
+	require.NoError(t, err)
+	upd, err := ts.noteRepo.UpdateByID(ts.ctx, user.ID, archived.ID, &model.NoteUpdate{Archived: &yes})
+	require.NoError(t, err)
+	require.True(t, upd.Archived)
+
+	revs, err := ts.noteRepo.ListRevisions(ts.ctx, user.ID, archived.ID)
+	require.NoError(t, err)
+	require.Empty(t, revs, "flag changes must not create revisions")
+
+	page, err := ts.noteRepo.List(ts.ctx, user.ID, &model.NoteFilter{
+		Limit:    10,
+		Archived: &no,
+		Sort:     model.Sort{{Name: "pinned", Desc: true}, {Name: "created_at"}},
+	})
+	require.NoError(t, err)
+	require.Len(t, page.Items, 2)
+	require.Equal(t, pinned.ID, page.Items[0].ID, "pinned note goes first")
+	require.Equal(t, plain.ID, page.Items[1].ID)
+
+	page, err = ts.noteRepo.List(ts.ctx, user.ID, &model.NoteFilter{Limit: 10, Favorite: &yes, Sort: model.Sort{{Name: "id"}}})
+	require.NoError(t, err)
+	require.Len(t, page.Items, 1)
+	require.Equal(t, pinned.ID, page.Items[0].ID)
+
+	page, err = ts.noteRepo.List(ts.ctx, user.ID, &model.NoteFilter{Limit: 10, Archived: &yes, Sort: model.Sort{{Name: "id"}}})
+	require.NoError(t, err)
+	require.Len(t, page.Items, 1)
+	require.Equal(t, archived.ID, page.Items[0].ID)
+}
+
+func Test_Repo_List_Notebook(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	user := ensureUser(t, ts.db, ts.ctx)
+	parent := &model.Notebook{Name: "parent", UserID: user.ID}
+	_, err := ts.db.NewInsert().Model(parent).Returning("id").Exec(ts.ctx)
+	require.NoError(t, err)
+	child := &model.Notebook{Name: "child", ParentID: parent.ID, UserID: user.ID}
+	_, err = ts.db.NewInsert().Model(child).Returning("id").Exec(ts.ctx)
+	require.NoError(t, err)
+
+	inParent := insertNote(t, ts.db, ts.ctx, user.ID, "in parent", "")
+	inChild := insertNote(t, ts.db, ts.ctx, user.ID, "in child", "")
+	insertNote(t, ts.db, ts.ctx, user.ID, "loose", "")
+	_, err = ts.noteRepo.UpdateByID(ts.ctx, user.ID, inParent.ID, &model.NoteUpdate{NotebookID: &parent.ID})
+	require.NoError(t, err)
+	_, err = ts.noteRepo.UpdateByID(ts.ctx, user.ID, inChild.ID, &model.NoteUpdate{NotebookID: &child.ID})
+	require.NoError(t, err)
+
+	sort := model.Sort{{Name: "id"}}
+	page, err := ts.noteRepo.List(ts.ctx, user.ID, &model.NoteFilter{Limit: 10, Sort: sort, NotebookID: parent.ID})
+	require.NoError(t, err)
+	require.Len(t, page.Items, 1)
+	require.Equal(t, inParent.ID, page.Items[0].ID)
+
+	page, err = ts.noteRepo.List(ts.ctx, user.ID, &model.NoteFilter{Limit: 10, Sort: sort, NotebookID: parent.ID, Recursive: true})
+	require.NoError(t, err)
+	require.Len(t, page.Items, 2)
+	require.Equal(t, inParent.ID, page.Items[0].ID)
+	require.Equal(t, inChild.ID, page.Items[1].ID)
+
+	root := int64(0)
+	moved, err := ts.noteRepo.UpdateByID(ts.ctx, user.ID, inChild.ID, &model.NoteUpdate{NotebookID: &root})
+	require.NoError(t, err)
+	require.Zero(t, moved.NotebookID)
+}
+
+func Test_Repo_List_TagDescendants(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	user := ensureUser(t, ts.db, ts.ctx)
+	work := &model.Tag{Name: "work", UserID: user.ID}
+	require.NoError(t, ts.tagRepo.Create(ts.ctx, work))
+	acme := &model.Tag{Name: "acme", ParentID: work.ID, UserID: user.ID}
+	require.NoError(t, ts.tagRepo.Create(ts.ctx, acme))
+	home := &model.Tag{Name: "home", UserID: user.ID}
+	require.NoError(t, ts.tagRepo.Create(ts.ctx, home))
+
+	nWork, err := ts.noteRepo.Create(ts.ctx, &model.Note{Title: "work", UserID: user.ID}, []*model.Tag{work})
+	require.NoError(t, err)
+	nAcme, err := ts.noteRepo.Create(ts.ctx, &model.Note{Title: "acme", UserID: user.ID}, []*model.Tag{acme, home})
+	require.NoError(t, err)
+
+	sort := model.Sort{{Name: "id"}}
+	page, err := ts.noteRepo.List(ts.ctx, user.ID, &model.NoteFilter{Limit: 10, Sort: sort, TagIDs: []int64{work.ID}})
+	require.NoError(t, err)
+	require.Len(t, page.Items, 1)
+	require.Equal(t, nWork.ID, page.Items[0].ID)
+
+	page, err = ts.noteRepo.List(ts.ctx, user.ID, &model.NoteFilter{Limit: 10, Sort: sort, TagIDs: []int64{work.ID}, TagDescendants: true})
+	require.NoError(t, err)
+	require.Len(t, page.Items, 2)
+
+	page, err = ts.noteRepo.List(ts.ctx, user.ID, &model.NoteFilter{
+		Limit: 10, Sort: sort, TagIDs: []int64{work.ID, home.ID}, TagMode: model.TagMatchAll, TagDescendants: true,
+	})
+	require.NoError(t, err)
+	require.Len(t, page.Items, 1)
+	require.Equal(t, nAcme.ID, page.Items[0].ID)
+}
+
+func Test_Repo_UpdateTags(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	user := ensureUser(t, ts.db, ts.ctx)
+	keep := &model.Tag{Name: "keep", UserID: user.ID}
+	drop := &model.Tag{Name: "drop", UserID: user.ID}
+	added := &model.Tag{Name: "added", UserID: user.ID}
+	_, err := ts.tagRepo.CreateTags(ts.ctx, []*model.Tag{keep, drop, added})
+	require.NoError(t, err)
+	n1, err := ts.noteRepo.Create(ts.ctx, &model.Note{Title: "n1", UserID: user.ID}, []*model.Tag{keep, drop})
+	require.NoError(t, err)
+	n2, err := ts.noteRepo.Create(ts.ctx, &model.Note{Title: "n2", UserID: user.ID}, []*model.Tag{added})
+	require.NoError(t, err)
+
+	notes, err := ts.noteRepo.UpdateTags(ts.ctx, user.ID, []int64{n1.ID, n2.ID}, []int64{added.ID}, []int64{drop.ID})
+	require.NoError(t, err)
+	require.Len(t, notes, 2)
+	tagNames := func(n model.Note) []string {
+		out := []string{}
+		for _, tag := range n.Tags {
+			out = append(out, tag.Name)
+		}
+		return out
+	}
+	require.ElementsMatch(t, []string{"keep", "added"}, tagNames(notes[0]))
+	require.ElementsMatch(t, []string{"added"}, tagNames(notes[1]))
+	require.Equal(t, int64(2), notes[0].Version)
+
+	revs, err := ts.noteRepo.ListRevisions(ts.ctx, user.ID, n1.ID)
+	require.NoError(t, err)
+	require.Len(t, revs, 1)
+
+	_, err = ts.noteRepo.UpdateTags(ts.ctx, user.ID, []int64{n1.ID, 9999999}, []int64{drop.ID}, nil)
+	require.ErrorIs(t, err, model.ErrNotFound)
+	got, err := ts.noteRepo.GetByID(ts.ctx, user.ID, n1.ID)
+	require.NoError(t, err)
+	require.Len(t, got.Tags, 2, "nothing changes if a note is missing")
+}
+
+func Test_Repo_TagNames(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	user := ensureUser(t, ts.db, ts.ctx)
+	existing := &model.Tag{Name: "work", UserID: user.ID}
+	global := &model.Tag{Name: "idea"}
+	require.NoError(t, ts.tagRepo.Create(ts.ctx, existing))
+	require.NoError(t, ts.tagRepo.Create(ts.ctx, global))
+
+	n, err := ts.noteRepo.Create(ts.ctx, &model.Note{Title: "n", UserID: user.ID},
+		[]*model.Tag{{Name: "work"}, {Name: "idea"}, {Name: "new"}, {Name: "new"}})
+	require.NoError(t, err)
+	byName := map[string]*model.Tag{}
+	for _, tag := range n.Tags {
+		byName[tag.Name] = tag
+	}
+	require.Len(t, byName, 3)
+	require.Equal(t, existing.ID, byName["work"].ID, "existing tag is reused")
+	require.Equal(t, global.ID, byName["idea"].ID, "global tag is reused")
+	require.Equal(t, user.ID, byName["new"].UserID, "missing tag is created for the user")
+
+	names := []string{"later"}
+	upd, err := ts.noteRepo.UpdateByID(ts.ctx, user.ID, n.ID, &model.NoteUpdate{TagIDs: &[]int64{existing.ID}, TagNames: &names})
+	require.NoError(t, err)
+	require.Len(t, upd.Tags, 2)
+
+	names = []string{"work/missing"}
+	_, err = ts.noteRepo.UpdateByID(ts.ctx, user.ID, n.ID, &model.NoteUpdate{TagNames: &names})
+	var vErr *model.ValidationError
+	require.ErrorAs(t, err, &vErr, "nested paths are not created")
+
+	names = []string{"go"}
+	_, err = ts.noteRepo.UpdateByID(ts.ctx, user.ID, n.ID, &model.NoteUpdate{TagNames: &names})
+	require.ErrorAs(t, err, &vErr, "names of new tags are checked like in the tag API")
+}
+
+func Test_Repo_AutoTags(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	user := ensureUser(t, ts.db, ts.ctx)
+	manual := &model.Tag{Name: "work", UserID: user.ID}
+	require.NoError(t, ts.tagRepo.Create(ts.ctx, manual))
+
+	sources := func(n *model.Note) map[string]string {
+		out := map[string]string{}
+		for _, tag := range n.Tags {
+			out[tag.Name] = tag.Source
+		}
+		return out
+	}
+
+	n, err := ts.noteRepo.Create(ts.ctx, &model.Note{Title: "n", Text: "#idea and #work, not `#code` or #go", AutoTags: true, UserID: user.ID},
+		[]*model.Tag{manual})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"work": model.TagSourceManual, "idea": model.TagSourceAuto}, sources(n),
+		"hashtags too short for a tag name are skipped")
+
+	text := "now about #sql"
+	upd, err := ts.noteRepo.UpdateByID(ts.ctx, user.ID, n.ID, &model.NoteUpdate{Text: &text})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"work": model.TagSourceManual, "sql": model.TagSourceAuto}, sources(upd),
+		"auto tags follow the text, manual ones stay")
+
+	shared := "an editor writes #spam"
+	upd, err = ts.noteRepo.UpdateByID(ts.ctx, user.ID, n.ID, &model.NoteUpdate{Text: &shared, KeepAutoTags: true})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"work": model.TagSourceManual, "sql": model.TagSourceAuto}, sources(upd),
+		"edits that keep auto tags don't parse the text")
+	upd, err = ts.noteRepo.UpdateByID(ts.ctx, user.ID, n.ID, &model.NoteUpdate{Text: &text})
+	require.NoError(t, err)
+
+	upd, err = ts.noteRepo.UpdateByID(ts.ctx, user.ID, n.ID, &model.NoteUpdate{TagIDs: &[]int64{}})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"sql": model.TagSourceAuto}, sources(upd), "replacing tags keeps auto ones")
+
+	off := false
+	upd, err = ts.noteRepo.UpdateByID(ts.ctx, user.ID, n.ID, &model.NoteUpdate{AutoTags: &off})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"sql": model.TagSourceManual}, sources(upd), "turned off: extracted tags become manual")
+
+	text = "#other"
+	upd, err = ts.noteRepo.UpdateByID(ts.ctx, user.ID, n.ID, &model.NoteUpdate{Text: &text})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"sql": model.TagSourceManual}, sources(upd), "text is not parsed while off")
+}
+
+func Test_Repo_TagNames_Case(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	user := ensureUser(t, ts.db, ts.ctx)
+	mixed := &model.Tag{Name: "Work", UserID: user.ID}
+	require.NoError(t, ts.tagRepo.Create(ts.ctx, mixed))
+
+	n, err := ts.noteRepo.Create(ts.ctx, &model.Note{Title: "n", Text: "#Work and #work", AutoTags: true, UserID: user.ID}, nil)
+	require.NoError(t, err)
+	require.Len(t, n.Tags, 1)
+	require.Equal(t, mixed.ID, n.Tags[0].ID, "a hashtag reuses the tag differing in case")
+
+	count, err := ts.db.NewSelect().Model((*model.Tag)(nil)).Where("user_id = ?", user.ID).Count(ts.ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, count, "no duplicate tag is created")
+
+	lower := &model.Tag{Name: "work", UserID: user.ID}
+	require.NoError(t, ts.tagRepo.Create(ts.ctx, lower))
+	manual, err := ts.noteRepo.Create(ts.ctx, &model.Note{Title: "manual", UserID: user.ID}, nil)
+	require.NoError(t, err)
+	upd, err := ts.noteRepo.UpdateByID(ts.ctx, user.ID, manual.ID, &model.NoteUpdate{TagNames: &[]string{"work"}})
+	require.NoError(t, err)
+	require.Len(t, upd.Tags, 1)
+	require.Equal(t, lower.ID, upd.Tags[0].ID, "an exact match wins")
+}
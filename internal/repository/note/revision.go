@@ -0,0 +1,98 @@
+package note
+
+import (
+	"context"
+
+	"github.com/Rasulikus/notebook/internal/model"
+	"github.com/Rasulikus/notebook/internal/repository"
+	"github.com/uptrace/bun"
+)
+
+// lockNote selects the user's note FOR UPDATE so concurrent updates are serialized.
+func lockNote(ctx context.Context, tx bun.Tx, userID, noteID int64) (*model.Note, error) {
+	note := new(model.Note)
+	err := tx.NewSelect().
+		Model(note).
+		Where("id = ? AND ?", noteID, repository.Owned(ctx, "", userID)).
+		For("UPDATE").
+		Scan(ctx)
+	if err != nil {
+		return nil, repository.IsNoRowsError(err)
+	}
+	return note, nil
+}
+
+// snapshotRevision stores the current title, text and tags of the locked note
+// as its next revision. Must run in the same transaction as the update it precedes.
+func snapshotRevision(ctx context.Context, tx bun.Tx, note *model.Note) error {
+	noteID := note.ID
+	tagIDs := []int64{}
+	err := tx.NewSelect().
+		Model((*model.NoteTag)(nil)).
+		Column("tag_id").
+		Where("note_id = ?", noteID).
+		Order("tag_id").
+		Scan(ctx, &tagIDs)
+	if err != nil {
+		return err
+	}
+
+	var last int
+	err = tx.NewSelect().
+		Model((*model.NoteRevision)(nil)).
+		ColumnExpr("coalesce(max(rev), 0)").
+		Where("note_id = ?", noteID).
+		Scan(ctx, &last)
+	if err != nil {
+		return err
+	}
+
+	rev := &model.NoteRevision{
+		NoteID: noteID,
+		Rev:    last + 1,
+		Title:  note.Title,
+		Text:   note.Text,
+		TagIDs: tagIDs,
+	}
+	_, err = tx.NewInsert().Model(rev).Exec(ctx)
+	return err
+}
+
+// ListRevisions returns revisions of the user's note, newest first.
+func (r *repo) ListRevisions(ctx context.Context, userID, noteID int64) ([]model.NoteRevision, error) {
+	exists, err := r.db.NewSelect().
+		Model((*model.Note)(nil)).
+		Where("id = ? AND ?", noteID, repository.Owned(ctx, "", userID)).
+		Exists(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, model.ErrNotFound
+	}
+
+	revs := []model.NoteRevision{}
+	err = r.db.NewSelect().
+		Model(&revs).
+		Where("note_id = ?", noteID).
+		Order("rev DESC").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return revs, nil
+}
+
+// GetRevision returns a single revision of the user's note by its number.
+func (r *repo) GetRevision(ctx context.Context, userID, noteID int64, rev int) (*model.NoteRevision, error) {
+	revision := new(model.NoteRevision)
+	err := r.db.NewSelect().
+		Model(revision).
+		Where("note_revision.note_id = ? AND note_revision.rev = ?", noteID, rev).
+		Where("EXISTS (SELECT 1 FROM notes AS n WHERE n.id = note_revision.note_id AND n.deleted_at IS NULL AND ?)", repository.Owned(ctx, "n", userID)).
+		Scan(ctx)
+	if err != nil {
+		return nil, repository.IsNoRowsError(err)
+	}
+	return revision, nil
+}
@@ -0,0 +1,85 @@
+package note
+
+import (
+	"testing"
+
+	"github.com/Rasulikus/notebook/internal/model"
+	testdb "github.com/Rasulikus/notebook/internal/repository/test_db"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Repo_UpdateByID_SnapshotsRevision(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	user := ensureUser(t, ts.db, ts.ctx)
+	tags := []*model.Tag{{Name: "bbb", UserID: user.ID}, {Name: "aaa", UserID: user.ID}}
+	_, err := ts.tagRepo.CreateTags(ts.ctx, tags)
+	require.NoError(t, err)
+	n, err := ts.noteRepo.Create(ts.ctx, &model.Note{Title: "v1", Text: "first", UserID: user.ID}, tags)
+	require.NoError(t, err)
+
+	title2, text2 := "v2", "second"
+	_, err = ts.noteRepo.UpdateByID(ts.ctx, user.ID, n.ID, &model.NoteUpdate{Title: &title2, Text: &text2, TagIDs: &[]int64{}})
+	require.NoError(t, err)
+	title3 := "v3"
+	_, err = ts.noteRepo.UpdateByID(ts.ctx, user.ID, n.ID, &model.NoteUpdate{Title: &title3})
+	require.NoError(t, err)
+
+	revs, err := ts.noteRepo.ListRevisions(ts.ctx, user.ID, n.ID)
+	require.NoError(t, err)
+	require.Len(t, revs, 2)
+	require.Equal(t, 2, revs[0].Rev)
+	require.Equal(t, "v2", revs[0].Title)
+	require.Empty(t, revs[0].TagIDs)
+	require.Equal(t, 1, revs[1].Rev)
+	require.Equal(t, "v1", revs[1].Title)
+	require.Equal(t, "first", revs[1].Text)
+	require.ElementsMatch(t, []int64{tags[0].ID, tags[1].ID}, revs[1].TagIDs)
+
+	_, err = ts.noteRepo.UpdateByID(ts.ctx, 9999999, n.ID, &model.NoteUpdate{Title: &title3})
+	require.ErrorIs(t, err, model.ErrNotFound, "there is no note with this user_id")
+	revs, err = ts.noteRepo.ListRevisions(ts.ctx, user.ID, n.ID)
+	require.NoError(t, err)
+	require.Len(t, revs, 2, "failed update must not leave a revision")
+}
+
+func Test_Repo_ListRevisions(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	user := ensureUser(t, ts.db, ts.ctx)
+	n := insertNote(t, ts.db, ts.ctx, user.ID, "n1", "note 1")
+
+	revs, err := ts.noteRepo.ListRevisions(ts.ctx, user.ID, n.ID)
+	require.NoError(t, err)
+	require.Empty(t, revs)
+
+	_, err = ts.noteRepo.ListRevisions(ts.ctx, 9999999, n.ID)
+	require.ErrorIs(t, err, model.ErrNotFound, "there is no note with this user_id")
+}
+
+func Test_Repo_GetRevision(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	user := ensureUser(t, ts.db, ts.ctx)
+	n := insertNote(t, ts.db, ts.ctx, user.ID, "n1", "note 1")
+	text := "changed"
+	_, err := ts.noteRepo.UpdateByID(ts.ctx, user.ID, n.ID, &model.NoteUpdate{Text: &text})
+	require.NoError(t, err)
+
+	rev, err := ts.noteRepo.GetRevision(ts.ctx, user.ID, n.ID, 1)
+	require.NoError(t, err)
+	require.Equal(t, "note 1", rev.Text)
+
+	got, err := ts.noteRepo.GetRevision(ts.ctx, user.ID, n.ID, 2)
+	require.ErrorIs(t, err, model.ErrNotFound, "there is no such revision")
+	require.Nil(t, got)
+
+	got, err = ts.noteRepo.GetRevision(ts.ctx, 9999999, n.ID, 1)
+	require.ErrorIs(t, err, model.ErrNotFound, "there is no note with this user_id")
+	require.Nil(t, got)
+
+	require.NoError(t, ts.noteRepo.DeleteByID(ts.ctx, user.ID, n.ID, 0))
+	got, err = ts.noteRepo.GetRevision(ts.ctx, user.ID, n.ID, 1)
+	require.ErrorIs(t, err, model.ErrNotFound, "revisions of a trashed note are hidden")
+	require.Nil(t, got)
+}
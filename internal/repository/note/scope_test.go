@@ -0,0 +1,56 @@
+package note
+
+import (
+	"testing"
+
+	"github.com/Rasulikus/notebook/internal/model"
+	testdb "github.com/Rasulikus/notebook/internal/repository/test_db"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Repo_WorkspaceScope(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	owner := ensureUser(t, ts.db, ts.ctx)
+	viewer := &model.User{Email: "viewer@mail.ru", PasswordHash: "x", Name: "viewer"}
+	_, err := ts.db.NewInsert().Model(viewer).Exec(ts.ctx)
+	require.NoError(t, err)
+
+	ws := &model.Workspace{Name: "team"}
+	_, err = ts.db.NewInsert().Model(ws).Exec(ts.ctx)
+	require.NoError(t, err)
+	ownerCtx := model.WithWorkspace(ts.ctx, &model.WorkspaceMembership{WorkspaceID: ws.ID, UserID: owner.ID, Role: model.WorkspaceOwner})
+	viewerCtx := model.WithWorkspace(ts.ctx, &model.WorkspaceMembership{WorkspaceID: ws.ID, UserID: viewer.ID, Role: model.WorkspaceViewer})
+
+	personal, err := ts.noteRepo.Create(ts.ctx, &model.Note{Title: "personal", UserID: owner.ID}, []*model.Tag{{Name: "plan"}})
+	require.NoError(t, err)
+	shared, err := ts.noteRepo.Create(ownerCtx, &model.Note{Title: "shared", UserID: owner.ID, WorkspaceID: ws.ID}, []*model.Tag{{Name: "plan"}})
+	require.NoError(t, err)
+	require.Len(t, shared.Tags, 1)
+	require.Equal(t, ws.ID, shared.Tags[0].WorkspaceID, "tags created by name belong to the workspace")
+	require.NotEqual(t, personal.Tags[0].ID, shared.Tags[0].ID, "the same path is a different tag in each scope")
+
+	list, err := ts.noteRepo.List(ts.ctx, owner.ID, &model.NoteFilter{Limit: 20})
+	require.NoError(t, err)
+	require.Len(t, list.Items, 1)
+	require.Equal(t, personal.ID, list.Items[0].ID)
+
+	list, err = ts.noteRepo.List(viewerCtx, viewer.ID, &model.NoteFilter{Limit: 20})
+	require.NoError(t, err)
+	require.Len(t, list.Items, 1, "every member sees the notes of the workspace")
+	require.Equal(t, shared.ID, list.Items[0].ID)
+
+	_, err = ts.noteRepo.GetByID(ts.ctx, owner.ID, shared.ID)
+	require.ErrorIs(t, err, model.ErrNotFound, "notes of a workspace are not personal")
+	_, err = ts.noteRepo.GetByID(ownerCtx, owner.ID, personal.ID)
+	require.ErrorIs(t, err, model.ErrNotFound, "personal notes are not in the workspace")
+
+	_, role, err := ts.noteRepo.Access(viewerCtx, viewer.ID, shared.ID)
+	require.NoError(t, err)
+	require.Equal(t, model.RoleViewer, role)
+	_, role, err = ts.noteRepo.Access(ownerCtx, owner.ID, shared.ID)
+	require.NoError(t, err)
+	require.Equal(t, model.RoleOwner, role)
+	_, _, err = ts.noteRepo.Access(ts.ctx, viewer.ID, shared.ID)
+	require.ErrorIs(t, err, model.ErrNotFound)
+}
@@ -0,0 +1,122 @@
+package note
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Rasulikus/notebook/internal/model"
+	"github.com/Rasulikus/notebook/internal/repository"
+	tagRepository "github.com/Rasulikus/notebook/internal/repository/tag"
+	"github.com/uptrace/bun"
+)
+
+// resolveTagNames returns ids of tags with the given paths, creating missing root tags for the user,
+// in the workspace selected in ctx if any, in the same transaction. The own tag wins over a global
+// tag with the same path, and a tag differing only in case is reused rather than duplicated;
+// nested paths ("work/acme") must already exist, names of new tags must pass model.CheckTagName
+// like tags created through the tag API.
+func resolveTagNames(ctx context.Context, tx bun.Tx, userID int64, names []string) ([]int64, error) {
+	names = uniqueNames(names)
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	byPath, err := visibleTagsByPath(ctx, tx, userID, names)
+	if err != nil {
+		return nil, err
+	}
+	var missing []*model.Tag
+	for _, name := range names {
+		if _, ok := byPath[name]; ok {
+			continue
+		}
+		if strings.Contains(name, model.TagPathSep) {
+			return nil, &model.ValidationError{Fields: map[string]string{"tag_names": fmt.Sprintf("tag %q not found", name)}}
+		}
+		if msg := model.CheckTagName(name); msg != "" {
+			return nil, &model.ValidationError{Fields: map[string]string{"tag_names": msg}}
+		}
+		missing = append(missing, &model.Tag{Name: name, UserID: userID, WorkspaceID: model.WorkspaceID(ctx)})
+	}
+	if len(missing) > 0 {
+		// a concurrent request may create the same tag: it is skipped here and picked up below
+		if _, err = tagRepository.CreateTags(ctx, tx, missing); err != nil {
+			return nil, err
+		}
+		byPath, err = visibleTagsByPath(ctx, tx, userID, names)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ids := make([]int64, 0, len(names))
+	for _, name := range names {
+		tag, ok := byPath[name]
+		if !ok {
+			return nil, fmt.Errorf("tag %q was not created", name)
+		}
+		ids = append(ids, tag.ID)
+	}
+	return ids, nil
+}
+
+// visibleTagsByPath loads own tags of the scope of ctx and global tags with the given paths,
+// compared ignoring case, and picks the best match for every path (see tagMatch).
+func visibleTagsByPath(ctx context.Context, tx bun.Tx, userID int64, paths []string) (map[string]*model.Tag, error) {
+	lowered := make([]string, len(paths))
+	for i, path := range paths {
+		lowered[i] = strings.ToLower(path)
+	}
+	var tags []model.Tag
+	err := tx.NewSelect().
+		Model(&tags).
+		Where("lower(path) IN (?) AND (? OR user_id IS NULL)", bun.In(lowered), repository.Owned(ctx, "", userID)).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byPath := make(map[string]*model.Tag, len(paths))
+	for _, path := range paths {
+		best := 0
+		for i := range tags {
+			if rank := tagMatch(&tags[i], path); rank > best {
+				byPath[path], best = &tags[i], rank
+			}
+		}
+	}
+	return byPath, nil
+}
+
+// tagMatch ranks how well the tag matches path: an exact path beats one differing in case,
+// and among those an own tag beats a global one. 0 - no match.
+func tagMatch(tag *model.Tag, path string) int {
+	rank := 0
+	switch {
+	case tag.Path == path:
+		rank = 3
+	case strings.ToLower(tag.Path) == strings.ToLower(path):
+		rank = 1
+	default:
+		return 0
+	}
+	if tag.UserID != 0 {
+		rank++
+	}
+	return rank
+}
+
+// uniqueNames trims names and drops empty ones and duplicates, keeping the original order.
+func uniqueNames(names []string) []string {
+	seen := make(map[string]struct{}, len(names))
+	out := make([]string, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if _, ok := seen[name]; ok || name == "" {
+			continue
+		}
+		seen[name] = struct{}{}
+		out = append(out, name)
+	}
+	return out
+}
@@ -0,0 +1,29 @@
+package note
+
+import (
+	"context"
+
+	"github.com/Rasulikus/notebook/internal/model"
+	"github.com/Rasulikus/notebook/internal/repository"
+)
+
+// ListWithTasks returns up to limit user's notes (not archived, recently updated first) whose text
+// may hold checklist items, continuing after the note after (nil - from the start) by its
+// UpdatedAt and ID. Only ID, Title, Text, Version and UpdatedAt are filled; the text still has to be parsed.
+func (r *repo) ListWithTasks(ctx context.Context, userID int64, after *model.Note, limit int) ([]model.Note, error) {
+	notes := []model.Note{}
+	q := r.db.NewSelect().
+		Model(&notes).
+		Column("id", "title", "text", "version", "updated_at").
+		Where("? AND NOT note.archived", repository.Owned(ctx, "note", userID)).
+		Where(`note.text ~ '\[[ xX]\]'`).
+		Order("note.updated_at DESC", "note.id DESC").
+		Limit(limit)
+	if after != nil {
+		q.Where("(note.updated_at, note.id) < (?, ?)", after.UpdatedAt, after.ID)
+	}
+	if err := q.Scan(ctx); err != nil {
+		return nil, err
+	}
+	return notes, nil
+}
@@ -0,0 +1,42 @@
+package note
+
+import (
+	"testing"
+
+	"github.com/Rasulikus/notebook/internal/model"
+	testdb "github.com/Rasulikus/notebook/internal/repository/test_db"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Repo_ListWithTasks(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	user := ensureUser(t, ts.db, ts.ctx)
+	withTasks := insertNote(t, ts.db, ts.ctx, user.ID, "todo", "- [ ] one\n- [x] two")
+	insertNote(t, ts.db, ts.ctx, user.ID, "plain", "no tasks here")
+	archived := insertNote(t, ts.db, ts.ctx, user.ID, "old", "- [ ] forgotten")
+	yes := true
+	_, err := ts.noteRepo.UpdateByID(ts.ctx, user.ID, archived.ID, &model.NoteUpdate{Archived: &yes})
+	require.NoError(t, err)
+
+	notes, err := ts.noteRepo.ListWithTasks(ts.ctx, user.ID, nil, 10)
+	require.NoError(t, err)
+	require.Len(t, notes, 1)
+	require.Equal(t, withTasks.ID, notes[0].ID)
+	require.Equal(t, withTasks.Text, notes[0].Text)
+
+	newer := insertNote(t, ts.db, ts.ctx, user.ID, "newer", "* [X] shipped")
+	notes, err = ts.noteRepo.ListWithTasks(ts.ctx, user.ID, nil, 1)
+	require.NoError(t, err)
+	require.Len(t, notes, 1)
+	require.Equal(t, newer.ID, notes[0].ID, "recently updated first")
+
+	notes, err = ts.noteRepo.ListWithTasks(ts.ctx, user.ID, &notes[0], 1)
+	require.NoError(t, err)
+	require.Len(t, notes, 1)
+	require.Equal(t, withTasks.ID, notes[0].ID, "the next page continues after the given note")
+
+	notes, err = ts.noteRepo.ListWithTasks(ts.ctx, user.ID, &notes[0], 1)
+	require.NoError(t, err)
+	require.Empty(t, notes)
+}
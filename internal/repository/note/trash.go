@@ -0,0 +1,85 @@
+package note
+
+import (
+	"context"
+	"time"
+
+	"github.com/Rasulikus/notebook/internal/model"
+	"github.com/Rasulikus/notebook/internal/repository"
+)
+
+// ListTrash returns user's notes in trash, most recently deleted first.
+func (r *repo) ListTrash(ctx context.Context, userID int64, limit, offset int) ([]model.Note, error) {
+	notes := []model.Note{}
+	err := r.db.NewSelect().
+		Model(&notes).
+		WhereDeleted().
+		Where("?", repository.Owned(ctx, "", userID)).
+		Relation("Tags", withTagSource).
+		Order("deleted_at DESC", "id DESC").
+		Limit(limit).
+		Offset(offset).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return notes, nil
+}
+
+// Restore moves the note from trash back to the list of notes.
+func (r *repo) Restore(ctx context.Context, userID, id int64) (*model.Note, error) {
+	res, err := r.db.NewUpdate().
+		Model((*model.Note)(nil)).
+		WhereDeleted().
+		Set("deleted_at = NULL").
+		Where("id = ? AND ?", id, repository.Owned(ctx, "", userID)).
+		Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	aff, err := res.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if aff == 0 {
+		return nil, model.ErrNotFound
+	}
+	return r.GetByID(ctx, userID, id)
+}
+
+// DeletePermanently removes a note that is already in trash, together with
+// its tag links and revisions.
+func (r *repo) DeletePermanently(ctx context.Context, userID, id int64) error {
+	res, err := r.db.NewDelete().
+		Model((*model.Note)(nil)).
+		WhereDeleted().
+		ForceDelete().
+		Where("id = ? AND ?", id, repository.Owned(ctx, "", userID)).
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+	aff, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if aff == 0 {
+		return model.ErrNotFound
+	}
+	return nil
+}
+
+// PurgeTrash permanently removes notes of all users moved to trash before
+// deletedBefore. Returns the number of removed notes.
+func (r *repo) PurgeTrash(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	res, err := r.db.NewDelete().
+		Model((*model.Note)(nil)).
+		WhereDeleted().
+		ForceDelete().
+		Where("deleted_at < ?", deletedBefore).
+		Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
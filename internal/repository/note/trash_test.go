@@ -0,0 +1,95 @@
+package note
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Rasulikus/notebook/internal/model"
+	testdb "github.com/Rasulikus/notebook/internal/repository/test_db"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Repo_DeleteByID_MovesToTrash(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	user := ensureUser(t, ts.db, ts.ctx)
+	n := insertNote(t, ts.db, ts.ctx, user.ID, "n1", "note 1")
+
+	require.NoError(t, ts.noteRepo.DeleteByID(ts.ctx, user.ID, n.ID, 0))
+
+	list, err := ts.noteRepo.List(ts.ctx, user.ID, &model.NoteFilter{Limit: 20})
+	require.NoError(t, err)
+	require.Empty(t, list.Items, "trashed note must not be listed")
+
+	trash, err := ts.noteRepo.ListTrash(ts.ctx, user.ID, 20, 0)
+	require.NoError(t, err)
+	require.Len(t, trash, 1)
+	require.Equal(t, n.ID, trash[0].ID)
+	require.False(t, trash[0].DeletedAt.IsZero())
+
+	err = ts.noteRepo.DeleteByID(ts.ctx, user.ID, n.ID, 0)
+	require.ErrorIs(t, err, model.ErrNotFound, "note is already in trash")
+}
+
+func Test_Repo_Restore(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	user := ensureUser(t, ts.db, ts.ctx)
+	n := insertNote(t, ts.db, ts.ctx, user.ID, "n1", "note 1")
+
+	_, err := ts.noteRepo.Restore(ts.ctx, user.ID, n.ID)
+	require.ErrorIs(t, err, model.ErrNotFound, "note is not in trash")
+
+	require.NoError(t, ts.noteRepo.DeleteByID(ts.ctx, user.ID, n.ID, 0))
+	_, err = ts.noteRepo.Restore(ts.ctx, 9999999, n.ID)
+	require.ErrorIs(t, err, model.ErrNotFound, "there is no note with this user_id")
+
+	restored, err := ts.noteRepo.Restore(ts.ctx, user.ID, n.ID)
+	require.NoError(t, err)
+	require.Equal(t, n.ID, restored.ID)
+	require.True(t, restored.DeletedAt.IsZero())
+}
+
+func Test_Repo_DeletePermanently(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	user := ensureUser(t, ts.db, ts.ctx)
+	n := insertNote(t, ts.db, ts.ctx, user.ID, "n1", "note 1")
+
+	err := ts.noteRepo.DeletePermanently(ts.ctx, user.ID, n.ID)
+	require.ErrorIs(t, err, model.ErrNotFound, "only notes in trash can be deleted permanently")
+
+	require.NoError(t, ts.noteRepo.DeleteByID(ts.ctx, user.ID, n.ID, 0))
+	require.NoError(t, ts.noteRepo.DeletePermanently(ts.ctx, user.ID, n.ID))
+
+	trash, err := ts.noteRepo.ListTrash(ts.ctx, user.ID, 20, 0)
+	require.NoError(t, err)
+	require.Empty(t, trash)
+}
+
+func Test_Repo_PurgeTrash(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	user := ensureUser(t, ts.db, ts.ctx)
+	expired := insertNote(t, ts.db, ts.ctx, user.ID, "expired", "")
+	fresh := insertNote(t, ts.db, ts.ctx, user.ID, "fresh", "")
+	insertNote(t, ts.db, ts.ctx, user.ID, "alive", "")
+	require.NoError(t, ts.noteRepo.DeleteByID(ts.ctx, user.ID, expired.ID, 0))
+	require.NoError(t, ts.noteRepo.DeleteByID(ts.ctx, user.ID, fresh.ID, 0))
+	_, err := ts.db.NewUpdate().
+		Model((*model.Note)(nil)).
+		WhereDeleted().
+		Set("deleted_at = ?", time.Now().Add(-48*time.Hour)).
+		Where("id = ?", expired.ID).
+		Exec(ts.ctx)
+	require.NoError(t, err)
+
+	purged, err := ts.noteRepo.PurgeTrash(ts.ctx, time.Now().Add(-24*time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, int64(1), purged)
+
+	trash, err := ts.noteRepo.ListTrash(ts.ctx, user.ID, 20, 0)
+	require.NoError(t, err)
+	require.Len(t, trash, 1)
+	require.Equal(t, fresh.ID, trash[0].ID)
+}
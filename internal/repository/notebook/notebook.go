@@ -0,0 +1,147 @@
+package notebook
+
+import (
+	"context"
+
+	"github.com/Rasulikus/notebook/internal/model"
+	"github.com/Rasulikus/notebook/internal/repository"
+	"github.com/uptrace/bun"
+)
+
+type Repo struct {
+	db *bun.DB
+}
+
+func NewRepository(db *bun.DB) *Repo {
+	return &Repo{db: db}
+}
+
+func (r *Repo) Create(ctx context.Context, nb *model.Notebook) error {
+	_, err := r.db.NewInsert().Model(nb).Returning("*").Exec(ctx)
+	return repository.IsUniqueViolation(err)
+}
+
+// List returns user's notebooks ordered by name. A nil parentID returns the whole tree,
+// 0 returns root notebooks, any other value returns direct children of that notebook.
+func (r *Repo) List(ctx context.Context, userID int64, parentID *int64) ([]model.Notebook, error) {
+	notebooks := []model.Notebook{}
+	q := r.db.NewSelect().
+		Model(&notebooks).
+		Where("user_id = ?", userID).
+		Order("name", "id")
+	if parentID != nil {
+		if *parentID == 0 {
+			q.Where("parent_id IS NULL")
+		} else {
+			q.Where("parent_id = ?", *parentID)
+		}
+	}
+	if err := q.Scan(ctx); err != nil {
+		return nil, err
+	}
+	return notebooks, nil
+}
+
+func (r *Repo) GetByID(ctx context.Context, userID, id int64) (*model.Notebook, error) {
+	nb := new(model.Notebook)
+	err := r.db.NewSelect().Model(nb).Where("id = ? AND user_id = ?", id, userID).Scan(ctx)
+	if err != nil {
+		return nil, repository.IsNoRowsError(err)
+	}
+	return nb, nil
+}
+
+// UpdateByID renames the notebook and/or moves it under upd.ParentID (0 - to the root);
+// nil fields are left unchanged. Moving a notebook into itself or its own descendant
+// is rejected with a ValidationError. Moves of the user's notebooks run under a lock on
+// the user, so two concurrent moves can't pass the check together and make a cycle.
+func (r *Repo) UpdateByID(ctx context.Context, userID, id int64, upd *model.NotebookUpdate) (*model.Notebook, error) {
+	nb := new(model.Notebook)
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if upd.ParentID != nil && *upd.ParentID != 0 {
+			_, err := tx.NewSelect().
+				Model((*model.User)(nil)).
+				Column("id").
+				Where("id = ?", userID).
+				For("UPDATE").
+				Exec(ctx)
+			if err != nil {
+				return err
+			}
+			cycle, err := tx.NewSelect().
+				TableExpr("("+repository.SubtreeSQL("notebooks")+") AS subtree", id).
+				Where("subtree.id = ?", *upd.ParentID).
+				Exists(ctx)
+			if err != nil {
+				return err
+			}
+			if cycle {
+				return &model.ValidationError{Fields: map[string]string{
+					"parent_id": "notebook can't be moved into itself or its sub-notebook",
+				}}
+			}
+		}
+
+		q := tx.NewUpdate().
+			Model(nb).
+			Set("updated_at = now()").
+			Where("id = ? AND user_id = ?", id, userID).
+			Returning("*")
+		if upd.Name != nil {
+			q.Set("name = ?", *upd.Name)
+		}
+		if upd.ParentID != nil {
+			q.Set("parent_id = ?", bun.NullZero(*upd.ParentID))
+		}
+		res, err := q.Exec(ctx)
+		if err != nil {
+			return repository.IsUniqueViolation(err)
+		}
+		aff, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if aff == 0 {
+			return model.ErrNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return nb, nil
+}
+
+// DeleteByID deletes the notebook with all its sub-notebooks. Their notes are either
+// left without a notebook or moved to trash, depending on the policy.
+func (r *Repo) DeleteByID(ctx context.Context, userID, id int64, policy model.NotebookDeletePolicy) error {
+	return r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if policy == model.NotebookDeleteTrash {
+			_, err := tx.NewDelete().
+				Model((*model.Note)(nil)).
+				Where("user_id = ?", userID).
+				Where("notebook_id IN ("+repository.SubtreeSQL("notebooks")+")", id).
+				Exec(ctx)
+			if err != nil {
+				return err
+			}
+		}
+
+		// sub-notebooks go by ON DELETE CASCADE, notes get notebook_id = NULL by ON DELETE SET NULL
+		res, err := tx.NewDelete().
+			Model((*model.Notebook)(nil)).
+			Where("id = ? AND user_id = ?", id, userID).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		aff, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if aff == 0 {
+			return model.ErrNotFound
+		}
+		return nil
+	})
+}
@@ -0,0 +1,218 @@
+package notebook
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/Rasulikus/notebook/internal/model"
+	testdb "github.com/Rasulikus/notebook/internal/repository/test_db"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+)
+
+func TestMain(m *testing.M) {
+	testdb.RecreateTables()
+	code := m.Run()
+	testdb.CloseDB()
+	os.Exit(code)
+}
+
+type testSuite struct {
+	db           *bun.DB
+	notebookRepo *Repo
+	ctx          context.Context
+}
+
+func setupTestSuite(t *testing.T) *testSuite {
+	t.Helper()
+	var suite testSuite
+	suite.db = testdb.DB()
+	suite.notebookRepo = NewRepository(suite.db)
+	suite.ctx = context.Background()
+	return &suite
+}
+
+func ensureUser(t *testing.T, db *bun.DB, ctx context.Context) *model.User {
+	t.Helper()
+	u := &model.User{Email: "test@mail.ru", PasswordHash: "x", Name: "test"}
+	err := db.NewInsert().Model(u).Scan(ctx, u)
+	require.NoError(t, err)
+	require.NotZero(t, u.ID)
+	return u
+}
+
+func insertNotebook(t *testing.T, ts *testSuite, userID, parentID int64, name string) *model.Notebook {
+	t.Helper()
+	nb := &model.Notebook{Name: name, ParentID: parentID, UserID: userID}
+	require.NoError(t, ts.notebookRepo.Create(ts.ctx, nb))
+	require.NotZero(t, nb.ID)
+	return nb
+}
+
+func insertNote(t *testing.T, ts *testSuite, userID, notebookID int64, title string) *model.Note {
+	t.Helper()
+	n := &model.Note{Title: title, NotebookID: notebookID, UserID: userID}
+	_, err := ts.db.NewInsert().Model(n).Returning("id").Exec(ts.ctx)
+	require.NoError(t, err)
+	return n
+}
+
+func Test_Repo_Create(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	user := ensureUser(t, ts.db, ts.ctx)
+
+	root := insertNotebook(t, ts, user.ID, 0, "work")
+	child := insertNotebook(t, ts, user.ID, root.ID, "projects")
+	require.Equal(t, root.ID, child.ParentID)
+
+	err := ts.notebookRepo.Create(ts.ctx, &model.Notebook{Name: "work", UserID: user.ID})
+	require.ErrorIs(t, err, model.ErrConflict, "root names are unique")
+
+	insertNotebook(t, ts, user.ID, child.ID, "work")
+}
+
+func Test_Repo_List(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	user := ensureUser(t, ts.db, ts.ctx)
+	b := insertNotebook(t, ts, user.ID, 0, "b")
+	a := insertNotebook(t, ts, user.ID, 0, "a")
+	child := insertNotebook(t, ts, user.ID, b.ID, "child")
+
+	all, err := ts.notebookRepo.List(ts.ctx, user.ID, nil)
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+
+	root := int64(0)
+	roots, err := ts.notebookRepo.List(ts.ctx, user.ID, &root)
+	require.NoError(t, err)
+	require.Len(t, roots, 2)
+	require.Equal(t, a.ID, roots[0].ID)
+	require.Equal(t, b.ID, roots[1].ID)
+
+	children, err := ts.notebookRepo.List(ts.ctx, user.ID, &b.ID)
+	require.NoError(t, err)
+	require.Len(t, children, 1)
+	require.Equal(t, child.ID, children[0].ID)
+
+	other, err := ts.notebookRepo.List(ts.ctx, 9999999, nil)
+	require.NoError(t, err)
+	require.Empty(t, other)
+}
+
+func Test_Repo_GetByID(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	user := ensureUser(t, ts.db, ts.ctx)
+	nb := insertNotebook(t, ts, user.ID, 0, "work")
+
+	got, err := ts.notebookRepo.GetByID(ts.ctx, user.ID, nb.ID)
+	require.NoError(t, err)
+	require.Equal(t, "work", got.Name)
+
+	_, err = ts.notebookRepo.GetByID(ts.ctx, 9999999, nb.ID)
+	require.ErrorIs(t, err, model.ErrNotFound)
+}
+
+func Test_Repo_UpdateByID(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	user := ensureUser(t, ts.db, ts.ctx)
+	root := insertNotebook(t, ts, user.ID, 0, "root")
+	child := insertNotebook(t, ts, user.ID, root.ID, "child")
+	grandchild := insertNotebook(t, ts, user.ID, child.ID, "grandchild")
+
+	name := "renamed"
+	upd, err := ts.notebookRepo.UpdateByID(ts.ctx, user.ID, grandchild.ID, &model.NotebookUpdate{Name: &name})
+	require.NoError(t, err)
+	require.Equal(t, "renamed", upd.Name)
+	require.Equal(t, child.ID, upd.ParentID, "a rename keeps the parent")
+
+	toRoot := int64(0)
+	upd, err = ts.notebookRepo.UpdateByID(ts.ctx, user.ID, grandchild.ID, &model.NotebookUpdate{ParentID: &toRoot})
+	require.NoError(t, err)
+	require.Equal(t, "renamed", upd.Name, "a move keeps the name")
+	require.Zero(t, upd.ParentID, "explicit 0 moves to the root")
+
+	var vErr *model.ValidationError
+	_, err = ts.notebookRepo.UpdateByID(ts.ctx, user.ID, root.ID, &model.NotebookUpdate{ParentID: &child.ID})
+	require.ErrorAs(t, err, &vErr, "can't move a notebook into its descendant")
+
+	_, err = ts.notebookRepo.UpdateByID(ts.ctx, user.ID, root.ID, &model.NotebookUpdate{ParentID: &root.ID})
+	require.ErrorAs(t, err, &vErr, "can't move a notebook into itself")
+
+	_, err = ts.notebookRepo.UpdateByID(ts.ctx, 9999999, root.ID, &model.NotebookUpdate{Name: &name})
+	require.ErrorIs(t, err, model.ErrNotFound)
+}
+
+func Test_Repo_UpdateByID_ConcurrentMoves(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	user := ensureUser(t, ts.db, ts.ctx)
+
+	for i := range 20 {
+		a := insertNotebook(t, ts, user.ID, 0, fmt.Sprintf("a%d", i))
+		b := insertNotebook(t, ts, user.ID, 0, fmt.Sprintf("b%d", i))
+
+		var wg sync.WaitGroup
+		errs := make([]error, 2)
+		moves := [][2]int64{{a.ID, b.ID}, {b.ID, a.ID}}
+		for i, move := range moves {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, errs[i] = ts.notebookRepo.UpdateByID(ts.ctx, user.ID, move[0], &model.NotebookUpdate{ParentID: &move[1]})
+			}()
+		}
+		wg.Wait()
+
+		var vErr *model.ValidationError
+		failed := 0
+		for _, err := range errs {
+			if err != nil {
+				require.ErrorAs(t, err, &vErr)
+				failed++
+			}
+		}
+		require.Equal(t, 1, failed, "only one of the opposite moves may pass")
+	}
+}
+
+func Test_Repo_DeleteByID(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	user := ensureUser(t, ts.db, ts.ctx)
+
+	t.Run("move to root", func(t *testing.T) {
+		root := insertNotebook(t, ts, user.ID, 0, "keep")
+		child := insertNotebook(t, ts, user.ID, root.ID, "child")
+		n := insertNote(t, ts, user.ID, child.ID, "note")
+
+		require.NoError(t, ts.notebookRepo.DeleteByID(ts.ctx, user.ID, root.ID, model.NotebookDeleteMoveToRoot))
+
+		_, err := ts.notebookRepo.GetByID(ts.ctx, user.ID, child.ID)
+		require.ErrorIs(t, err, model.ErrNotFound, "sub-notebooks are deleted too")
+		got := new(model.Note)
+		require.NoError(t, ts.db.NewSelect().Model(got).Where("id = ?", n.ID).Scan(ts.ctx))
+		require.Zero(t, got.NotebookID)
+	})
+
+	t.Run("trash", func(t *testing.T) {
+		root := insertNotebook(t, ts, user.ID, 0, "drop")
+		child := insertNotebook(t, ts, user.ID, root.ID, "child")
+		n := insertNote(t, ts, user.ID, child.ID, "note")
+
+		require.NoError(t, ts.notebookRepo.DeleteByID(ts.ctx, user.ID, root.ID, model.NotebookDeleteTrash))
+
+		got := new(model.Note)
+		require.NoError(t, ts.db.NewSelect().Model(got).WhereDeleted().Where("id = ?", n.ID).Scan(ts.ctx))
+		require.False(t, got.DeletedAt.IsZero(), "note is in trash")
+	})
+
+	err := ts.notebookRepo.DeleteByID(ts.ctx, user.ID, 9999999, model.NotebookDeleteMoveToRoot)
+	require.ErrorIs(t, err, model.ErrNotFound)
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/Rasulikus/notebook/internal/config"
 	"github.com/Rasulikus/notebook/internal/model"
@@ -56,3 +57,25 @@ func IsUniqueViolation(err error) error {
 	}
 	return err
 }
+
+// UniqueIDs returns ids without duplicates, keeping the original order.
+func UniqueIDs(ids []int64) []int64 {
+	seen := make(map[int64]struct{}, len(ids))
+	out := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		out = append(out, id)
+	}
+	return out
+}
+
+// EscapeLike escapes LIKE wildcards in user input, so it is matched literally
+// (backslash is the default LIKE escape character in Postgres).
+func EscapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
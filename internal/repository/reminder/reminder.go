@@ -0,0 +1,122 @@
+package reminder
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/Rasulikus/notebook/internal/model"
+	"github.com/uptrace/bun"
+)
+
+type Repo struct {
+	db *bun.DB
+}
+
+func NewRepository(db *bun.DB) *Repo {
+	return &Repo{db: db}
+}
+
+func (r *Repo) Create(ctx context.Context, rem *model.Reminder) error {
+	_, err := r.db.NewInsert().Model(rem).Returning("*").Exec(ctx)
+	return err
+}
+
+// List returns a page of user's reminders: upcoming ones soonest first,
+// otherwise all of them, the latest first.
+func (r *Repo) List(ctx context.Context, userID int64, filter *model.ReminderFilter) ([]model.Reminder, error) {
+	reminders := []model.Reminder{}
+	q := r.db.NewSelect().
+		Model(&reminders).
+		Where("reminder.user_id = ?", userID).
+		Limit(filter.Limit).
+		Offset(filter.Offset)
+	if filter.Upcoming {
+		q.Where("NOT reminder.done").Order("reminder.remind_at", "reminder.id")
+	} else {
+		q.Order("reminder.remind_at DESC", "reminder.id DESC")
+	}
+	if err := q.Scan(ctx); err != nil {
+		return nil, err
+	}
+	return reminders, nil
+}
+
+// ListByNote returns reminders of the user's note, soonest first.
+func (r *Repo) ListByNote(ctx context.Context, userID, noteID int64) ([]model.Reminder, error) {
+	reminders := []model.Reminder{}
+	err := r.db.NewSelect().
+		Model(&reminders).
+		Where("reminder.user_id = ? AND reminder.note_id = ?", userID, noteID).
+		Order("reminder.remind_at", "reminder.id").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return reminders, nil
+}
+
+func (r *Repo) DeleteByID(ctx context.Context, userID, id int64) error {
+	res, err := r.db.NewDelete().
+		Model((*model.Reminder)(nil)).
+		Where("id = ? AND user_id = ?", id, userID).
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+	aff, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if aff == 0 {
+		return model.ErrNotFound
+	}
+	return nil
+}
+
+// ClaimDue locks up to limit reminders that are due at now and whose notes are not in trash,
+// moves each one on with advance and saves RemindAt, FiredCount, LastFiredAt and Done as advance
+// left them. Returns the claimed reminders as they were when due, after the transaction is
+// committed, so the caller notifies about them without holding the locks. Locked rows are skipped,
+// so several schedulers can share the table.
+func (r *Repo) ClaimDue(ctx context.Context, now time.Time, limit int, advance func(rem *model.Reminder)) ([]model.Reminder, error) {
+	var reminders, due []model.Reminder
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		err := tx.NewSelect().
+			Model(&reminders).
+			ColumnExpr("reminder.*").
+			ColumnExpr("note.title AS note_title").
+			Join("JOIN notes AS note ON note.id = reminder.note_id AND note.deleted_at IS NULL").
+			Where("NOT reminder.done AND reminder.remind_at <= ?", now).
+			Order("reminder.remind_at", "reminder.id").
+			Limit(limit).
+			For("UPDATE OF reminder SKIP LOCKED").
+			Scan(ctx)
+		if err != nil {
+			return err
+		}
+
+		due = slices.Clone(reminders)
+		for i := range reminders {
+			rem := &reminders[i]
+			advance(rem)
+			_, err := tx.NewUpdate().
+				Model((*model.Reminder)(nil)).
+				Set("remind_at = ?", rem.RemindAt).
+				Set("fired_count = ?", rem.FiredCount).
+				Set("last_fired_at = ?", bun.NullZero(rem.LastFiredAt)).
+				Set("done = ?", rem.Done).
+				Set("updated_at = now()").
+				Where("id = ?", rem.ID).
+				Exec(ctx)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return due, nil
+}
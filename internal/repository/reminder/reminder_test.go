@@ -0,0 +1,161 @@
+package reminder
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Rasulikus/notebook/internal/model"
+	testdb "github.com/Rasulikus/notebook/internal/repository/test_db"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+)
+
+func TestMain(m *testing.M) {
+	testdb.RecreateTables()
+	code := m.Run()
+	testdb.CloseDB()
+	os.Exit(code)
+}
+
+type testSuite struct {
+	db           *bun.DB
+	reminderRepo *Repo
+	ctx          context.Context
+}
+
+func setupTestSuite(t *testing.T) *testSuite {
+	t.Helper()
+	var suite testSuite
+	suite.db = testdb.DB()
+	suite.reminderRepo = NewRepository(suite.db)
+	suite.ctx = context.Background()
+	return &suite
+}
+
+func ensureUser(t *testing.T, db *bun.DB, ctx context.Context) *model.User {
+	t.Helper()
+	u := &model.User{Email: "test@mail.ru", PasswordHash: "x", Name: "test"}
+	err := db.NewInsert().Model(u).Scan(ctx, u)
+	require.NoError(t, err)
+	require.NotZero(t, u.ID)
+	return u
+}
+
+func insertNote(t *testing.T, ts *testSuite, userID int64, title string) *model.Note {
+	t.Helper()
+	n := &model.Note{Title: title, UserID: userID}
+	_, err := ts.db.NewInsert().Model(n).Returning("id").Exec(ts.ctx)
+	require.NoError(t, err)
+	return n
+}
+
+func insertReminder(t *testing.T, ts *testSuite, userID, noteID int64, at time.Time) *model.Reminder {
+	t.Helper()
+	rem := &model.Reminder{NoteID: noteID, UserID: userID, RemindAt: at, StartsAt: at, Timezone: "UTC"}
+	require.NoError(t, ts.reminderRepo.Create(ts.ctx, rem))
+	require.NotZero(t, rem.ID)
+	return rem
+}
+
+func Test_Repo_List(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	user := ensureUser(t, ts.db, ts.ctx)
+	note := insertNote(t, ts, user.ID, "note")
+
+	now := time.Now().UTC().Truncate(time.Second)
+	later := insertReminder(t, ts, user.ID, note.ID, now.Add(2*time.Hour))
+	sooner := insertReminder(t, ts, user.ID, note.ID, now.Add(time.Hour))
+	done := insertReminder(t, ts, user.ID, note.ID, now.Add(3*time.Hour))
+	_, err := ts.db.NewUpdate().Model((*model.Reminder)(nil)).Set("done = true").Where("id = ?", done.ID).Exec(ts.ctx)
+	require.NoError(t, err)
+
+	upcoming, err := ts.reminderRepo.List(ts.ctx, user.ID, &model.ReminderFilter{Upcoming: true, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, upcoming, 2)
+	require.Equal(t, sooner.ID, upcoming[0].ID)
+	require.Equal(t, later.ID, upcoming[1].ID)
+
+	all, err := ts.reminderRepo.List(ts.ctx, user.ID, &model.ReminderFilter{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+	require.Equal(t, done.ID, all[0].ID)
+
+	byNote, err := ts.reminderRepo.ListByNote(ts.ctx, user.ID, note.ID)
+	require.NoError(t, err)
+	require.Len(t, byNote, 3)
+
+	other, err := ts.reminderRepo.ListByNote(ts.ctx, user.ID+1, note.ID)
+	require.NoError(t, err)
+	require.Empty(t, other)
+}
+
+func Test_Repo_DeleteByID(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	user := ensureUser(t, ts.db, ts.ctx)
+	note := insertNote(t, ts, user.ID, "note")
+	rem := insertReminder(t, ts, user.ID, note.ID, time.Now().Add(time.Hour))
+
+	err := ts.reminderRepo.DeleteByID(ts.ctx, user.ID+1, rem.ID)
+	require.ErrorIs(t, err, model.ErrNotFound)
+
+	require.NoError(t, ts.reminderRepo.DeleteByID(ts.ctx, user.ID, rem.ID))
+	err = ts.reminderRepo.DeleteByID(ts.ctx, user.ID, rem.ID)
+	require.ErrorIs(t, err, model.ErrNotFound)
+}
+
+func Test_Repo_ProcessDue(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	user := ensureUser(t, ts.db, ts.ctx)
+	note := insertNote(t, ts, user.ID, "note")
+	trashed := insertNote(t, ts, user.ID, "trashed")
+	_, err := ts.db.NewUpdate().Model((*model.Note)(nil)).Set("deleted_at = now()").Where("id = ?", trashed.ID).Exec(ts.ctx)
+	require.NoError(t, err)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	oneOff := insertReminder(t, ts, user.ID, note.ID, now.Add(-time.Minute))
+	recurring := insertReminder(t, ts, user.ID, note.ID, now.Add(-2*time.Minute))
+	insertReminder(t, ts, user.ID, note.ID, now.Add(time.Hour))
+	insertReminder(t, ts, user.ID, trashed.ID, now.Add(-time.Minute))
+
+	var fired []int64
+	due, err := ts.reminderRepo.ClaimDue(ts.ctx, now, 10, func(rem *model.Reminder) {
+		fired = append(fired, rem.ID)
+		rem.FiredCount++
+		rem.LastFiredAt = now
+		if rem.ID == recurring.ID {
+			rem.RemindAt = rem.RemindAt.Add(24 * time.Hour)
+		} else {
+			rem.Done = true
+		}
+	})
+	require.NoError(t, err)
+	require.Len(t, due, 2)
+	require.Equal(t, []int64{recurring.ID, oneOff.ID}, fired, "due reminders go oldest first, trashed notes are skipped")
+	require.Equal(t, recurring.ID, due[0].ID)
+	require.Equal(t, "note", due[0].NoteTitle)
+	require.True(t, due[0].RemindAt.Equal(now.Add(-2*time.Minute)), "claimed reminders are returned as they were due")
+	require.Zero(t, due[0].FiredCount)
+
+	byNote, err := ts.reminderRepo.ListByNote(ts.ctx, user.ID, note.ID)
+	require.NoError(t, err)
+	got := make(map[int64]model.Reminder, len(byNote))
+	for _, rem := range byNote {
+		got[rem.ID] = rem
+	}
+	require.True(t, got[oneOff.ID].Done)
+	require.Equal(t, 1, got[oneOff.ID].FiredCount)
+	require.False(t, got[recurring.ID].Done)
+	require.True(t, got[recurring.ID].RemindAt.Equal(now.Add(-2*time.Minute).Add(24*time.Hour)))
+	require.True(t, got[recurring.ID].LastFiredAt.Equal(now))
+
+	due, err = ts.reminderRepo.ClaimDue(ts.ctx, now, 10, func(rem *model.Reminder) {
+		t.Fatalf("reminder %d fired twice", rem.ID)
+	})
+	require.NoError(t, err)
+	require.Empty(t, due)
+}
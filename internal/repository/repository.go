@@ -9,16 +9,31 @@ import (
 
 type NoteRepository interface {
 	Create(ctx context.Context, note *model.Note, tags []*model.Tag) (*model.Note, error)
-	List(ctx context.Context, userID int64, limit, offset int, order string) ([]model.Note, error)
+	List(ctx context.Context, userID int64, filter *model.NoteFilter) (*model.Page[model.Note], error)
+	Search(ctx context.Context, userID int64, query string, limit, offset int) ([]model.NoteSearchHit, error)
 	GetByID(ctx context.Context, userID, id int64) (*model.Note, error)
-	UpdateByID(ctx context.Context, userID, id int64, title, text *string, tagsIDs *[]int64) (*model.Note, error)
+	UpdateByID(ctx context.Context, userID, id int64, upd *model.NoteUpdate) (*model.Note, error)
 	DeleteTags(ctx context.Context, userID, id int64) error
-	DeleteByID(ctx context.Context, userID, id int64) error
+	UpdateTags(ctx context.Context, userID int64, noteIDs, add, remove []int64) ([]model.Note, error)
+	DeleteByID(ctx context.Context, userID, id, ifVersion int64) error
+	ListTrash(ctx context.Context, userID int64, limit, offset int) ([]model.Note, error)
+	Restore(ctx context.Context, userID, id int64) (*model.Note, error)
+	DeletePermanently(ctx context.Context, userID, id int64) error
+	PurgeTrash(ctx context.Context, deletedBefore time.Time) (int64, error)
+	ListRevisions(ctx context.Context, userID, noteID int64) ([]model.NoteRevision, error)
+	GetRevision(ctx context.Context, userID, noteID int64, rev int) (*model.NoteRevision, error)
+	ListLinks(ctx context.Context, userID, noteID int64) ([]model.NoteLink, error)
+	ListBacklinks(ctx context.Context, userID, noteID int64) ([]model.NoteLink, error)
+	Graph(ctx context.Context, userID int64, filter *model.NoteFilter) (*model.NoteGraph, error)
+	ListWithTasks(ctx context.Context, userID int64, after *model.Note, limit int) ([]model.Note, error)
+	Access(ctx context.Context, userID, noteID int64) (int64, model.Role, error)
+	ListSharedWith(ctx context.Context, userID int64, limit, offset int) ([]model.SharedNote, error)
 }
 
 type UserRepository interface {
 	Create(ctx context.Context, user *model.User) error
 	GetByEmail(ctx context.Context, email string) (*model.User, error)
+	GetByID(ctx context.Context, id int64) (*model.User, error)
 }
 
 type SessionRepository interface {
@@ -30,9 +45,72 @@ type SessionRepository interface {
 type TagRepository interface {
 	Create(ctx context.Context, tag *model.Tag) error
 	CreateTags(ctx context.Context, tags []*model.Tag) ([]*model.Tag, error)
-	List(ctx context.Context, userID int64, limit, offset int, order string) ([]model.Tag, error)
+	List(ctx context.Context, userID int64, filter *model.TagFilter) (*model.Page[model.Tag], error)
 	GetByID(ctx context.Context, userID, id int64) (*model.Tag, error)
 	GetByIDs(ctx context.Context, userID int64, ids []int64) ([]*model.Tag, error)
 	UpdateByID(ctx context.Context, userID int64, tag *model.Tag) (*model.Tag, error)
 	DeleteByID(ctx context.Context, userID, id int64) error
+	Merge(ctx context.Context, userID, sourceID, targetID int64) (*model.Tag, error)
+	Suggest(ctx context.Context, userID int64, prefix string, limit int) ([]model.Tag, error)
+}
+
+type NotebookRepository interface {
+	Create(ctx context.Context, nb *model.Notebook) error
+	List(ctx context.Context, userID int64, parentID *int64) ([]model.Notebook, error)
+	GetByID(ctx context.Context, userID, id int64) (*model.Notebook, error)
+	UpdateByID(ctx context.Context, userID, id int64, upd *model.NotebookUpdate) (*model.Notebook, error)
+	DeleteByID(ctx context.Context, userID, id int64, policy model.NotebookDeletePolicy) error
+}
+
+type ReminderRepository interface {
+	Create(ctx context.Context, rem *model.Reminder) error
+	List(ctx context.Context, userID int64, filter *model.ReminderFilter) ([]model.Reminder, error)
+	ListByNote(ctx context.Context, userID, noteID int64) ([]model.Reminder, error)
+	DeleteByID(ctx context.Context, userID, id int64) error
+	ClaimDue(ctx context.Context, now time.Time, limit int, advance func(rem *model.Reminder)) ([]model.Reminder, error)
+}
+
+type AttachmentRepository interface {
+	Create(ctx context.Context, att *model.Attachment, quota int64) error
+	ListByNote(ctx context.Context, userID, noteID int64) ([]model.Attachment, error)
+	GetByID(ctx context.Context, userID, id int64) (*model.Attachment, error)
+	DeleteByID(ctx context.Context, userID, id int64) (*model.Attachment, error)
+	ListOrphans(ctx context.Context, limit int) ([]model.Attachment, error)
+	DeleteOrphans(ctx context.Context, ids []int64) error
+	ListPendingThumbs(ctx context.Context, afterID int64, limit int) ([]model.Attachment, error)
+	SaveThumbs(ctx context.Context, id int64, thumbs []*model.AttachmentThumb, status string) error
+	FailThumbs(ctx context.Context, id int64, maxAttempts int) (bool, error)
+	GetThumb(ctx context.Context, id int64, size int) (*model.AttachmentThumb, error)
+}
+
+type ShareRepository interface {
+	Create(ctx context.Context, share *model.NoteShare) error
+	List(ctx context.Context, userID, noteID int64) ([]model.NoteShare, error)
+	GetActiveByTokenHash(ctx context.Context, tokenHash []byte, now time.Time) (*model.NoteShare, error)
+	MarkViewed(ctx context.Context, id int64) error
+	ReservePasswordAttempt(ctx context.Context, id int64, maxAttempts int, now time.Time) (bool, error)
+	FailPassword(ctx context.Context, id int64, maxAttempts int, lockedUntil time.Time) error
+	Revoke(ctx context.Context, userID, id int64) error
+}
+
+type ACLRepository interface {
+	Grant(ctx context.Context, grant *model.AccessGrant) error
+	List(ctx context.Context, ownerID, noteID, tagID int64) ([]model.AccessGrant, error)
+	Revoke(ctx context.Context, ownerID, id int64) error
+}
+
+type WorkspaceRepository interface {
+	Create(ctx context.Context, ws *model.Workspace, ownerID int64) error
+	List(ctx context.Context, userID int64) ([]model.Workspace, error)
+	GetByID(ctx context.Context, userID, id int64) (*model.Workspace, error)
+	Rename(ctx context.Context, id int64, name string) error
+	DeleteByID(ctx context.Context, id int64) error
+	GetMember(ctx context.Context, workspaceID, userID int64) (*model.WorkspaceMembership, error)
+	ListMembers(ctx context.Context, workspaceID int64) ([]model.WorkspaceMembership, error)
+	SetMemberRole(ctx context.Context, workspaceID, actorID, userID int64, role model.WorkspaceRole, allow func(actor, target *model.WorkspaceMembership) error) error
+	RemoveMember(ctx context.Context, workspaceID, actorID, userID int64, allow func(actor, target *model.WorkspaceMembership) error) error
+	CreateInvite(ctx context.Context, invite *model.WorkspaceInvite) error
+	ListInvites(ctx context.Context, workspaceID int64, now time.Time) ([]model.WorkspaceInvite, error)
+	RevokeInvite(ctx context.Context, workspaceID, id int64) error
+	AcceptInvite(ctx context.Context, tokenHash []byte, userID int64, now time.Time, accept func(invite *model.WorkspaceInvite) error) (*model.WorkspaceMembership, error)
 }
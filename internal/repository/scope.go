@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Rasulikus/notebook/internal/model"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/schema"
+)
+
+// Owned narrows a query of notes or tags to the owner scope of ctx: personal rows of the user or,
+// once the request selected a workspace (model.WithWorkspace), rows of that workspace.
+// userID 0 stands for global rows, which belong to nobody. table qualifies the columns, "" for none:
+//
+//	q.Where("note.id = ? AND ?", id, repository.Owned(ctx, "note", userID))
+func Owned(ctx context.Context, table string, userID int64) schema.QueryAppender {
+	prefix := ""
+	if table != "" {
+		prefix = table + "."
+	}
+	if userID == 0 {
+		return bun.SafeQuery("(" + prefix + "user_id IS NULL AND " + prefix + "workspace_id IS NULL)")
+	}
+	if workspaceID := model.WorkspaceID(ctx); workspaceID != 0 {
+		return bun.SafeQuery("("+prefix+"workspace_id = ?)", workspaceID)
+	}
+	return bun.SafeQuery("("+prefix+"user_id = ? AND "+prefix+"workspace_id IS NULL)", userID)
+}
@@ -0,0 +1,124 @@
+package share
+
+import (
+	"context"
+	"time"
+
+	"github.com/Rasulikus/notebook/internal/model"
+	"github.com/Rasulikus/notebook/internal/repository"
+	"github.com/uptrace/bun"
+)
+
+type Repo struct {
+	db *bun.DB
+}
+
+func NewRepository(db *bun.DB) *Repo {
+	return &Repo{db: db}
+}
+
+func (r *Repo) Create(ctx context.Context, share *model.NoteShare) error {
+	_, err := r.db.NewInsert().Model(share).Returning("*").Exec(ctx)
+	return repository.IsUniqueViolation(err)
+}
+
+// List returns user's shares, the latest first; a non-zero noteID limits them to one note.
+// Revoked and expired shares are listed too.
+func (r *Repo) List(ctx context.Context, userID, noteID int64) ([]model.NoteShare, error) {
+	shares := []model.NoteShare{}
+	q := r.db.NewSelect().
+		Model(&shares).
+		ColumnExpr("share.*").
+		ColumnExpr("note.title AS note_title").
+		Join("JOIN notes AS note ON note.id = share.note_id AND note.deleted_at IS NULL").
+		Where("share.user_id = ?", userID).
+		Order("share.created_at DESC", "share.id DESC")
+	if noteID != 0 {
+		q.Where("share.note_id = ?", noteID)
+	}
+	if err := q.Scan(ctx); err != nil {
+		return nil, err
+	}
+	return shares, nil
+}
+
+// GetActiveByTokenHash returns the share with the token if it is neither revoked nor expired
+// at now and its note is not in trash.
+func (r *Repo) GetActiveByTokenHash(ctx context.Context, tokenHash []byte, now time.Time) (*model.NoteShare, error) {
+	share := new(model.NoteShare)
+	err := r.db.NewSelect().
+		Model(share).
+		Join("JOIN notes AS note ON note.id = share.note_id AND note.deleted_at IS NULL").
+		Where("share.token_hash = ?", tokenHash).
+		Where("share.revoked_at IS NULL").
+		Where("(share.expires_at IS NULL OR share.expires_at > ?)", now).
+		Scan(ctx)
+	if err != nil {
+		return nil, repository.IsNoRowsError(err)
+	}
+	return share, nil
+}
+
+// MarkViewed counts a view of the shared note and forgets password attempts made before it.
+func (r *Repo) MarkViewed(ctx context.Context, id int64) error {
+	_, err := r.db.NewUpdate().
+		Model((*model.NoteShare)(nil)).
+		Set("views = views + 1").
+		Set("last_viewed_at = now()").
+		Set("failed_attempts = 0").
+		Where("id = ?", id).
+		Exec(ctx)
+	return err
+}
+
+// ReservePasswordAttempt counts an attempt to open the share with a password before the password
+// is checked, so concurrent guesses can't slip past the limit. It reports false, counting nothing,
+// when the share is locked at now or maxAttempts attempts are already counted.
+func (r *Repo) ReservePasswordAttempt(ctx context.Context, id int64, maxAttempts int, now time.Time) (bool, error) {
+	res, err := r.db.NewUpdate().
+		Model((*model.NoteShare)(nil)).
+		Set("failed_attempts = failed_attempts + 1").
+		Where("id = ? AND failed_attempts < ?", id, maxAttempts).
+		Where("(locked_until IS NULL OR locked_until <= ?)", now).
+		Exec(ctx)
+	if err != nil {
+		return false, err
+	}
+	aff, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return aff > 0, nil
+}
+
+// FailPassword records that a reserved attempt had a wrong password. Once maxAttempts of them are
+// counted the share is locked until lockedUntil and the count starts over.
+func (r *Repo) FailPassword(ctx context.Context, id int64, maxAttempts int, lockedUntil time.Time) error {
+	_, err := r.db.NewUpdate().
+		Model((*model.NoteShare)(nil)).
+		Set("failed_attempts = 0").
+		Set("locked_until = ?", lockedUntil).
+		Where("id = ? AND failed_attempts >= ?", id, maxAttempts).
+		Exec(ctx)
+	return err
+}
+
+// Revoke makes the user's share stop working; revoking it again is ErrNotFound.
+func (r *Repo) Revoke(ctx context.Context, userID, id int64) error {
+	res, err := r.db.NewUpdate().
+		Model((*model.NoteShare)(nil)).
+		Set("revoked_at = now()").
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+	aff, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if aff == 0 {
+		return model.ErrNotFound
+	}
+	return nil
+}
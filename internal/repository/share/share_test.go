@@ -0,0 +1,202 @@
+package share
+
+import (
+	"context"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Rasulikus/notebook/internal/model"
+	testdb "github.com/Rasulikus/notebook/internal/repository/test_db"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+)
+
+func TestMain(m *testing.M) {
+	testdb.RecreateTables()
+	code := m.Run()
+	testdb.CloseDB()
+	os.Exit(code)
+}
+
+type testSuite struct {
+	db        *bun.DB
+	shareRepo *Repo
+	ctx       context.Context
+}
+
+func setupTestSuite(t *testing.T) *testSuite {
+	t.Helper()
+	var suite testSuite
+	suite.db = testdb.DB()
+	suite.shareRepo = NewRepository(suite.db)
+	suite.ctx = context.Background()
+	return &suite
+}
+
+func ensureUser(t *testing.T, db *bun.DB, ctx context.Context) *model.User {
+	t.Helper()
+	u := &model.User{Email: "test@mail.ru", PasswordHash: "x", Name: "test"}
+	err := db.NewInsert().Model(u).Scan(ctx, u)
+	require.NoError(t, err)
+	require.NotZero(t, u.ID)
+	return u
+}
+
+func insertNote(t *testing.T, ts *testSuite, userID int64, title string) *model.Note {
+	t.Helper()
+	n := &model.Note{Title: title, UserID: userID}
+	_, err := ts.db.NewInsert().Model(n).Returning("id").Exec(ts.ctx)
+	require.NoError(t, err)
+	return n
+}
+
+func insertShare(t *testing.T, ts *testSuite, userID, noteID int64, token string, expiresAt time.Time) *model.NoteShare {
+	t.Helper()
+	share := &model.NoteShare{NoteID: noteID, UserID: userID, TokenHash: []byte(token), ExpiresAt: expiresAt}
+	require.NoError(t, ts.shareRepo.Create(ts.ctx, share))
+	require.NotZero(t, share.ID)
+	return share
+}
+
+func Test_Repo_GetActiveByTokenHash(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	user := ensureUser(t, ts.db, ts.ctx)
+	note := insertNote(t, ts, user.ID, "note")
+	trashed := insertNote(t, ts, user.ID, "trashed")
+	_, err := ts.db.NewUpdate().Model((*model.Note)(nil)).Set("deleted_at = now()").Where("id = ?", trashed.ID).Exec(ts.ctx)
+	require.NoError(t, err)
+
+	now := time.Now()
+	forever := insertShare(t, ts, user.ID, note.ID, "forever", time.Time{})
+	insertShare(t, ts, user.ID, note.ID, "expiring", now.Add(time.Hour))
+	insertShare(t, ts, user.ID, trashed.ID, "trashed", time.Time{})
+
+	err = ts.shareRepo.Create(ts.ctx, &model.NoteShare{NoteID: note.ID, UserID: user.ID, TokenHash: []byte("forever")})
+	require.ErrorIs(t, err, model.ErrConflict)
+
+	got, err := ts.shareRepo.GetActiveByTokenHash(ts.ctx, []byte("forever"), now)
+	require.NoError(t, err)
+	require.Equal(t, forever.ID, got.ID)
+
+	_, err = ts.shareRepo.GetActiveByTokenHash(ts.ctx, []byte("expiring"), now)
+	require.NoError(t, err)
+	_, err = ts.shareRepo.GetActiveByTokenHash(ts.ctx, []byte("expiring"), now.Add(2*time.Hour))
+	require.ErrorIs(t, err, model.ErrNotFound, "expired")
+
+	_, err = ts.shareRepo.GetActiveByTokenHash(ts.ctx, []byte("trashed"), now)
+	require.ErrorIs(t, err, model.ErrNotFound, "note in trash")
+	_, err = ts.shareRepo.GetActiveByTokenHash(ts.ctx, []byte("unknown"), now)
+	require.ErrorIs(t, err, model.ErrNotFound)
+
+	require.NoError(t, ts.shareRepo.Revoke(ts.ctx, user.ID, forever.ID))
+	_, err = ts.shareRepo.GetActiveByTokenHash(ts.ctx, []byte("forever"), now)
+	require.ErrorIs(t, err, model.ErrNotFound, "revoked")
+}
+
+func Test_Repo_List(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	user := ensureUser(t, ts.db, ts.ctx)
+	a := insertNote(t, ts, user.ID, "a")
+	b := insertNote(t, ts, user.ID, "b")
+	first := insertShare(t, ts, user.ID, a.ID, "1", time.Time{})
+	second := insertShare(t, ts, user.ID, b.ID, "2", time.Time{})
+
+	all, err := ts.shareRepo.List(ts.ctx, user.ID, 0)
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+	require.Equal(t, second.ID, all[0].ID)
+	require.Equal(t, "b", all[0].NoteTitle)
+
+	byNote, err := ts.shareRepo.List(ts.ctx, user.ID, a.ID)
+	require.NoError(t, err)
+	require.Len(t, byNote, 1)
+	require.Equal(t, first.ID, byNote[0].ID)
+
+	other, err := ts.shareRepo.List(ts.ctx, user.ID+1, 0)
+	require.NoError(t, err)
+	require.Empty(t, other)
+}
+
+func Test_Repo_RevokeAndViews(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	user := ensureUser(t, ts.db, ts.ctx)
+	note := insertNote(t, ts, user.ID, "note")
+	share := insertShare(t, ts, user.ID, note.ID, "token", time.Time{})
+
+	require.NoError(t, ts.shareRepo.MarkViewed(ts.ctx, share.ID))
+	require.NoError(t, ts.shareRepo.MarkViewed(ts.ctx, share.ID))
+
+	err := ts.shareRepo.Revoke(ts.ctx, user.ID+1, share.ID)
+	require.ErrorIs(t, err, model.ErrNotFound)
+	require.NoError(t, ts.shareRepo.Revoke(ts.ctx, user.ID, share.ID))
+	err = ts.shareRepo.Revoke(ts.ctx, user.ID, share.ID)
+	require.ErrorIs(t, err, model.ErrNotFound)
+
+	list, err := ts.shareRepo.List(ts.ctx, user.ID, note.ID)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	require.EqualValues(t, 2, list[0].Views)
+	require.NotZero(t, list[0].LastViewedAt)
+	require.False(t, list[0].Active(time.Now()))
+}
+
+func Test_Repo_PasswordAttempts(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	user := ensureUser(t, ts.db, ts.ctx)
+	note := insertNote(t, ts, user.ID, "note")
+	share := insertShare(t, ts, user.ID, note.ID, "token", time.Time{})
+	now := time.Now()
+	lockedUntil := now.Add(time.Hour)
+
+	attempts := func() *model.NoteShare {
+		got, err := ts.shareRepo.GetActiveByTokenHash(ts.ctx, []byte("token"), now)
+		require.NoError(t, err)
+		return got
+	}
+
+	ok, err := ts.shareRepo.ReservePasswordAttempt(ts.ctx, share.ID, 3, now)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.NoError(t, ts.shareRepo.FailPassword(ts.ctx, share.ID, 3, lockedUntil))
+	require.Equal(t, 1, attempts().FailedAttempts)
+	require.Zero(t, attempts().LockedUntil, "not locked before the limit")
+
+	require.NoError(t, ts.shareRepo.MarkViewed(ts.ctx, share.ID))
+	require.Zero(t, attempts().FailedAttempts, "a right password resets the count")
+
+	// concurrent wrong passwords: only maxAttempts of them get checked
+	var wg sync.WaitGroup
+	var reserved atomic.Int32
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ok, err := ts.shareRepo.ReservePasswordAttempt(ts.ctx, share.ID, 3, now)
+			if err != nil || !ok {
+				return
+			}
+			reserved.Add(1)
+			_ = ts.shareRepo.FailPassword(ts.ctx, share.ID, 3, lockedUntil)
+		}()
+	}
+	wg.Wait()
+	require.EqualValues(t, 3, reserved.Load())
+
+	got := attempts()
+	require.Zero(t, got.FailedAttempts, "the count starts over once locked")
+	require.WithinDuration(t, lockedUntil, got.LockedUntil, time.Millisecond)
+
+	ok, err = ts.shareRepo.ReservePasswordAttempt(ts.ctx, share.ID, 3, now)
+	require.NoError(t, err)
+	require.False(t, ok, "locked")
+	ok, err = ts.shareRepo.ReservePasswordAttempt(ts.ctx, share.ID, 3, lockedUntil)
+	require.NoError(t, err)
+	require.True(t, ok, "the lock is over")
+}
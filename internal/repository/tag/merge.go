@@ -0,0 +1,120 @@
+package tag
+
+import (
+	"context"
+	"unicode/utf8"
+
+	"github.com/Rasulikus/notebook/internal/model"
+	"github.com/Rasulikus/notebook/internal/repository"
+	"github.com/uptrace/bun"
+)
+
+// Merge moves all notes of the source tag to the target tag and deletes the source.
+// Child tags of the source are moved under the target. The source must belong to the user,
+// the target may also be a global tag unless the source has children: private tags can't
+// end up under a global one. Notes that change get their version bumped.
+func (r *Repo) Merge(ctx context.Context, userID, sourceID, targetID int64) (*model.Tag, error) {
+	if sourceID == targetID {
+		return nil, &model.ValidationError{Fields: map[string]string{"target_id": "can't merge a tag into itself"}}
+	}
+	target := new(model.Tag)
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		var tags []model.Tag
+		err := tx.NewSelect().
+			Model(&tags).
+			Where("id IN (?) AND (? OR user_id IS NULL)", bun.In([]int64{sourceID, targetID}), repository.Owned(ctx, "", userID)).
+			For("UPDATE").
+			Scan(ctx)
+		if err != nil {
+			return err
+		}
+		source := new(model.Tag)
+		for i := range tags {
+			switch tags[i].ID {
+			case sourceID:
+				source = &tags[i]
+			case targetID:
+				target = &tags[i]
+			}
+		}
+		if source.ID == 0 {
+			return model.ErrNotFound
+		}
+		// a global source can only be merged by administrators
+		if source.UserID == 0 && userID != 0 {
+			return model.ErrForbidden
+		}
+		if target.ID == 0 {
+			return &model.ValidationError{Fields: map[string]string{"target_id": "tag not found"}}
+		}
+
+		inSource, err := tx.NewSelect().
+			TableExpr("("+repository.SubtreeSQL("tags")+") AS subtree", sourceID).
+			Where("subtree.id = ?", targetID).
+			Exists(ctx)
+		if err != nil {
+			return err
+		}
+		if inSource {
+			return &model.ValidationError{Fields: map[string]string{"target_id": "can't merge a tag into its descendant"}}
+		}
+		if target.UserID == 0 && source.UserID != 0 {
+			hasChildren, err := tx.NewSelect().Model((*model.Tag)(nil)).Where("parent_id = ?", sourceID).Exists(ctx)
+			if err != nil {
+				return err
+			}
+			if hasChildren {
+				return &model.ValidationError{Fields: map[string]string{
+					"target_id": "a tag with child tags can't be merged into a global tag",
+				}}
+			}
+		}
+
+		// notes that get the target tag now
+		_, err = tx.NewUpdate().
+			Model((*model.Note)(nil)).
+			Set("version = version + 1").
+			Set("updated_at = now()").
+			Where("id IN (SELECT note_id FROM notes_tags WHERE tag_id = ?)", sourceID).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		_, err = tx.NewRaw(`INSERT INTO notes_tags (note_id, tag_id, source)
+			SELECT note_id, ?, source FROM notes_tags WHERE tag_id = ?
+			ON CONFLICT (note_id, tag_id) DO NOTHING`, targetID, sourceID).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.NewUpdate().
+			Model((*model.Tag)(nil)).
+			Set("path = ? || substr(path, ?)", target.Path, utf8.RuneCountInString(source.Path)+1).
+			Where("id IN ("+repository.SubtreeSQL("tags")+")", sourceID).
+			Where("id <> ?", sourceID).
+			Exec(ctx)
+		if err != nil {
+			return repository.IsUniqueViolation(err)
+		}
+		_, err = tx.NewUpdate().
+			Model((*model.Tag)(nil)).
+			Set("parent_id = ?", targetID).
+			Where("parent_id = ?", sourceID).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		// remaining notes_tags rows of the source go by ON DELETE CASCADE
+		_, err = tx.NewDelete().
+			Model((*model.Tag)(nil)).
+			Where("id = ?", sourceID).
+			Exec(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return target, nil
+}
@@ -2,11 +2,14 @@ package tag
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"unicode/utf8"
 
 	"github.com/Rasulikus/notebook/internal/model"
 	"github.com/Rasulikus/notebook/internal/repository"
 	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/schema"
 )
 
 type Repo struct {
@@ -17,61 +20,152 @@ func NewRepository(db *bun.DB) *Repo {
 	return &Repo{db: db}
 }
 
+// Create inserts the tag under tag.ParentID, which must belong to the same owner.
+// The path is built from the parent's path and the tag name.
 func (r *Repo) Create(ctx context.Context, tag *model.Tag) error {
-	_, err := r.db.NewInsert().Model(tag).Returning("*").Exec(ctx)
-	return repository.IsUniqueViolation(err)
+	return r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		parentPath, err := parentTagPath(ctx, tx, tag.UserID, tag.ParentID)
+		if err != nil {
+			return err
+		}
+		tag.Path = model.TagPath(parentPath, tag.Name)
+		_, err = tx.NewInsert().Model(tag).Returning("*").Exec(ctx)
+		return repository.IsUniqueViolation(err)
+	})
+}
+
+// parentTagPath returns the path of the parent tag owned by userID (0 - global tags) in the scope of ctx;
+// "" for parentID = 0. A missing parent is reported as a ValidationError.
+func parentTagPath(ctx context.Context, db bun.IDB, userID, parentID int64) (string, error) {
+	if parentID == 0 {
+		return "", nil
+	}
+	var path string
+	err := db.NewSelect().
+		Model((*model.Tag)(nil)).
+		Column("path").
+		Where("id = ?", parentID).
+		Where("?", repository.Owned(ctx, "", userID)).
+		Scan(ctx, &path)
+	if err != nil {
+		if errors.Is(repository.IsNoRowsError(err), model.ErrNotFound) {
+			return "", &model.ValidationError{Fields: map[string]string{"parent_id": "tag not found"}}
+		}
+		return "", err
+	}
+	return path, nil
 }
 
 func (r *Repo) CreateTags(ctx context.Context, tags []*model.Tag) ([]*model.Tag, error) {
+	return CreateTags(ctx, r.db, tags)
+}
+
+// CreateTags inserts root tags with db, which may be a transaction of another repository.
+// Names are checked with model.CheckTagName (ValidationError); tags whose path is already
+// taken are skipped.
+func CreateTags(ctx context.Context, db bun.IDB, tags []*model.Tag) ([]*model.Tag, error) {
 	if len(tags) == 0 {
 		return tags, nil
-
 	}
 	for _, t := range tags {
 		if t == nil {
 			return nil, fmt.Errorf("nil tag in slice")
 		}
-		if t.Name == "" {
-			return nil, fmt.Errorf("tag name is empty")
+		if msg := model.CheckTagName(t.Name); msg != "" {
+			return nil, &model.ValidationError{Fields: map[string]string{"name": msg}}
 		}
+		if t.ParentID != 0 {
+			return nil, fmt.Errorf("only root tags can be created in bulk")
+		}
+		t.Path = t.Name
 	}
-	_, err := r.db.NewInsert().Model(&tags).On("CONFLICT (user_id, name) DO NOTHING").Returning("*").Exec(ctx)
+	// no conflict target: global tags are unique by a separate partial index
+	_, err := db.NewInsert().Model(&tags).On("CONFLICT DO NOTHING").Returning("*").Exec(ctx)
 	if err != nil {
 		return nil, err
 	}
 	return tags, nil
 }
 
-func (r *Repo) List(ctx context.Context, userID int64, limit, offset int, order string) ([]model.Tag, error) {
+// noteCountJoin counts notes (aliased "n", filtered by ?) the tag is attached to, and when
+// such a note was last updated, ignoring notes in trash. Global tags are shared, so notes
+// outside the scope must not be counted, see countedNotes.
+const noteCountJoin = `LEFT JOIN LATERAL (
+	SELECT count(*) AS note_count, max(n.updated_at) AS last_used_at FROM notes_tags AS nt
+	JOIN notes AS n ON n.id = nt.note_id AND n.deleted_at IS NULL AND ?
+	WHERE nt.tag_id = tag.id) AS tag_usage ON true`
+
+// countedNotes filters noteCountJoin to notes in the owner scope of ctx, all notes for userID 0.
+func countedNotes(ctx context.Context, userID int64) schema.QueryAppender {
+	if userID == 0 {
+		return bun.Safe("true")
+	}
+	return repository.Owned(ctx, "n", userID)
+}
+
+// tagSortColumns maps sort fields from model.TagSortFields to SQL.
+var tagSortColumns = map[string]repository.SortColumn[model.Tag]{
+	"name":       {Expr: "tag.name", Value: func(t *model.Tag) any { return t.Name }},
+	"path":       {Expr: "tag.path", Value: func(t *model.Tag) any { return t.Path }},
+	"note_count": {Expr: "tag_usage.note_count", Value: func(t *model.Tag) any { return t.NoteCount }},
+}
+
+// List returns a page of user's and global tags ordered by filter.Sort
+// with the tag id as a tie-breaker. userID 0 lists only global tags.
+func (r *Repo) List(ctx context.Context, userID int64, filter *model.TagFilter) (*model.Page[model.Tag], error) {
+	keyset, cursorOf, err := repository.NewKeyset(filter.Sort, tagSortColumns, "tag.id", tagID)
+	if err != nil {
+		return nil, err
+	}
+
 	var tags []model.Tag
-	err := r.db.NewSelect().
+	q := r.db.NewSelect().
 		Model(&tags).
-		Where("user_id = ? OR user_id is NULL ", userID).
-		Order(order).
-		Limit(limit).
-		Offset(offset).
-		Scan(ctx)
+		ColumnExpr("tag.*").
+		ColumnExpr("tag_usage.note_count").
+		Join(noteCountJoin, countedNotes(ctx, userID)).
+		Where("(? OR tag.user_id IS NULL)", repository.Owned(ctx, "tag", userID))
+	if filter.ParentID != nil {
+		if *filter.ParentID == 0 {
+			q.Where("tag.parent_id IS NULL")
+		} else {
+			q.Where("tag.parent_id = ?", *filter.ParentID)
+		}
+	}
+	if err := keyset.Apply(q, filter.Cursor); err != nil {
+		return nil, err
+	}
+	if filter.Cursor == "" {
+		q.Offset(filter.Offset)
+	}
+	err = q.Limit(filter.Limit + 1).Scan(ctx)
 	if err != nil {
 		return nil, err
 	}
-	return tags, nil
+	return repository.NewPage(keyset, tags, filter.Limit, cursorOf)
 }
 
+func tagID(t *model.Tag) int64 { return t.ID }
+
+// GetByID returns a tag visible to the user: own or global.
 func (r *Repo) GetByID(ctx context.Context, userID, id int64) (*model.Tag, error) {
 	tag := new(model.Tag)
-	err := r.db.NewSelect().Model(tag).Where("id = ? AND user_id = ?", id, userID).Scan(ctx)
+	err := r.db.NewSelect().Model(tag).Where("id = ? AND (? OR user_id IS NULL)", id, repository.Owned(ctx, "", userID)).Scan(ctx)
 	if err != nil {
 		return nil, repository.IsNoRowsError(err)
 	}
 	return tag, nil
 }
 
+// GetByIDs returns tags visible to the user: own or global; ErrNotFound if any is missing.
+// Duplicate ids are ignored.
 func (r *Repo) GetByIDs(ctx context.Context, userID int64, ids []int64) ([]*model.Tag, error) {
 	var tags []*model.Tag
 	if len(ids) == 0 {
 		return tags, nil
 	}
-	err := r.db.NewSelect().Model(&tags).Where("id IN (?) AND user_id = ?", bun.In(ids), userID).Scan(ctx)
+	ids = repository.UniqueIDs(ids)
+	err := r.db.NewSelect().Model(&tags).Where("id IN (?) AND (? OR user_id IS NULL)", bun.In(ids), repository.Owned(ctx, "", userID)).Scan(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -81,28 +175,97 @@ func (r *Repo) GetByIDs(ctx context.Context, userID int64, ids []int64) ([]*mode
 	return tags, nil
 }
 
-func (r *Repo) UpdateByID(ctx context.Context, userID int64, tag *model.Tag) (*model.Tag, error) {
-	res, err := r.db.NewUpdate().
-		Model(tag).
-		Set("name = ?", tag.Name).
-		Where("id = ? AND user_id = ?", tag.ID, userID).
-		Returning("*").
-		Exec(ctx)
+// notOwned tells why a tag can't be changed by the owner userID (0 - global tags):
+// ErrForbidden if it is a global tag the user can only read, ErrNotFound otherwise.
+func notOwned(ctx context.Context, db bun.IDB, userID, id int64) error {
+	if userID == 0 {
+		return model.ErrNotFound
+	}
+	global, err := db.NewSelect().Model((*model.Tag)(nil)).Where("id = ? AND user_id IS NULL", id).Exists(ctx)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	aff, err := res.RowsAffected()
+	if global {
+		return model.ErrForbidden
+	}
+	return model.ErrNotFound
+}
+
+// UpdateByID renames the tag and moves it under tag.ParentID (0 - to the root).
+// Only tags of the owner userID in the scope of ctx can be changed, userID 0 means global tags.
+// Paths of all descendant tags are rewritten in the same transaction; moving a tag
+// into itself or its own descendant is rejected with a ValidationError.
+func (r *Repo) UpdateByID(ctx context.Context, userID int64, tag *model.Tag) (*model.Tag, error) {
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		current := new(model.Tag)
+		err := tx.NewSelect().
+			Model(current).
+			Where("id = ? AND ?", tag.ID, repository.Owned(ctx, "", userID)).
+			For("UPDATE").
+			Scan(ctx)
+		if err != nil {
+			if err = repository.IsNoRowsError(err); errors.Is(err, model.ErrNotFound) {
+				return notOwned(ctx, tx, userID, tag.ID)
+			}
+			return err
+		}
+
+		if tag.ParentID != 0 {
+			cycle, err := tx.NewSelect().
+				TableExpr("("+repository.SubtreeSQL("tags")+") AS subtree", tag.ID).
+				Where("subtree.id = ?", tag.ParentID).
+				Exists(ctx)
+			if err != nil {
+				return err
+			}
+			if cycle {
+				return &model.ValidationError{Fields: map[string]string{
+					"parent_id": "tag can't be moved into itself or its descendant",
+				}}
+			}
+		}
+		parentPath, err := parentTagPath(ctx, tx, userID, tag.ParentID)
+		if err != nil {
+			return err
+		}
+		tag.Path = model.TagPath(parentPath, tag.Name)
+
+		_, err = tx.NewUpdate().
+			Model(tag).
+			Set("name = ?", tag.Name).
+			Set("parent_id = ?", bun.NullZero(tag.ParentID)).
+			Set("path = ?", tag.Path).
+			Where("id = ?", tag.ID).
+			Returning("*").
+			Exec(ctx)
+		if err != nil {
+			return repository.IsUniqueViolation(err)
+		}
+		if tag.Path == current.Path {
+			return nil
+		}
+
+		// descendants keep their path suffix after the old prefix
+		_, err = tx.NewUpdate().
+			Model((*model.Tag)(nil)).
+			Set("path = ? || substr(path, ?)", tag.Path, utf8.RuneCountInString(current.Path)+1).
+			Where("id IN ("+repository.SubtreeSQL("tags")+")", tag.ID).
+			Where("id <> ?", tag.ID).
+			Exec(ctx)
+		return repository.IsUniqueViolation(err)
+	})
 	if err != nil {
 		return nil, err
 	}
-	if aff == 0 {
-		return nil, model.ErrNotFound
-	}
 	return tag, nil
 }
 
+// DeleteByID deletes the tag of the owner userID (0 - a global tag) with its descendants.
 func (r *Repo) DeleteByID(ctx context.Context, userID, id int64) error {
-	res, err := r.db.NewDelete().Model((*model.Tag)(nil)).Where("id = ? AND user_id = ?", id, userID).Exec(ctx)
+	res, err := r.db.NewDelete().
+		Model((*model.Tag)(nil)).
+		Where("id = ? AND ?", id, repository.Owned(ctx, "", userID)).
+		Exec(ctx)
 	if err != nil {
 		return err
 	}
@@ -111,7 +274,31 @@ func (r *Repo) DeleteByID(ctx context.Context, userID, id int64) error {
 		return err
 	}
 	if aff == 0 {
-		return model.ErrNotFound
+		return notOwned(ctx, r.db, userID, id)
 	}
 	return nil
 }
+
+// Suggest returns user's and global tags whose name or path starts with prefix,
+// most used first, then most recently used.
+func (r *Repo) Suggest(ctx context.Context, userID int64, prefix string, limit int) ([]model.Tag, error) {
+	tags := []model.Tag{}
+	q := r.db.NewSelect().
+		Model(&tags).
+		ColumnExpr("tag.*").
+		ColumnExpr("tag_usage.note_count").
+		Join(noteCountJoin, countedNotes(ctx, userID)).
+		Where("(? OR tag.user_id IS NULL)", repository.Owned(ctx, "tag", userID))
+	if prefix != "" {
+		like := repository.EscapeLike(prefix) + "%"
+		q.Where("(tag.name ILIKE ? OR tag.path ILIKE ?)", like, like)
+	}
+	err := q.
+		OrderExpr("tag_usage.note_count DESC, tag_usage.last_used_at DESC NULLS LAST, tag.path, tag.id").
+		Limit(limit).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
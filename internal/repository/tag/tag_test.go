@@ -92,6 +92,10 @@ func Test_Repo_CreateTags(t *testing.T) {
 	require.Len(t, got, 2)
 	require.NotZero(t, got[0].ID)
 	require.NotZero(t, got[1].ID)
+
+	_, err = ts.tagRepo.CreateTags(ts.ctx, []*model.Tag{{Name: "go", UserID: user.ID}})
+	var vErr *model.ValidationError
+	require.ErrorAs(t, err, &vErr, "names are checked like in the tag API")
 }
 func Test_Repo_List(t *testing.T) {
 	ts := setupTestSuite(t)
@@ -107,10 +111,71 @@ func Test_Repo_List(t *testing.T) {
 	})
 	require.NoError(t, err)
 
-	tags, err := ts.tagRepo.List(ts.ctx, user.ID, 10, 0, "")
+	tags, err := ts.tagRepo.List(ts.ctx, user.ID, &model.TagFilter{Limit: 10})
+	require.NoError(t, err)
+
+	require.Equal(t, 2, len(tags.Items))
+	require.False(t, tags.HasMore)
+}
+
+func Test_Repo_List_Cursor(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	user := ensureUser(t, ts.db, ts.ctx)
+	_, err := ts.tagRepo.CreateTags(ts.ctx, []*model.Tag{
+		{Name: "ccc", UserID: user.ID}, {Name: "aaa", UserID: user.ID}, {Name: "bbb", UserID: user.ID},
+	})
+	require.NoError(t, err)
+
+	var names []string
+	filter := &model.TagFilter{Limit: 2, Sort: model.Sort{{Name: "name"}}}
+	for {
+		page, err := ts.tagRepo.List(ts.ctx, user.ID, filter)
+		require.NoError(t, err)
+		for _, tag := range page.Items {
+			names = append(names, tag.Name)
+		}
+		if !page.HasMore {
+			break
+		}
+		filter.Cursor = page.NextCursor
+	}
+	require.Equal(t, []string{"aaa", "bbb", "ccc"}, names)
+}
+
+func Test_Repo_List_SortByNoteCount(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	user := ensureUser(t, ts.db, ts.ctx)
+	rare := &model.Tag{Name: "rare", UserID: user.ID}
+	popular := &model.Tag{Name: "popular", UserID: user.ID}
+	unused := &model.Tag{Name: "unused", UserID: user.ID}
+	_, err := ts.tagRepo.CreateTags(ts.ctx, []*model.Tag{rare, popular, unused})
 	require.NoError(t, err)
+	for i, tagIDs := range [][]int64{{rare.ID, popular.ID}, {popular.ID}} {
+		n := &model.Note{Title: "note", UserID: user.ID}
+		_, err := ts.db.NewInsert().Model(n).Exec(ts.ctx)
+		require.NoError(t, err, i)
+		for _, tagID := range tagIDs {
+			_, err := ts.db.NewInsert().Model(&model.NoteTag{NoteID: n.ID, TagID: tagID}).Exec(ts.ctx)
+			require.NoError(t, err)
+		}
+	}
 
-	require.Equal(t, 2, len(tags))
+	var got []string
+	filter := &model.TagFilter{Limit: 1, Sort: model.Sort{{Name: "note_count", Desc: true}}}
+	for {
+		page, err := ts.tagRepo.List(ts.ctx, user.ID, filter)
+		require.NoError(t, err)
+		for _, tag := range page.Items {
+			got = append(got, tag.Name)
+		}
+		if !page.HasMore {
+			break
+		}
+		filter.Cursor = page.NextCursor
+	}
+	require.Equal(t, []string{"popular", "rare", "unused"}, got)
 }
 
 func Test_Repo_GetByID(t *testing.T) {
@@ -158,6 +223,10 @@ func Test_Repo_GetByIDs(t *testing.T) {
 	require.True(t, ids[t1.ID])
 	require.True(t, ids[t2.ID])
 
+	got, err = ts.tagRepo.GetByIDs(ts.ctx, u1.ID, []int64{t1.ID, t2.ID, t1.ID})
+	require.NoError(t, err, "duplicate ids are ignored")
+	require.Len(t, got, 2)
+
 	got, err = ts.tagRepo.GetByIDs(ts.ctx, u1.ID, []int64{t1.ID, t2.ID, t3.ID})
 	require.Error(t, err)
 	require.Nil(t, got)
@@ -202,3 +271,215 @@ func Test_Repo_DeleteByID(t *testing.T) {
 	require.ErrorIs(t, err, model.ErrNotFound, "there is no tag with this id")
 	require.Nil(t, got)
 }
+
+func Test_Repo_Create_Child(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	user := ensureUser(t, ts.db, ts.ctx)
+	work := &model.Tag{Name: "work", UserID: user.ID}
+	require.NoError(t, ts.tagRepo.Create(ts.ctx, work))
+	clients := &model.Tag{Name: "clients", ParentID: work.ID, UserID: user.ID}
+	require.NoError(t, ts.tagRepo.Create(ts.ctx, clients))
+	require.Equal(t, "work/clients", clients.Path)
+
+	err := ts.tagRepo.Create(ts.ctx, &model.Tag{Name: "clients", ParentID: work.ID, UserID: user.ID})
+	require.ErrorIs(t, err, model.ErrConflict, "path is unique per user")
+
+	err = ts.tagRepo.Create(ts.ctx, &model.Tag{Name: "x", ParentID: work.ID, UserID: 0})
+	var vErr *model.ValidationError
+	require.ErrorAs(t, err, &vErr, "parent must have the same owner")
+
+	root := int64(0)
+	page, err := ts.tagRepo.List(ts.ctx, user.ID, &model.TagFilter{Limit: 10, ParentID: &root})
+	require.NoError(t, err)
+	require.Len(t, page.Items, 1)
+	require.Equal(t, work.ID, page.Items[0].ID)
+}
+
+func Test_Repo_UpdateByID_Subtree(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	user := ensureUser(t, ts.db, ts.ctx)
+	work := &model.Tag{Name: "work", UserID: user.ID}
+	require.NoError(t, ts.tagRepo.Create(ts.ctx, work))
+	clients := &model.Tag{Name: "clients", ParentID: work.ID, UserID: user.ID}
+	require.NoError(t, ts.tagRepo.Create(ts.ctx, clients))
+	acme := &model.Tag{Name: "acme", ParentID: clients.ID, UserID: user.ID}
+	require.NoError(t, ts.tagRepo.Create(ts.ctx, acme))
+	archive := &model.Tag{Name: "archive", UserID: user.ID}
+	require.NoError(t, ts.tagRepo.Create(ts.ctx, archive))
+
+	upd, err := ts.tagRepo.UpdateByID(ts.ctx, user.ID, &model.Tag{ID: clients.ID, Name: "customers", ParentID: archive.ID})
+	require.NoError(t, err)
+	require.Equal(t, "archive/customers", upd.Path)
+
+	got, err := ts.tagRepo.GetByID(ts.ctx, user.ID, acme.ID)
+	require.NoError(t, err)
+	require.Equal(t, "archive/customers/acme", got.Path, "descendants follow the new path")
+
+	_, err = ts.tagRepo.UpdateByID(ts.ctx, user.ID, &model.Tag{ID: archive.ID, Name: "archive", ParentID: acme.ID})
+	var vErr *model.ValidationError
+	require.ErrorAs(t, err, &vErr, "can't move a tag into its descendant")
+}
+
+func insertTaggedNote(t *testing.T, ts *testSuite, userID int64, tagIDs ...int64) *model.Note {
+	t.Helper()
+	n := &model.Note{Title: "note", UserID: userID}
+	_, err := ts.db.NewInsert().Model(n).Returning("id").Exec(ts.ctx)
+	require.NoError(t, err)
+	for _, tagID := range tagIDs {
+		_, err = ts.db.NewInsert().Model(&model.NoteTag{NoteID: n.ID, TagID: tagID}).Exec(ts.ctx)
+		require.NoError(t, err)
+	}
+	return n
+}
+
+func noteTagIDs(t *testing.T, ts *testSuite, noteID int64) []int64 {
+	t.Helper()
+	var ids []int64
+	err := ts.db.NewSelect().Model((*model.NoteTag)(nil)).Column("tag_id").Where("note_id = ?", noteID).Scan(ts.ctx, &ids)
+	require.NoError(t, err)
+	return ids
+}
+
+func Test_Repo_DeleteByID_InUse(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	user := ensureUser(t, ts.db, ts.ctx)
+	tag := &model.Tag{Name: "used", UserID: user.ID}
+	require.NoError(t, ts.tagRepo.Create(ts.ctx, tag))
+	n := insertTaggedNote(t, ts, user.ID, tag.ID)
+
+	require.NoError(t, ts.tagRepo.DeleteByID(ts.ctx, user.ID, tag.ID))
+	require.Empty(t, noteTagIDs(t, ts, n.ID))
+}
+
+func Test_Repo_Merge(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	user := ensureUser(t, ts.db, ts.ctx)
+	todo := &model.Tag{Name: "todo", UserID: user.ID}
+	require.NoError(t, ts.tagRepo.Create(ts.ctx, todo))
+	dup := &model.Tag{Name: "to-do", UserID: user.ID}
+	require.NoError(t, ts.tagRepo.Create(ts.ctx, dup))
+	child := &model.Tag{Name: "urgent", ParentID: dup.ID, UserID: user.ID}
+	require.NoError(t, ts.tagRepo.Create(ts.ctx, child))
+
+	onlyDup := insertTaggedNote(t, ts, user.ID, dup.ID)
+	both := insertTaggedNote(t, ts, user.ID, dup.ID, todo.ID)
+
+	target, err := ts.tagRepo.Merge(ts.ctx, user.ID, dup.ID, todo.ID)
+	require.NoError(t, err)
+	require.Equal(t, todo.ID, target.ID)
+
+	require.Equal(t, []int64{todo.ID}, noteTagIDs(t, ts, onlyDup.ID))
+	require.Equal(t, []int64{todo.ID}, noteTagIDs(t, ts, both.ID))
+
+	_, err = ts.tagRepo.GetByID(ts.ctx, user.ID, dup.ID)
+	require.ErrorIs(t, err, model.ErrNotFound, "source tag is deleted")
+	got, err := ts.tagRepo.GetByID(ts.ctx, user.ID, child.ID)
+	require.NoError(t, err)
+	require.Equal(t, todo.ID, got.ParentID)
+	require.Equal(t, "todo/urgent", got.Path)
+
+	_, err = ts.tagRepo.Merge(ts.ctx, user.ID, todo.ID, todo.ID)
+	var vErr *model.ValidationError
+	require.ErrorAs(t, err, &vErr)
+
+	_, err = ts.tagRepo.Merge(ts.ctx, user.ID, dup.ID, todo.ID)
+	require.ErrorIs(t, err, model.ErrNotFound)
+}
+
+func Test_Repo_Merge_GlobalTarget(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	user := ensureUser(t, ts.db, ts.ctx)
+	global := &model.Tag{Name: "work"}
+	require.NoError(t, ts.tagRepo.Create(ts.ctx, global))
+	parent := &model.Tag{Name: "job", UserID: user.ID}
+	require.NoError(t, ts.tagRepo.Create(ts.ctx, parent))
+	child := &model.Tag{Name: "salary", ParentID: parent.ID, UserID: user.ID}
+	require.NoError(t, ts.tagRepo.Create(ts.ctx, child))
+	leaf := &model.Tag{Name: "office", UserID: user.ID}
+	require.NoError(t, ts.tagRepo.Create(ts.ctx, leaf))
+
+	_, err := ts.tagRepo.Merge(ts.ctx, user.ID, parent.ID, global.ID)
+	var vErr *model.ValidationError
+	require.ErrorAs(t, err, &vErr, "private child tags can't move under a global tag")
+	got, err := ts.tagRepo.GetByID(ts.ctx, user.ID, child.ID)
+	require.NoError(t, err)
+	require.Equal(t, parent.ID, got.ParentID, "nothing is changed")
+
+	n := insertTaggedNote(t, ts, user.ID, leaf.ID)
+	target, err := ts.tagRepo.Merge(ts.ctx, user.ID, leaf.ID, global.ID)
+	require.NoError(t, err, "a tag without children can be merged into a global one")
+	require.Equal(t, global.ID, target.ID)
+	require.Equal(t, []int64{global.ID}, noteTagIDs(t, ts, n.ID))
+}
+
+func Test_Repo_GlobalTags(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	user := ensureUser(t, ts.db, ts.ctx)
+	global := &model.Tag{Name: "global"}
+	require.NoError(t, ts.tagRepo.Create(ts.ctx, global))
+	err := ts.tagRepo.Create(ts.ctx, &model.Tag{Name: "global"})
+	require.ErrorIs(t, err, model.ErrConflict, "global paths are unique")
+
+	got, err := ts.tagRepo.GetByID(ts.ctx, user.ID, global.ID)
+	require.NoError(t, err, "users see global tags")
+	require.Zero(t, got.UserID)
+	tags, err := ts.tagRepo.GetByIDs(ts.ctx, user.ID, []int64{global.ID})
+	require.NoError(t, err)
+	require.Len(t, tags, 1)
+
+	_, err = ts.tagRepo.UpdateByID(ts.ctx, user.ID, &model.Tag{ID: global.ID, Name: "mine"})
+	require.ErrorIs(t, err, model.ErrForbidden)
+	err = ts.tagRepo.DeleteByID(ts.ctx, user.ID, global.ID)
+	require.ErrorIs(t, err, model.ErrForbidden)
+
+	upd, err := ts.tagRepo.UpdateByID(ts.ctx, 0, &model.Tag{ID: global.ID, Name: "renamed"})
+	require.NoError(t, err, "global tags are managed with owner 0")
+	require.Equal(t, "renamed", upd.Path)
+
+	own := &model.Tag{Name: "own", UserID: user.ID}
+	require.NoError(t, ts.tagRepo.Create(ts.ctx, own))
+	page, err := ts.tagRepo.List(ts.ctx, 0, &model.TagFilter{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, page.Items, 1, "owner 0 lists only global tags")
+	err = ts.tagRepo.DeleteByID(ts.ctx, 0, own.ID)
+	require.ErrorIs(t, err, model.ErrNotFound)
+
+	require.NoError(t, ts.tagRepo.DeleteByID(ts.ctx, 0, global.ID))
+}
+
+func Test_Repo_Suggest(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	user := ensureUser(t, ts.db, ts.ctx)
+	work := &model.Tag{Name: "work", UserID: user.ID}
+	workout := &model.Tag{Name: "workout", UserID: user.ID}
+	wonder := &model.Tag{Name: "wo_nder", UserID: user.ID}
+	home := &model.Tag{Name: "home", UserID: user.ID}
+	_, err := ts.tagRepo.CreateTags(ts.ctx, []*model.Tag{work, workout, wonder, home})
+	require.NoError(t, err)
+	insertTaggedNote(t, ts, user.ID, workout.ID)
+	insertTaggedNote(t, ts, user.ID, workout.ID, work.ID)
+
+	tags, err := ts.tagRepo.Suggest(ts.ctx, user.ID, "WO", 10)
+	require.NoError(t, err)
+	require.Len(t, tags, 3)
+	require.Equal(t, workout.ID, tags[0].ID, "most used first")
+	require.Equal(t, int64(2), tags[0].NoteCount)
+	require.Equal(t, work.ID, tags[1].ID)
+	require.Equal(t, wonder.ID, tags[2].ID)
+
+	tags, err = ts.tagRepo.Suggest(ts.ctx, user.ID, "wo_", 10)
+	require.NoError(t, err)
+	require.Len(t, tags, 1, "LIKE wildcards are matched literally")
+	require.Equal(t, wonder.ID, tags[0].ID)
+
+	tags, err = ts.tagRepo.Suggest(ts.ctx, 9999999, "wo", 10)
+	require.NoError(t, err)
+	require.Empty(t, tags)
+}
@@ -4,14 +4,17 @@ import (
 	"context"
 	"database/sql"
 	"embed"
+	"errors"
 	"fmt"
 	"log"
+	"os"
 
 	myfs "github.com/Rasulikus/notebook"
 	"github.com/Rasulikus/notebook/internal/config"
 	"github.com/Rasulikus/notebook/internal/model"
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source"
 	"github.com/golang-migrate/migrate/v4/source/iofs"
 	"github.com/uptrace/bun"
 	"github.com/uptrace/bun/dialect/pgdialect"
@@ -25,9 +28,20 @@ var (
 	testDSN     string
 	truncateSQL = `
 	TRUNCATE TABLE
+		workspace_invites,
+		workspace_members,
+		access_grants,
+		note_shares,
+		attachment_thumbs,
+		attachments,
+		reminders,
+		note_links,
+		note_revisions,
+		notebooks,
 		notes_tags,
 		notes,
 		tags,
+		workspaces,
 		users
 	RESTART IDENTITY CASCADE;
 	`
@@ -120,6 +134,10 @@ func RecreateTables() {
 	if err != nil {
 		log.Fatalf("iofs.New: %v", err)
 	}
+	latest, err := latestVersion(d)
+	if err != nil {
+		log.Fatalf("latestVersion: %v", err)
+	}
 	m, err := migrate.NewWithSourceInstance("iofs", d, testDSN)
 	if err != nil {
 		log.Fatalf("migrate.NewWithSourceInstance: %v", err)
@@ -127,7 +145,7 @@ func RecreateTables() {
 	}
 	defer m.Close() //nolint:errcheck // not need
 
-	err = m.Force(1)
+	err = m.Force(int(latest))
 	if err != nil {
 		log.Fatalf("migrate.Force: %v", err)
 		return
@@ -141,6 +159,25 @@ func RecreateTables() {
 	}
 }
 
+// latestVersion returns the version of the last migration in the source,
+// so that Down can roll back every migration regardless of the db state.
+func latestVersion(d source.Driver) (uint, error) {
+	v, err := d.First()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		next, err := d.Next(v)
+		if errors.Is(err, os.ErrNotExist) {
+			return v, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		v = next
+	}
+}
+
 func CleanDB(ctx context.Context) {
 	_, err := testDB.ExecContext(ctx, truncateSQL)
 	if err != nil {
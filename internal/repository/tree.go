@@ -0,0 +1,15 @@
+package repository
+
+import "fmt"
+
+// SubtreeSQL returns a query selecting ids of the rows with id IN (?) and all their
+// descendants linked through parent_id in the given table. Use it as a subquery:
+//
+//	q.Where("note.notebook_id IN ("+repository.SubtreeSQL("notebooks")+")", rootID)
+func SubtreeSQL(table string) string {
+	return fmt.Sprintf(`WITH RECURSIVE subtree(id) AS (
+		SELECT id FROM %[1]s WHERE id IN (?)
+		UNION
+		SELECT child.id FROM %[1]s AS child JOIN subtree ON child.parent_id = subtree.id
+	) SELECT id FROM subtree`, table)
+}
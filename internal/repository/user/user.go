@@ -34,3 +34,15 @@ func (r *repo) GetByEmail(ctx context.Context, email string) (*model.User, error
 	return user, nil
 
 }
+
+func (r *repo) GetByID(ctx context.Context, id int64) (*model.User, error) {
+	user := new(model.User)
+	err := r.db.NewSelect().
+		Model(user).
+		Where("id = ?", id).
+		Scan(ctx)
+	if err != nil {
+		return nil, repository.IsNoRowsError(err)
+	}
+	return user, nil
+}
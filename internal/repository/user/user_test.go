@@ -65,3 +65,18 @@ func Test_Repo_FindByEmail(t *testing.T) {
 	require.Error(t, err)
 	require.Equal(t, gotErr, (*model.User)(nil))
 }
+
+func Test_Repo_GetByID(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	user := &model.User{Email: "admin@g.ru", PasswordHash: "123", Name: "admin", IsAdmin: true}
+	require.NoError(t, ts.userRepo.Create(ts.ctx, user))
+
+	got, err := ts.userRepo.GetByID(ts.ctx, user.ID)
+	require.NoError(t, err)
+	require.Equal(t, user.Email, got.Email)
+	require.True(t, got.IsAdmin)
+
+	_, err = ts.userRepo.GetByID(ts.ctx, 9999999)
+	require.ErrorIs(t, err, model.ErrNotFound)
+}
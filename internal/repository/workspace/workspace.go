@@ -0,0 +1,353 @@
+package workspace
+
+import (
+	"context"
+	"time"
+
+	"github.com/Rasulikus/notebook/internal/model"
+	"github.com/Rasulikus/notebook/internal/repository"
+	"github.com/uptrace/bun"
+)
+
+type Repo struct {
+	db *bun.DB
+}
+
+func NewRepository(db *bun.DB) *Repo {
+	return &Repo{db: db}
+}
+
+// Create inserts the workspace with ownerID as its first owner.
+func (r *Repo) Create(ctx context.Context, ws *model.Workspace, ownerID int64) error {
+	return r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewInsert().Model(ws).Returning("*").Exec(ctx)
+		if err != nil {
+			return err
+		}
+		member := &model.WorkspaceMembership{WorkspaceID: ws.ID, UserID: ownerID, Role: model.WorkspaceOwner}
+		_, err = tx.NewInsert().Model(member).Exec(ctx)
+		if err != nil {
+			return err
+		}
+		ws.Role = member.Role
+		return nil
+	})
+}
+
+// List returns workspaces the user is a member of with the user's role, oldest first.
+func (r *Repo) List(ctx context.Context, userID int64) ([]model.Workspace, error) {
+	workspaces := []model.Workspace{}
+	err := r.db.NewSelect().
+		Model(&workspaces).
+		ColumnExpr("workspace.*").
+		ColumnExpr("member.role").
+		Join("JOIN workspace_members AS member ON member.workspace_id = workspace.id").
+		Where("member.user_id = ?", userID).
+		Order("workspace.id").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return workspaces, nil
+}
+
+// GetByID returns the workspace with the user's role; ErrNotFound unless the user is a member.
+func (r *Repo) GetByID(ctx context.Context, userID, id int64) (*model.Workspace, error) {
+	ws := new(model.Workspace)
+	err := r.db.NewSelect().
+		Model(ws).
+		ColumnExpr("workspace.*").
+		ColumnExpr("member.role").
+		Join("JOIN workspace_members AS member ON member.workspace_id = workspace.id").
+		Where("workspace.id = ? AND member.user_id = ?", id, userID).
+		Scan(ctx)
+	if err != nil {
+		return nil, repository.IsNoRowsError(err)
+	}
+	return ws, nil
+}
+
+func (r *Repo) Rename(ctx context.Context, id int64, name string) error {
+	res, err := r.db.NewUpdate().
+		Model((*model.Workspace)(nil)).
+		Set("name = ?", name).
+		Where("id = ?", id).
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+	aff, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if aff == 0 {
+		return model.ErrNotFound
+	}
+	return nil
+}
+
+// DeleteByID deletes the workspace; its members, invitations, notes and tags go by ON DELETE CASCADE.
+func (r *Repo) DeleteByID(ctx context.Context, id int64) error {
+	res, err := r.db.NewDelete().
+		Model((*model.Workspace)(nil)).
+		Where("id = ?", id).
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+	aff, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if aff == 0 {
+		return model.ErrNotFound
+	}
+	return nil
+}
+
+// GetMember returns the user's membership in the workspace; ErrNotFound for non-members.
+func (r *Repo) GetMember(ctx context.Context, workspaceID, userID int64) (*model.WorkspaceMembership, error) {
+	member := new(model.WorkspaceMembership)
+	err := r.db.NewSelect().
+		Model(member).
+		Where("workspace_id = ? AND user_id = ?", workspaceID, userID).
+		Scan(ctx)
+	if err != nil {
+		return nil, repository.IsNoRowsError(err)
+	}
+	return member, nil
+}
+
+// ListMembers returns members of the workspace with their emails and names, in joining order.
+func (r *Repo) ListMembers(ctx context.Context, workspaceID int64) ([]model.WorkspaceMembership, error) {
+	members := []model.WorkspaceMembership{}
+	err := r.db.NewSelect().
+		Model(&members).
+		ColumnExpr("member.*").
+		ColumnExpr("u.email, u.name").
+		Join("JOIN users AS u ON u.id = member.user_id").
+		Where("member.workspace_id = ?", workspaceID).
+		Order("member.created_at", "member.user_id").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// SetMemberRole changes the role of a member on behalf of the member actorID. Both memberships are
+// read under a lock and passed to allow, which decides whether the actor may do it.
+// The last owner can't be demoted.
+func (r *Repo) SetMemberRole(ctx context.Context, workspaceID, actorID, userID int64, role model.WorkspaceRole, allow func(actor, target *model.WorkspaceMembership) error) error {
+	return r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		actor, target, err := lockMembers(ctx, tx, workspaceID, actorID, userID)
+		if err != nil {
+			return err
+		}
+		if err := allow(actor, target); err != nil {
+			return err
+		}
+		if role != model.WorkspaceOwner {
+			if err := keepOwner(ctx, tx, workspaceID, userID); err != nil {
+				return err
+			}
+		}
+		res, err := tx.NewUpdate().
+			Model((*model.WorkspaceMembership)(nil)).
+			Set("role = ?", role).
+			Where("workspace_id = ? AND user_id = ?", workspaceID, userID).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		aff, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if aff == 0 {
+			return model.ErrNotFound
+		}
+		return nil
+	})
+}
+
+// RemoveMember takes the user out of the workspace on behalf of the member actorID, who may be
+// the user. Both memberships are read under a lock and passed to allow, which decides whether
+// the actor may do it. The last owner can't leave.
+func (r *Repo) RemoveMember(ctx context.Context, workspaceID, actorID, userID int64, allow func(actor, target *model.WorkspaceMembership) error) error {
+	return r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		actor, target, err := lockMembers(ctx, tx, workspaceID, actorID, userID)
+		if err != nil {
+			return err
+		}
+		if err := allow(actor, target); err != nil {
+			return err
+		}
+		if err := keepOwner(ctx, tx, workspaceID, userID); err != nil {
+			return err
+		}
+		res, err := tx.NewDelete().
+			Model((*model.WorkspaceMembership)(nil)).
+			Where("workspace_id = ? AND user_id = ?", workspaceID, userID).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		aff, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if aff == 0 {
+			return model.ErrNotFound
+		}
+		return nil
+	})
+}
+
+// lockMembers locks the workspace, so changes of its members run one at a time, and returns
+// the memberships of the actor and of the target user; ErrNotFound unless both are members.
+func lockMembers(ctx context.Context, tx bun.Tx, workspaceID, actorID, userID int64) (*model.WorkspaceMembership, *model.WorkspaceMembership, error) {
+	_, err := tx.NewSelect().
+		Model((*model.Workspace)(nil)).
+		Column("id").
+		Where("id = ?", workspaceID).
+		For("UPDATE").
+		Exec(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	var members []model.WorkspaceMembership
+	err = tx.NewSelect().
+		Model(&members).
+		Where("workspace_id = ? AND user_id IN (?)", workspaceID, bun.In([]int64{actorID, userID})).
+		Scan(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	var actor, target *model.WorkspaceMembership
+	for i := range members {
+		if members[i].UserID == actorID {
+			actor = &members[i]
+		}
+		if members[i].UserID == userID {
+			target = &members[i]
+		}
+	}
+	if actor == nil || target == nil {
+		return nil, nil, model.ErrNotFound
+	}
+	return actor, target, nil
+}
+
+// keepOwner locks the owners of the workspace and fails with ErrConflict when the user
+// is the only one of them, so the workspace never ends up without an owner.
+func keepOwner(ctx context.Context, tx bun.Tx, workspaceID, userID int64) error {
+	var owners []int64
+	err := tx.NewSelect().
+		Model((*model.WorkspaceMembership)(nil)).
+		Column("user_id").
+		Where("workspace_id = ? AND role = ?", workspaceID, model.WorkspaceOwner).
+		For("UPDATE").
+		Scan(ctx, &owners)
+	if err != nil {
+		return err
+	}
+	if len(owners) == 1 && owners[0] == userID {
+		return model.ErrConflict
+	}
+	return nil
+}
+
+func (r *Repo) CreateInvite(ctx context.Context, invite *model.WorkspaceInvite) error {
+	_, err := r.db.NewInsert().Model(invite).Returning("*").Exec(ctx)
+	return repository.IsUniqueViolation(err)
+}
+
+// ListInvites returns invitations of the workspace that can still be accepted at now, the latest first.
+func (r *Repo) ListInvites(ctx context.Context, workspaceID int64, now time.Time) ([]model.WorkspaceInvite, error) {
+	invites := []model.WorkspaceInvite{}
+	err := r.db.NewSelect().
+		Model(&invites).
+		Where("workspace_id = ?", workspaceID).
+		Where("accepted_at IS NULL AND revoked_at IS NULL AND expires_at > ?", now).
+		Order("created_at DESC", "id DESC").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return invites, nil
+}
+
+// RevokeInvite makes a pending invitation of the workspace stop working.
+func (r *Repo) RevokeInvite(ctx context.Context, workspaceID, id int64) error {
+	res, err := r.db.NewUpdate().
+		Model((*model.WorkspaceInvite)(nil)).
+		Set("revoked_at = now()").
+		Where("id = ? AND workspace_id = ?", id, workspaceID).
+		Where("accepted_at IS NULL AND revoked_at IS NULL").
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+	aff, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if aff == 0 {
+		return model.ErrNotFound
+	}
+	return nil
+}
+
+// AcceptInvite locks the invitation with the token, lets accept decide whether the user may take it
+// and makes the user a member with the invited role. A user who is already a member keeps the current role.
+// An invitation that is accepted, revoked or expired at now is ErrNotFound.
+func (r *Repo) AcceptInvite(ctx context.Context, tokenHash []byte, userID int64, now time.Time, accept func(invite *model.WorkspaceInvite) error) (*model.WorkspaceMembership, error) {
+	member := new(model.WorkspaceMembership)
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		invite := new(model.WorkspaceInvite)
+		err := tx.NewSelect().
+			Model(invite).
+			Where("token_hash = ?", tokenHash).
+			For("UPDATE").
+			Scan(ctx)
+		if err != nil {
+			return repository.IsNoRowsError(err)
+		}
+		if !invite.Pending(now) {
+			return model.ErrNotFound
+		}
+		if err := accept(invite); err != nil {
+			return err
+		}
+
+		member.WorkspaceID = invite.WorkspaceID
+		member.UserID = userID
+		member.Role = invite.Role
+		_, err = tx.NewInsert().
+			Model(member).
+			On("CONFLICT (workspace_id, user_id) DO NOTHING").
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		err = tx.NewSelect().
+			Model(member).
+			Where("workspace_id = ? AND user_id = ?", invite.WorkspaceID, userID).
+			Scan(ctx)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.NewUpdate().
+			Model((*model.WorkspaceInvite)(nil)).
+			Set("accepted_at = ?", now).
+			Where("id = ?", invite.ID).
+			Exec(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return member, nil
+}
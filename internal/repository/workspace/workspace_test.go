@@ -0,0 +1,175 @@
+package workspace
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Rasulikus/notebook/internal/model"
+	testdb "github.com/Rasulikus/notebook/internal/repository/test_db"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+)
+
+func TestMain(m *testing.M) {
+	testdb.RecreateTables()
+	code := m.Run()
+	testdb.CloseDB()
+	os.Exit(code)
+}
+
+type testSuite struct {
+	db            *bun.DB
+	workspaceRepo *Repo
+	ctx           context.Context
+}
+
+func setupTestSuite(t *testing.T) *testSuite {
+	t.Helper()
+	var suite testSuite
+	suite.db = testdb.DB()
+	suite.workspaceRepo = NewRepository(suite.db)
+	suite.ctx = context.Background()
+	return &suite
+}
+
+func ensureUser(t *testing.T, db *bun.DB, ctx context.Context, email string) *model.User {
+	t.Helper()
+	u := &model.User{Email: email, PasswordHash: "x", Name: "test"}
+	err := db.NewInsert().Model(u).Scan(ctx, u)
+	require.NoError(t, err)
+	require.NotZero(t, u.ID)
+	return u
+}
+
+func Test_Repo_CreateAndMembers(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	owner := ensureUser(t, ts.db, ts.ctx, "owner@mail.ru")
+	other := ensureUser(t, ts.db, ts.ctx, "other@mail.ru")
+
+	ws := &model.Workspace{Name: "team"}
+	require.NoError(t, ts.workspaceRepo.Create(ts.ctx, ws, owner.ID))
+	require.NotZero(t, ws.ID)
+	require.Equal(t, model.WorkspaceOwner, ws.Role)
+
+	list, err := ts.workspaceRepo.List(ts.ctx, owner.ID)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	require.Equal(t, model.WorkspaceOwner, list[0].Role)
+
+	list, err = ts.workspaceRepo.List(ts.ctx, other.ID)
+	require.NoError(t, err)
+	require.Empty(t, list)
+	_, err = ts.workspaceRepo.GetByID(ts.ctx, other.ID, ws.ID)
+	require.ErrorIs(t, err, model.ErrNotFound)
+	_, err = ts.workspaceRepo.GetMember(ts.ctx, ws.ID, other.ID)
+	require.ErrorIs(t, err, model.ErrNotFound)
+
+	require.ErrorIs(t, ts.workspaceRepo.SetMemberRole(ts.ctx, ws.ID, owner.ID, owner.ID, model.WorkspaceAdmin, allowAll), model.ErrConflict,
+		"the last owner can't be demoted")
+	require.ErrorIs(t, ts.workspaceRepo.RemoveMember(ts.ctx, ws.ID, owner.ID, owner.ID, allowAll), model.ErrConflict,
+		"the last owner can't leave")
+
+	_, err = ts.db.NewInsert().Model(&model.WorkspaceMembership{WorkspaceID: ws.ID, UserID: other.ID, Role: model.WorkspaceViewer}).Exec(ts.ctx)
+	require.NoError(t, err)
+	require.NoError(t, ts.workspaceRepo.SetMemberRole(ts.ctx, ws.ID, owner.ID, other.ID, model.WorkspaceOwner, allowAll))
+	require.NoError(t, ts.workspaceRepo.RemoveMember(ts.ctx, ws.ID, owner.ID, owner.ID, allowAll), "another owner is left")
+
+	members, err := ts.workspaceRepo.ListMembers(ts.ctx, ws.ID)
+	require.NoError(t, err)
+	require.Len(t, members, 1)
+	require.Equal(t, other.ID, members[0].UserID)
+	require.Equal(t, "other@mail.ru", members[0].Email)
+	require.Equal(t, model.WorkspaceOwner, members[0].Role)
+}
+
+func Test_Repo_MemberChecks(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	owner := ensureUser(t, ts.db, ts.ctx, "owner@mail.ru")
+	admin := ensureUser(t, ts.db, ts.ctx, "admin@mail.ru")
+	stranger := ensureUser(t, ts.db, ts.ctx, "stranger@mail.ru")
+	ws := &model.Workspace{Name: "team"}
+	require.NoError(t, ts.workspaceRepo.Create(ts.ctx, ws, owner.ID))
+	_, err := ts.db.NewInsert().Model(&model.WorkspaceMembership{WorkspaceID: ws.ID, UserID: admin.ID, Role: model.WorkspaceAdmin}).Exec(ts.ctx)
+	require.NoError(t, err)
+
+	var seen [2]model.WorkspaceRole
+	refuse := func(actor, target *model.WorkspaceMembership) error {
+		seen = [2]model.WorkspaceRole{actor.Role, target.Role}
+		return model.ErrForbidden
+	}
+	err = ts.workspaceRepo.SetMemberRole(ts.ctx, ws.ID, admin.ID, owner.ID, model.WorkspaceViewer, refuse)
+	require.ErrorIs(t, err, model.ErrForbidden)
+	require.Equal(t, [2]model.WorkspaceRole{model.WorkspaceAdmin, model.WorkspaceOwner}, seen, "allow gets the current roles")
+	require.ErrorIs(t, ts.workspaceRepo.RemoveMember(ts.ctx, ws.ID, admin.ID, owner.ID, refuse), model.ErrForbidden)
+
+	m, err := ts.workspaceRepo.GetMember(ts.ctx, ws.ID, owner.ID)
+	require.NoError(t, err)
+	require.Equal(t, model.WorkspaceOwner, m.Role, "a refused change is not applied")
+
+	err = ts.workspaceRepo.SetMemberRole(ts.ctx, ws.ID, stranger.ID, admin.ID, model.WorkspaceViewer, allowAll)
+	require.ErrorIs(t, err, model.ErrNotFound, "the actor must be a member")
+	err = ts.workspaceRepo.RemoveMember(ts.ctx, ws.ID, owner.ID, stranger.ID, allowAll)
+	require.ErrorIs(t, err, model.ErrNotFound, "the target must be a member")
+}
+
+// allowAll lets any member change any other.
+func allowAll(_, _ *model.WorkspaceMembership) error { return nil }
+
+func Test_Repo_AcceptInvite(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	owner := ensureUser(t, ts.db, ts.ctx, "owner@mail.ru")
+	invited := ensureUser(t, ts.db, ts.ctx, "invited@mail.ru")
+	ws := &model.Workspace{Name: "team"}
+	require.NoError(t, ts.workspaceRepo.Create(ts.ctx, ws, owner.ID))
+
+	now := time.Now()
+	invite := &model.WorkspaceInvite{
+		WorkspaceID: ws.ID,
+		InvitedBy:   owner.ID,
+		Email:       invited.Email,
+		Role:        model.WorkspaceMember,
+		TokenHash:   []byte("token"),
+		ExpiresAt:   now.Add(time.Hour),
+	}
+	require.NoError(t, ts.workspaceRepo.CreateInvite(ts.ctx, invite))
+	expired := &model.WorkspaceInvite{
+		WorkspaceID: ws.ID,
+		InvitedBy:   owner.ID,
+		Email:       invited.Email,
+		Role:        model.WorkspaceAdmin,
+		TokenHash:   []byte("expired"),
+		ExpiresAt:   now.Add(-time.Hour),
+	}
+	require.NoError(t, ts.workspaceRepo.CreateInvite(ts.ctx, expired))
+
+	pending, err := ts.workspaceRepo.ListInvites(ts.ctx, ws.ID, now)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	require.Equal(t, invite.ID, pending[0].ID)
+
+	accept := func(*model.WorkspaceInvite) error { return nil }
+	_, err = ts.workspaceRepo.AcceptInvite(ts.ctx, []byte("expired"), invited.ID, now, accept)
+	require.ErrorIs(t, err, model.ErrNotFound)
+	_, err = ts.workspaceRepo.AcceptInvite(ts.ctx, []byte("token"), invited.ID, now, func(*model.WorkspaceInvite) error {
+		return model.ErrForbidden
+	})
+	require.ErrorIs(t, err, model.ErrForbidden)
+
+	member, err := ts.workspaceRepo.AcceptInvite(ts.ctx, []byte("token"), invited.ID, now, accept)
+	require.NoError(t, err)
+	require.Equal(t, ws.ID, member.WorkspaceID)
+	require.Equal(t, model.WorkspaceMember, member.Role)
+
+	_, err = ts.workspaceRepo.AcceptInvite(ts.ctx, []byte("token"), invited.ID, now, accept)
+	require.ErrorIs(t, err, model.ErrNotFound, "an invitation is accepted once")
+	pending, err = ts.workspaceRepo.ListInvites(ts.ctx, ws.ID, now)
+	require.NoError(t, err)
+	require.Empty(t, pending)
+
+	require.ErrorIs(t, ts.workspaceRepo.RevokeInvite(ts.ctx, ws.ID, invite.ID), model.ErrNotFound)
+}
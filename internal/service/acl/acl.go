@@ -0,0 +1,116 @@
+// Package acl provides business logic for sharing notes and tags with other users.
+package acl
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/Rasulikus/notebook/internal/model"
+	"github.com/Rasulikus/notebook/internal/repository"
+)
+
+// Service manages access grants; checking them is up to the note service.
+type Service struct {
+	aclRepo  repository.ACLRepository
+	noteRepo repository.NoteRepository
+	tagRepo  repository.TagRepository
+	userRepo repository.UserRepository
+}
+
+// NewService constructs the ACL service.
+func NewService(aclRepo repository.ACLRepository, noteRepo repository.NoteRepository, tagRepo repository.TagRepository, userRepo repository.UserRepository) *Service {
+	return &Service{aclRepo: aclRepo, noteRepo: noteRepo, tagRepo: tagRepo, userRepo: userRepo}
+}
+
+// ShareNote gives the user with the email a role on a note of the owner.
+func (s *Service) ShareNote(ctx context.Context, ownerID, noteID int64, email string, role model.Role) (*model.AccessGrant, error) {
+	if err := s.checkNoteOwner(ctx, ownerID, noteID); err != nil {
+		return nil, err
+	}
+	grant := &model.AccessGrant{OwnerID: ownerID, NoteID: noteID, Role: role}
+	return grant, s.grant(ctx, grant, email)
+}
+
+// ShareTag gives the user with the email a role on all notes of the owner that carry the tag,
+// now or later. Only the owner's own tags can be shared, not global ones.
+func (s *Service) ShareTag(ctx context.Context, ownerID, tagID int64, email string, role model.Role) (*model.AccessGrant, error) {
+	if err := s.checkTagOwner(ctx, ownerID, tagID); err != nil {
+		return nil, err
+	}
+	grant := &model.AccessGrant{OwnerID: ownerID, TagID: tagID, Role: role}
+	return grant, s.grant(ctx, grant, email)
+}
+
+// ListNoteGrants returns who the owner shared the note with.
+func (s *Service) ListNoteGrants(ctx context.Context, ownerID, noteID int64) ([]model.AccessGrant, error) {
+	if err := s.checkNoteOwner(ctx, ownerID, noteID); err != nil {
+		return nil, err
+	}
+	return s.aclRepo.List(ctx, ownerID, noteID, 0)
+}
+
+// ListTagGrants returns who the owner shared the tag with.
+func (s *Service) ListTagGrants(ctx context.Context, ownerID, tagID int64) ([]model.AccessGrant, error) {
+	if err := s.checkTagOwner(ctx, ownerID, tagID); err != nil {
+		return nil, err
+	}
+	return s.aclRepo.List(ctx, ownerID, 0, tagID)
+}
+
+// Revoke takes back a grant given by the owner.
+func (s *Service) Revoke(ctx context.Context, ownerID, id int64) error {
+	return s.aclRepo.Revoke(ctx, ownerID, id)
+}
+
+// grant resolves the grantee by email and saves the grant.
+func (s *Service) grant(ctx context.Context, grant *model.AccessGrant, email string) error {
+	if grant.Role != model.RoleViewer && grant.Role != model.RoleEditor {
+		return &model.ValidationError{Fields: map[string]string{"role": "must be viewer or editor"}}
+	}
+	grantee, err := s.userRepo.GetByEmail(ctx, strings.ToLower(strings.TrimSpace(email)))
+	if err != nil {
+		if errors.Is(err, model.ErrNotFound) {
+			return &model.ValidationError{Fields: map[string]string{"email": "user not found"}}
+		}
+		return err
+	}
+	if grantee.ID == grant.OwnerID {
+		return &model.ValidationError{Fields: map[string]string{"email": "can't share with yourself"}}
+	}
+	grant.GranteeID = grantee.ID
+	grant.GranteeEmail = grantee.Email
+	grant.GranteeName = grantee.Name
+	return s.aclRepo.Grant(ctx, grant)
+}
+
+// checkNoteOwner allows only the owner to manage access to a note; users it is shared with get ErrForbidden.
+// Notes of a workspace are shared through its membership, not by grants.
+func (s *Service) checkNoteOwner(ctx context.Context, userID, noteID int64) error {
+	if model.WorkspaceID(ctx) != 0 {
+		return model.ErrForbidden
+	}
+	_, role, err := s.noteRepo.Access(ctx, userID, noteID)
+	if err != nil {
+		return err
+	}
+	if role != model.RoleOwner {
+		return model.ErrForbidden
+	}
+	return nil
+}
+
+// checkTagOwner allows sharing only of the user's own personal tags.
+func (s *Service) checkTagOwner(ctx context.Context, userID, tagID int64) error {
+	if model.WorkspaceID(ctx) != 0 {
+		return model.ErrForbidden
+	}
+	tag, err := s.tagRepo.GetByID(ctx, userID, tagID)
+	if err != nil {
+		return err
+	}
+	if tag.UserID != userID {
+		return model.ErrForbidden
+	}
+	return nil
+}
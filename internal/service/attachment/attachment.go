@@ -0,0 +1,276 @@
+// Package attachment provides business logic for files attached to notes.
+// Attachments belong to personal notes: inside a workspace every call is ErrForbidden.
+package attachment
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Rasulikus/notebook/internal/model"
+	"github.com/Rasulikus/notebook/internal/repository"
+	"github.com/Rasulikus/notebook/internal/storage"
+	"github.com/Rasulikus/notebook/internal/thumbnail"
+)
+
+// orphanBatch is how many detached attachments the cleaner removes at a time.
+const orphanBatch = 100
+
+// maxNameLen limits stored file names, in runes.
+const maxNameLen = 255
+
+// allowedTypes are content types accepted for upload, as sniffed by http.DetectContentType.
+var allowedTypes = map[string]struct{}{
+	"image/png":       {},
+	"image/jpeg":      {},
+	"image/gif":       {},
+	"image/webp":      {},
+	"application/pdf": {},
+}
+
+// Limits bound uploads: MaxSize is the largest accepted file, Quota the total size of
+// all attachments of a user. Zero means no limit.
+type Limits struct {
+	MaxSize int64
+	Quota   int64
+}
+
+// Service coordinates attachment metadata in the repository with contents in the blob store.
+type Service struct {
+	attachmentRepo repository.AttachmentRepository
+	noteRepo       repository.NoteRepository
+	store          storage.BlobStore
+	limits         Limits
+	thumbSizes     []int
+}
+
+// NewService constructs the attachment service; thumbSizes are the sizes thumbnails of images are made in.
+func NewService(attachmentRepo repository.AttachmentRepository, noteRepo repository.NoteRepository, store storage.BlobStore, limits Limits, thumbSizes []int) *Service {
+	return &Service{attachmentRepo: attachmentRepo, noteRepo: noteRepo, store: store, limits: limits, thumbSizes: thumbSizes}
+}
+
+// Upload stores r as an attachment of the user's note. att carries NoteID, UserID, Name and
+// Size as declared by the client; the content type is sniffed from the content and only
+// images and PDFs are accepted. The blob is written first and removed again if saving
+// the metadata fails, e.g. on exceeded quota.
+func (s *Service) Upload(ctx context.Context, att *model.Attachment, r io.Reader) error {
+	if err := personalOnly(ctx); err != nil {
+		return err
+	}
+	if s.limits.MaxSize > 0 && att.Size > s.limits.MaxSize {
+		return model.ErrTooLarge
+	}
+	if att.Size <= 0 {
+		return &model.ValidationError{Fields: map[string]string{"file": "file is empty"}}
+	}
+	if _, err := s.noteRepo.GetByID(ctx, att.UserID, att.NoteID); err != nil {
+		return err
+	}
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return err
+	}
+	contentType, _, _ := strings.Cut(http.DetectContentType(head[:n]), ";")
+	if _, ok := allowedTypes[contentType]; !ok {
+		return model.ErrUnsupportedType
+	}
+
+	key, err := newKey(att.UserID)
+	if err != nil {
+		return err
+	}
+	hash := sha256.New()
+	body := &countingReader{r: io.TeeReader(io.MultiReader(bytes.NewReader(head[:n]), r), hash)}
+	if err := s.store.Put(ctx, key, io.LimitReader(body, att.Size+1), att.Size, contentType); err != nil {
+		return err
+	}
+	if body.n != att.Size {
+		s.deleteBlob(ctx, key)
+		return model.ErrBadRequest
+	}
+
+	att.Name = cleanName(att.Name)
+	att.ContentType = contentType
+	att.SHA256 = hex.EncodeToString(hash.Sum(nil))
+	att.StorageKey = key
+	att.ThumbStatus = model.ThumbNone
+	if thumbnail.Supported(contentType) {
+		att.ThumbStatus = model.ThumbPending
+	}
+	if err := s.attachmentRepo.Create(ctx, att, s.limits.Quota); err != nil {
+		s.deleteBlob(ctx, key)
+		return err
+	}
+	return nil
+}
+
+// ListByNote returns attachments of the user's note.
+func (s *Service) ListByNote(ctx context.Context, userID, noteID int64) ([]model.Attachment, error) {
+	if err := personalOnly(ctx); err != nil {
+		return nil, err
+	}
+	if _, err := s.noteRepo.GetByID(ctx, userID, noteID); err != nil {
+		return nil, err
+	}
+	return s.attachmentRepo.ListByNote(ctx, userID, noteID)
+}
+
+// Open returns the user's attachment with its content; the caller closes the reader.
+func (s *Service) Open(ctx context.Context, userID, id int64) (*model.Attachment, io.ReadCloser, error) {
+	if err := personalOnly(ctx); err != nil {
+		return nil, nil, err
+	}
+	att, err := s.attachmentRepo.GetByID(ctx, userID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	rc, err := s.store.Get(ctx, att.StorageKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return att, rc, nil
+}
+
+// DeleteByID removes the user's attachment. The blob is deleted right away; if that fails,
+// the cleaner retries it later.
+func (s *Service) DeleteByID(ctx context.Context, userID, id int64) error {
+	if err := personalOnly(ctx); err != nil {
+		return err
+	}
+	att, err := s.attachmentRepo.DeleteByID(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	if _, err := s.removeOrphans(ctx, []model.Attachment{*att}); err != nil {
+		log.Printf("remove attachment %d: %v", att.ID, err)
+	}
+	return nil
+}
+
+// RunCleaner removes blobs of attachments left without a note — deleted ones and those of
+// notes purged from trash — every interval until ctx is done. Meant to run in its own goroutine.
+func (s *Service) RunCleaner(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		removed, err := s.CleanOrphans(ctx)
+		if err != nil {
+			log.Printf("clean attachments: %v", err)
+		} else if removed > 0 {
+			log.Printf("clean attachments: removed %d file(s)", removed)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CleanOrphans removes all detached attachments with their blobs. Returns how many were removed.
+func (s *Service) CleanOrphans(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		orphans, err := s.attachmentRepo.ListOrphans(ctx, orphanBatch)
+		if err != nil {
+			return total, err
+		}
+		removed, err := s.removeOrphans(ctx, orphans)
+		total += removed
+		if err != nil {
+			return total, err
+		}
+		if len(orphans) < orphanBatch {
+			return total, nil
+		}
+	}
+}
+
+// removeOrphans deletes blobs of detached attachments and their thumbnails and then their rows.
+// Rows whose blob could not be deleted are kept for the next run.
+func (s *Service) removeOrphans(ctx context.Context, orphans []model.Attachment) (int, error) {
+	ids := make([]int64, 0, len(orphans))
+	var blobErr error
+	for _, att := range orphans {
+		keys := []string{att.StorageKey}
+		for _, thumb := range att.Thumbs {
+			keys = append(keys, thumb.StorageKey)
+		}
+		removed := true
+		for _, key := range keys {
+			if err := s.store.Delete(ctx, key); err != nil {
+				blobErr = err
+				removed = false
+			}
+		}
+		if removed {
+			ids = append(ids, att.ID)
+		}
+	}
+	if err := s.attachmentRepo.DeleteOrphans(ctx, ids); err != nil {
+		return 0, err
+	}
+	return len(ids), blobErr
+}
+
+// deleteBlob removes a blob that ended up without metadata; failures are only logged.
+func (s *Service) deleteBlob(ctx context.Context, key string) {
+	if err := s.store.Delete(ctx, key); err != nil {
+		log.Printf("delete blob %s: %v", key, err)
+	}
+}
+
+// newKey returns a fresh random blob key, grouped by user.
+func newKey(userID int64) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d/%s", userID, hex.EncodeToString(b)), nil
+}
+
+// cleanName keeps only the base name of an uploaded file, trimmed to maxNameLen runes.
+func cleanName(name string) string {
+	name = strings.TrimSpace(filepath.Base(strings.ReplaceAll(name, `\`, "/")))
+	if name == "." || name == "/" || name == "" {
+		return "file"
+	}
+	if utf8.RuneCountInString(name) > maxNameLen {
+		name = string([]rune(name)[:maxNameLen])
+	}
+	return name
+}
+
+// countingReader counts bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// personalOnly refuses work with attachments in a workspace scope.
+func personalOnly(ctx context.Context) error {
+	if model.WorkspaceID(ctx) != 0 {
+		return model.ErrForbidden
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package attachment
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Rasulikus/notebook/internal/model"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Service_WorkspaceScope(t *testing.T) {
+	// Calls are refused before any repository or the blob store is touched.
+	s := NewService(nil, nil, nil, Limits{}, []int{128})
+	ctx := model.WithWorkspace(context.Background(), &model.WorkspaceMembership{WorkspaceID: 1, UserID: 1, Role: model.WorkspaceOwner})
+
+	att := &model.Attachment{NoteID: 1, UserID: 1, Name: "a.png", Size: 1}
+	require.ErrorIs(t, s.Upload(ctx, att, strings.NewReader("x")), model.ErrForbidden)
+	_, err := s.ListByNote(ctx, 1, 1)
+	require.ErrorIs(t, err, model.ErrForbidden)
+	_, _, err = s.Open(ctx, 1, 1)
+	require.ErrorIs(t, err, model.ErrForbidden)
+	require.ErrorIs(t, s.DeleteByID(ctx, 1, 1), model.ErrForbidden)
+	_, err = s.Thumbnail(ctx, 1, 1, 128)
+	require.ErrorIs(t, err, model.ErrForbidden)
+}
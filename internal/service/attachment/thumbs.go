@@ -0,0 +1,161 @@
+package attachment
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Rasulikus/notebook/internal/model"
+	"github.com/Rasulikus/notebook/internal/thumbnail"
+)
+
+// thumbBatch is how many pending attachments the thumbnailer takes at a time.
+const thumbBatch = 20
+
+// maxThumbAttempts is how many runs of the thumbnailer may fail on an attachment
+// before it is marked failed and left alone.
+const maxThumbAttempts = 5
+
+// Thumbnail returns the thumbnail of the user's attachment in one of the configured sizes.
+// A thumbnail the worker hasn't made yet is made right away.
+func (s *Service) Thumbnail(ctx context.Context, userID, id int64, size int) (*model.AttachmentThumb, error) {
+	if err := personalOnly(ctx); err != nil {
+		return nil, err
+	}
+	if !slices.Contains(s.thumbSizes, size) {
+		return nil, &model.ValidationError{Fields: map[string]string{"size": "must be one of " + joinSizes(s.thumbSizes)}}
+	}
+	att, err := s.attachmentRepo.GetByID(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if att.ThumbStatus == model.ThumbPending {
+		if err := s.makeThumbs(ctx, att); err != nil {
+			return nil, err
+		}
+	}
+	return s.attachmentRepo.GetThumb(ctx, att.ID, size)
+}
+
+// OpenThumbnail returns the content of a thumbnail; the caller closes the reader.
+func (s *Service) OpenThumbnail(ctx context.Context, thumb *model.AttachmentThumb) (io.ReadCloser, error) {
+	return s.store.Get(ctx, thumb.StorageKey)
+}
+
+// RunThumbnailer makes thumbnails of newly uploaded images every interval until ctx is done.
+// Meant to run in its own goroutine.
+func (s *Service) RunThumbnailer(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		made, err := s.MakePendingThumbs(ctx)
+		if err != nil {
+			log.Printf("make thumbnails: %v", err)
+		} else if made > 0 {
+			log.Printf("make thumbnails: processed %d attachment(s)", made)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// MakePendingThumbs makes thumbnails of all attachments waiting for them, trying each once.
+// An attachment that fails is logged and skipped; after maxThumbAttempts failed runs it is
+// marked failed. Returns how many attachments were processed.
+func (s *Service) MakePendingThumbs(ctx context.Context) (int, error) {
+	total := 0
+	var afterID int64
+	for {
+		pending, err := s.attachmentRepo.ListPendingThumbs(ctx, afterID, thumbBatch)
+		if err != nil {
+			return total, err
+		}
+		for i := range pending {
+			att := &pending[i]
+			afterID = att.ID
+			if err := s.makeThumbs(ctx, att); err != nil {
+				if ctx.Err() != nil {
+					return total, ctx.Err()
+				}
+				log.Printf("thumbnail of attachment %d: %v", att.ID, err)
+				failed, err := s.attachmentRepo.FailThumbs(ctx, att.ID, maxThumbAttempts)
+				if err != nil {
+					return total, err
+				}
+				if failed {
+					log.Printf("thumbnail of attachment %d: giving up after %d attempts", att.ID, maxThumbAttempts)
+				}
+				continue
+			}
+			total++
+		}
+		if len(pending) < thumbBatch {
+			return total, nil
+		}
+	}
+}
+
+// makeThumbs makes thumbnails of the attachment in all configured sizes and stores them.
+// An image that can't be decoded or whose blob is gone marks the attachment failed,
+// so it is not retried; other storage errors are returned and the attachment stays pending.
+func (s *Service) makeThumbs(ctx context.Context, att *model.Attachment) error {
+	rc, err := s.store.Get(ctx, att.StorageKey)
+	if errors.Is(err, model.ErrNotFound) {
+		log.Printf("thumbnail of attachment %d: blob %s is missing", att.ID, att.StorageKey)
+		return s.attachmentRepo.SaveThumbs(ctx, att.ID, nil, model.ThumbFailed)
+	}
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		return err
+	}
+	thumbs, err := thumbnail.Generate(data, s.thumbSizes)
+	if err != nil {
+		log.Printf("thumbnail of attachment %d: %v", att.ID, err)
+		return s.attachmentRepo.SaveThumbs(ctx, att.ID, nil, model.ThumbFailed)
+	}
+
+	saved := make([]*model.AttachmentThumb, 0, len(thumbs))
+	for _, t := range thumbs {
+		sum := sha256.Sum256(t.Data)
+		thumb := &model.AttachmentThumb{
+			AttachmentID: att.ID,
+			Size:         t.Size,
+			ContentType:  t.ContentType,
+			ByteSize:     int64(len(t.Data)),
+			SHA256:       hex.EncodeToString(sum[:]),
+			StorageKey:   fmt.Sprintf("%s_thumb%d", att.StorageKey, t.Size),
+		}
+		err := s.store.Put(ctx, thumb.StorageKey, bytes.NewReader(t.Data), thumb.ByteSize, thumb.ContentType)
+		if err != nil {
+			return err
+		}
+		saved = append(saved, thumb)
+	}
+	return s.attachmentRepo.SaveThumbs(ctx, att.ID, saved, model.ThumbReady)
+}
+
+// joinSizes lists sizes for error messages.
+func joinSizes(sizes []int) string {
+	parts := make([]string, len(sizes))
+	for i, size := range sizes {
+		parts[i] = strconv.Itoa(size)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -88,3 +88,15 @@ func (s *Service) Logout(ctx context.Context, refreshToken string) error {
 	refreshTokenHash := s.tokenManager.generateRefreshTokenHash(refreshToken)
 	return s.tokenManager.SessionRepo.SetRevokedAtNow(ctx, refreshTokenHash)
 }
+
+// IsAdmin reports whether the user has administrator rights.
+func (s *Service) IsAdmin(ctx context.Context, userID int64) (bool, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, model.ErrNotFound) {
+			return false, model.ErrUnauthorized
+		}
+		return false, err
+	}
+	return user.IsAdmin, nil
+}
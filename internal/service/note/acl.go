@@ -0,0 +1,40 @@
+package note
+
+import (
+	"context"
+
+	"github.com/Rasulikus/notebook/internal/model"
+	"github.com/Rasulikus/notebook/internal/service"
+)
+
+// access checks that the user may work with the note in the need role and returns the owner,
+// whom the note is addressed by in the repository, and the role the user actually has.
+// Notes the user can't see at all are ErrNotFound; a weaker role is ErrForbidden.
+func (s *Service) access(ctx context.Context, userID, noteID int64, need model.Role) (int64, model.Role, error) {
+	ownerID, role, err := s.noteRepo.Access(ctx, userID, noteID)
+	if err != nil {
+		return 0, "", err
+	}
+	if !role.Allows(need) {
+		return 0, "", model.ErrForbidden
+	}
+	return ownerID, role, nil
+}
+
+// organizes reports whether the update touches anything but the title and the text:
+// tags, notebook and flags organize the owner's notes and are left to the owner.
+func organizes(req *service.UpdateByIDNoteReq) bool {
+	return req.TagsIDs != nil || req.TagNames != nil || req.Pinned != nil || req.Archived != nil ||
+		req.Favorite != nil || req.NotebookID != nil || req.AutoTags != nil
+}
+
+// ListSharedWithMe returns notes other users shared with the user, recently updated first.
+func (s *Service) ListSharedWithMe(ctx context.Context, userID int64, limit, offset int) ([]model.SharedNote, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return s.noteRepo.ListSharedWith(ctx, userID, limit, offset)
+}
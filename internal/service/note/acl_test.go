@@ -0,0 +1,94 @@
+package note
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Rasulikus/notebook/internal/model"
+	"github.com/Rasulikus/notebook/internal/repository"
+	"github.com/Rasulikus/notebook/internal/service"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeNoteRepo serves one note of ownerID to a user with role and records updates of it.
+// Methods the tests don't use panic through the nil embedded interface.
+type fakeNoteRepo struct {
+	repository.NoteRepository
+	ownerID int64
+	role    model.Role
+	note    *model.Note
+	updates []*model.NoteUpdate
+}
+
+func (r *fakeNoteRepo) Access(context.Context, int64, int64) (int64, model.Role, error) {
+	return r.ownerID, r.role, nil
+}
+
+func (r *fakeNoteRepo) GetByID(context.Context, int64, int64) (*model.Note, error) {
+	return r.note, nil
+}
+
+func (r *fakeNoteRepo) GetRevision(_ context.Context, _, noteID int64, rev int) (*model.NoteRevision, error) {
+	return &model.NoteRevision{NoteID: noteID, Rev: rev, Title: "old title", Text: "old #text", TagIDs: []int64{7}}, nil
+}
+
+func (r *fakeNoteRepo) UpdateByID(_ context.Context, _, _ int64, upd *model.NoteUpdate) (*model.Note, error) {
+	r.updates = append(r.updates, upd)
+	return r.note, nil
+}
+
+type fakeTagRepo struct {
+	repository.TagRepository
+}
+
+func (fakeTagRepo) GetByID(_ context.Context, userID, id int64) (*model.Tag, error) {
+	return &model.Tag{ID: id, UserID: userID}, nil
+}
+
+func Test_Service_RestoreRevision_Roles(t *testing.T) {
+	cases := []struct {
+		role     model.Role
+		wantTags *[]int64
+		wantKeep bool
+	}{
+		{role: model.RoleOwner, wantTags: &[]int64{7}},
+		{role: model.RoleEditor, wantKeep: true},
+	}
+	for _, tc := range cases {
+		t.Run(string(tc.role), func(t *testing.T) {
+			notes := &fakeNoteRepo{ownerID: 1, role: tc.role, note: &model.Note{ID: 10, UserID: 1}}
+			s := NewService(notes, fakeTagRepo{}, nil)
+
+			_, err := s.RestoreRevision(context.Background(), 2, 10, 3)
+			require.NoError(t, err)
+			require.Len(t, notes.updates, 1)
+			upd := notes.updates[0]
+			require.Equal(t, "old title", *upd.Title)
+			require.Equal(t, "old #text", *upd.Text)
+			require.Equal(t, tc.wantTags, upd.TagIDs, "only the owner restores tags")
+			require.Equal(t, tc.wantKeep, upd.KeepAutoTags)
+		})
+	}
+
+	notes := &fakeNoteRepo{ownerID: 1, role: model.RoleViewer}
+	_, err := NewService(notes, fakeTagRepo{}, nil).RestoreRevision(context.Background(), 2, 10, 3)
+	require.ErrorIs(t, err, model.ErrForbidden)
+	require.Empty(t, notes.updates)
+}
+
+func Test_Service_SetTask_Roles(t *testing.T) {
+	for _, role := range []model.Role{model.RoleOwner, model.RoleEditor} {
+		t.Run(string(role), func(t *testing.T) {
+			notes := &fakeNoteRepo{ownerID: 1, role: role, note: &model.Note{ID: 10, UserID: 1, Text: "- [ ] #todo item", Version: 4}}
+			s := NewService(notes, fakeTagRepo{}, nil)
+
+			_, _, err := s.SetTask(context.Background(), 2, 10, &service.SetTaskReq{Index: 0})
+			require.NoError(t, err)
+			require.Len(t, notes.updates, 1)
+			upd := notes.updates[0]
+			require.Equal(t, "- [x] #todo item", *upd.Text)
+			require.Equal(t, role != model.RoleOwner, upd.KeepAutoTags, "an editor's toggle leaves auto tags alone")
+			require.Nil(t, upd.TagIDs)
+		})
+	}
+}
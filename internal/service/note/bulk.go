@@ -0,0 +1,40 @@
+package note
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/Rasulikus/notebook/internal/model"
+	"github.com/Rasulikus/notebook/internal/service"
+)
+
+// UpdateTags adds and removes tags on many notes at once; all notes and tags must belong to the user.
+func (s *Service) UpdateTags(ctx context.Context, userID int64, req *service.UpdateTagsNoteReq) ([]model.Note, error) {
+	fields := map[string]string{}
+	if len(req.NoteIDs) == 0 {
+		fields["note_ids"] = "required field"
+	}
+	if len(req.Add) == 0 && len(req.Remove) == 0 {
+		fields["add"] = "add or remove is required"
+	}
+	for _, id := range req.Add {
+		if slices.Contains(req.Remove, id) {
+			fields["remove"] = fmt.Sprintf("tag %d is both added and removed", id)
+			break
+		}
+	}
+	if len(fields) > 0 {
+		return nil, &model.ValidationError{Fields: fields}
+	}
+
+	tagIDs := append(slices.Clone(req.Add), req.Remove...)
+	if _, err := s.tagRepo.GetByIDs(ctx, userID, tagIDs); err != nil {
+		if errors.Is(err, model.ErrNotFound) {
+			return nil, fmt.Errorf("tag not found: %w", model.ErrNotFound)
+		}
+		return nil, err
+	}
+	return s.noteRepo.UpdateTags(ctx, userID, req.NoteIDs, req.Add, req.Remove)
+}
@@ -0,0 +1,226 @@
+package note
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change.
+const diffContext = 3
+
+// maxDiffEdits bounds the work of diffLines: texts that differ in more lines than this
+// are diffed as a replacement of the whole changed part.
+const maxDiffEdits = 1000
+
+type editKind int
+
+const (
+	editEqual editKind = iota
+	editDelete
+	editInsert
+)
+
+type edit struct {
+	kind editKind
+	line string
+}
+
+// unifiedDiff renders a line diff between a and b in unified format
+// (as `diff -u` does). Returns an empty string when texts are equal.
+func unifiedDiff(fromName, toName, a, b string) string {
+	edits := diffLines(splitLines(a), splitLines(b))
+
+	// aPos[i], bPos[i] - 0-based line numbers in a and b before edit i
+	aPos := make([]int, len(edits)+1)
+	bPos := make([]int, len(edits)+1)
+	for i, e := range edits {
+		aPos[i+1], bPos[i+1] = aPos[i], bPos[i]
+		if e.kind != editInsert {
+			aPos[i+1]++
+		}
+		if e.kind != editDelete {
+			bPos[i+1]++
+		}
+	}
+
+	var sb strings.Builder
+	for start := 0; start < len(edits); {
+		for start < len(edits) && edits[start].kind == editEqual {
+			start++
+		}
+		if start == len(edits) {
+			break
+		}
+		// extend the hunk while the next change is close enough to share context
+		end := start
+		for {
+			for end < len(edits) && edits[end].kind != editEqual {
+				end++
+			}
+			next := end
+			for next < len(edits) && edits[next].kind == editEqual {
+				next++
+			}
+			if next == len(edits) || next-end > 2*diffContext {
+				break
+			}
+			end = next
+		}
+
+		lo, hi := max(start-diffContext, 0), min(end+diffContext, len(edits))
+		if sb.Len() == 0 {
+			fmt.Fprintf(&sb, "--- %s\n+++ %s\n", fromName, toName)
+		}
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(aPos[lo], aPos[hi]-aPos[lo]), hunkRange(bPos[lo], bPos[hi]-bPos[lo]))
+		for _, e := range edits[lo:hi] {
+			switch e.kind {
+			case editEqual:
+				sb.WriteByte(' ')
+			case editDelete:
+				sb.WriteByte('-')
+			case editInsert:
+				sb.WriteByte('+')
+			}
+			sb.WriteString(e.line)
+			if !strings.HasSuffix(e.line, "\n") {
+				sb.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		start = hi
+	}
+	return sb.String()
+}
+
+// hunkRange formats a hunk range; start is 0-based.
+func hunkRange(start, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	default:
+		return fmt.Sprintf("%d,%d", start+1, count)
+	}
+}
+
+// splitLines splits s into lines keeping their "\n", so a missing newline
+// at the end of the text is a change of the last line.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines computes the shortest edit script turning a into b (Myers' algorithm).
+// The common prefix and suffix are matched up front; if the rest needs more than
+// maxDiffEdits edits, it is replaced as a whole instead.
+func diffLines(a, b []string) []edit {
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+
+	edits := make([]edit, 0, len(a)+len(b)-pre-suf)
+	for _, line := range a[:pre] {
+		edits = append(edits, edit{kind: editEqual, line: line})
+	}
+	edits = append(edits, myers(a[pre:len(a)-suf], b[pre:len(b)-suf])...)
+	for _, line := range a[len(a)-suf:] {
+		edits = append(edits, edit{kind: editEqual, line: line})
+	}
+	return edits
+}
+
+// myers returns the shortest edit script turning a into b, or deletes all of a and inserts all
+// of b when that takes more than maxDiffEdits edits. The trace keeps only the diagonals reached
+// at each step, so memory is bounded by maxDiffEdits² rather than by the text size.
+func myers(a, b []string) []edit {
+	n, m := len(a), len(b)
+	offset := n + m
+	v := make([]int, 2*offset+2)
+	// trace[d][i] is v[offset-d+i] before step d
+	var trace [][]int
+
+search:
+	for d := 0; d <= n+m; d++ {
+		if d > maxDiffEdits {
+			return replaceAll(a, b)
+		}
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				break search
+			}
+		}
+	}
+
+	// walk the trace back from (n, m) to (0, 0)
+	var edits []edit
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		// v at diagonal k before step d; step d reads only diagonals -(d-1)..d-1 of it
+		at := func(k int) int { return trace[d][k+d] }
+		k := x - y
+		var prevK, prevX int
+		if d == 0 {
+			prevK, prevX = 0, 0
+		} else {
+			prevK = k - 1
+			if k == -d || (k != d && at(k-1) < at(k+1)) {
+				prevK = k + 1
+			}
+			prevX = at(prevK)
+		}
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			edits = append(edits, edit{kind: editEqual, line: a[x-1]})
+			x--
+			y--
+		}
+		if d > 0 {
+			if x == prevX {
+				edits = append(edits, edit{kind: editInsert, line: b[y-1]})
+			} else {
+				edits = append(edits, edit{kind: editDelete, line: a[x-1]})
+			}
+		}
+		x, y = prevX, prevY
+	}
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+	return edits
+}
+
+// replaceAll is the edit script deleting every line of a and inserting every line of b.
+func replaceAll(a, b []string) []edit {
+	edits := make([]edit, 0, len(a)+len(b))
+	for _, line := range a {
+		edits = append(edits, edit{kind: editDelete, line: line})
+	}
+	for _, line := range b {
+		edits = append(edits, edit{kind: editInsert, line: line})
+	}
+	return edits
+}
@@ -0,0 +1,136 @@
+package note
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_unifiedDiff(t *testing.T) {
+	cases := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{
+			name: "equal",
+			a:    "one\ntwo\n",
+			b:    "one\ntwo\n",
+			want: "",
+		},
+		{
+			name: "both empty",
+			want: "",
+		},
+		{
+			name: "from empty",
+			b:    "one\ntwo\n",
+			want: "--- a\n+++ b\n@@ -0,0 +1,2 @@\n+one\n+two\n",
+		},
+		{
+			name: "to empty",
+			a:    "one\n",
+			want: "--- a\n+++ b\n@@ -1 +0,0 @@\n-one\n",
+		},
+		{
+			name: "insert only",
+			a:    "one\nthree\n",
+			b:    "one\ntwo\nthree\n",
+			want: "--- a\n+++ b\n@@ -1,2 +1,3 @@\n one\n+two\n three\n",
+		},
+		{
+			name: "delete only",
+			a:    "one\ntwo\nthree\n",
+			b:    "one\nthree\n",
+			want: "--- a\n+++ b\n@@ -1,3 +1,2 @@\n one\n-two\n three\n",
+		},
+		{
+			name: "change",
+			a:    "one\ntwo\nthree\n",
+			b:    "one\n2\nthree\n",
+			want: "--- a\n+++ b\n@@ -1,3 +1,3 @@\n one\n-two\n+2\n three\n",
+		},
+		{
+			name: "no trailing newline added",
+			a:    "one\ntwo",
+			b:    "one\ntwo\n",
+			want: "--- a\n+++ b\n@@ -1,2 +1,2 @@\n one\n-two\n\\ No newline at end of file\n+two\n",
+		},
+		{
+			name: "no trailing newline on both sides",
+			a:    "one",
+			b:    "two",
+			want: "--- a\n+++ b\n@@ -1 +1 @@\n-one\n\\ No newline at end of file\n+two\n\\ No newline at end of file\n",
+		},
+		{
+			name: "separate hunks",
+			a:    "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+			b:    "0\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n13\n",
+			want: "--- a\n+++ b\n@@ -1,4 +1,4 @@\n-1\n+0\n 2\n 3\n 4\n@@ -9,4 +9,4 @@\n 9\n 10\n 11\n-12\n+13\n",
+		},
+		{
+			name: "close changes share a hunk",
+			a:    "1\n2\n3\n4\n5\n6\n7\n8\n",
+			b:    "0\n2\n3\n4\n5\n6\n7\n9\n",
+			want: "--- a\n+++ b\n@@ -1,8 +1,8 @@\n-1\n+0\n 2\n 3\n 4\n 5\n 6\n 7\n-8\n+9\n",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, unifiedDiff("a", "b", tc.a, tc.b))
+		})
+	}
+}
+
+func Test_diffLines(t *testing.T) {
+	cases := []struct {
+		name string
+		a, b []string
+	}{
+		{"empty", nil, nil},
+		{"insert only", nil, []string{"a\n", "b\n"}},
+		{"delete only", []string{"a\n", "b\n"}, nil},
+		{"reorder", []string{"a\n", "b\n", "c\n"}, []string{"c\n", "a\n", "b\n"}},
+		{"interleaved", []string{"a\n", "x\n", "b\n", "y\n"}, []string{"x\n", "a\n", "y\n", "b\n"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			edits := diffLines(tc.a, tc.b)
+			from, to := apply(edits)
+			require.Equal(t, tc.a, from)
+			require.Equal(t, tc.b, to)
+		})
+	}
+}
+
+func Test_diffLines_Limit(t *testing.T) {
+	n := 3 * maxDiffEdits
+	a := make([]string, n)
+	b := make([]string, n)
+	for i := range n {
+		a[i] = strings.Repeat("a", i%7+1) + "\n"
+		b[i] = strings.Repeat("b", i%5+1) + "\n"
+	}
+	a[0], b[0] = "same\n", "same\n"
+
+	edits := diffLines(a, b)
+	from, to := apply(edits)
+	require.Equal(t, a, from)
+	require.Equal(t, b, to)
+	require.Equal(t, editEqual, edits[0].kind, "the common prefix is kept")
+	require.Len(t, edits, 2*n-1, "the rest is replaced as a whole")
+}
+
+// apply returns the old and the new text of an edit script.
+func apply(edits []edit) (from, to []string) {
+	for _, e := range edits {
+		if e.kind != editInsert {
+			from = append(from, e.line)
+		}
+		if e.kind != editDelete {
+			to = append(to, e.line)
+		}
+	}
+	return from, to
+}
@@ -0,0 +1,28 @@
+package note
+
+import (
+	"context"
+
+	"github.com/Rasulikus/notebook/internal/model"
+)
+
+// Graph returns user's notes with their tags and links between them, narrowed by tags
+// and notebook of the filter. Archived notes are included unless the filter says otherwise.
+func (s *Service) Graph(ctx context.Context, userID int64, filter *model.NoteFilter) (*model.NoteGraph, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = 500
+	}
+	filter.Limit = min(filter.Limit, 2000)
+	if filter.TagMode == "" {
+		filter.TagMode = model.TagMatchAny
+	}
+	if err := validateNoteFilter(filter); err != nil {
+		return nil, err
+	}
+	if filter.NotebookID != 0 {
+		if _, err := s.notebookRepo.GetByID(ctx, userID, filter.NotebookID); err != nil {
+			return nil, err
+		}
+	}
+	return s.noteRepo.Graph(ctx, userID, filter)
+}
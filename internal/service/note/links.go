@@ -0,0 +1,24 @@
+package note
+
+import (
+	"context"
+
+	"github.com/Rasulikus/notebook/internal/model"
+)
+
+// ListLinks returns [[...]] links from the user's note, dangling ones included.
+// Links show titles of the owner's other notes, so users the note is shared with get ErrForbidden.
+func (s *Service) ListLinks(ctx context.Context, userID, noteID int64) ([]model.NoteLink, error) {
+	if _, _, err := s.access(ctx, userID, noteID, model.RoleOwner); err != nil {
+		return nil, err
+	}
+	return s.noteRepo.ListLinks(ctx, userID, noteID)
+}
+
+// ListBacklinks returns links from other notes of the user to the note; owner only, as ListLinks.
+func (s *Service) ListBacklinks(ctx context.Context, userID, noteID int64) ([]model.NoteLink, error) {
+	if _, _, err := s.access(ctx, userID, noteID, model.RoleOwner); err != nil {
+		return nil, err
+	}
+	return s.noteRepo.ListBacklinks(ctx, userID, noteID)
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/Rasulikus/notebook/internal/model"
 	"github.com/Rasulikus/notebook/internal/repository"
@@ -13,17 +14,27 @@ import (
 
 // Package note provides business logic for notes.
 type Service struct {
-	noteRepo repository.NoteRepository
-	tagRepo  repository.TagRepository
+	noteRepo     repository.NoteRepository
+	tagRepo      repository.TagRepository
+	notebookRepo repository.NotebookRepository
 }
 
 // Package note provides business logic for notes.
-func NewService(noteRepo repository.NoteRepository, tagRepo repository.TagRepository) *Service {
-	return &Service{noteRepo: noteRepo, tagRepo: tagRepo}
+func NewService(noteRepo repository.NoteRepository, tagRepo repository.TagRepository, notebookRepo repository.NotebookRepository) *Service {
+	return &Service{noteRepo: noteRepo, tagRepo: tagRepo, notebookRepo: notebookRepo}
 }
 
-// Create builds a note and attaches tags by IDs.
-func (s *Service) Create(ctx context.Context, n *model.Note, tagsIDs []int64) (*model.Note, error) {
+// Create builds a note and attaches tags by IDs and by names; tags missing by name are created.
+// With n.AutoTags hashtags of the text become auto tags that follow the text on later updates;
+// hashtags that are not valid tag names (model.CheckTagName, e.g. too short "#go") are skipped.
+func (s *Service) Create(ctx context.Context, n *model.Note, tagsIDs []int64, tagNames []string) (*model.Note, error) {
+	if err := validateTagNames(tagNames); err != nil {
+		return nil, err
+	}
+	n.WorkspaceID = model.WorkspaceID(ctx)
+	if err := s.checkNotebook(ctx, n.UserID, n.NotebookID); err != nil {
+		return nil, err
+	}
 	for _, tagID := range tagsIDs {
 		tag, err := s.tagRepo.GetByID(ctx, n.UserID, tagID)
 		if err != nil {
@@ -32,32 +43,112 @@ func (s *Service) Create(ctx context.Context, n *model.Note, tagsIDs []int64) (*
 		}
 		n.Tags = append(n.Tags, tag)
 	}
+	for _, name := range tagNames {
+		n.Tags = append(n.Tags, &model.Tag{Name: name})
+	}
 	return s.noteRepo.Create(ctx, n, n.Tags)
 }
 
-// List returns user notes with sane defaults.
-func (s *Service) List(ctx context.Context, userID int64, limit, offset int, order string) ([]model.Note, error) {
+// List returns user notes matching the filter with sane defaults:
+// archived notes are hidden and pinned notes go first unless asked otherwise.
+func (s *Service) List(ctx context.Context, userID int64, filter *model.NoteFilter) (*model.Page[model.Note], error) {
+	if filter.Limit <= 0 || filter.Limit > 100 {
+		filter.Limit = 20
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+	if len(filter.Sort) == 0 {
+		filter.Sort = model.Sort{{Name: "pinned", Desc: true}, {Name: "created_at"}}
+	}
+	if filter.Archived == nil {
+		archived := false
+		filter.Archived = &archived
+	}
+	if filter.TagMode == "" {
+		filter.TagMode = model.TagMatchAny
+	}
+	if err := validateNoteFilter(filter); err != nil {
+		return nil, err
+	}
+	if filter.NotebookID != 0 {
+		if _, err := s.notebookRepo.GetByID(ctx, userID, filter.NotebookID); err != nil {
+			return nil, err
+		}
+	}
+	return s.noteRepo.List(ctx, userID, filter)
+}
+
+// validateNoteFilter rejects filters that can never match anything.
+func validateNoteFilter(filter *model.NoteFilter) error {
+	fields := map[string]string{}
+	if filter.TagMode != model.TagMatchAny && filter.TagMode != model.TagMatchAll {
+		fields["tag_mode"] = "must be one of: any, all"
+	}
+	if !filter.CreatedAfter.IsZero() && !filter.CreatedBefore.IsZero() && !filter.CreatedAfter.Before(filter.CreatedBefore) {
+		fields["created_before"] = "must be after created_after"
+	}
+	if !filter.UpdatedAfter.IsZero() && !filter.UpdatedBefore.IsZero() && !filter.UpdatedAfter.Before(filter.UpdatedBefore) {
+		fields["updated_before"] = "must be after updated_after"
+	}
+	if filter.Recursive && filter.NotebookID == 0 {
+		fields["recursive"] = "requires notebook_id"
+	}
+	if len(fields) > 0 {
+		return &model.ValidationError{Fields: fields}
+	}
+	return nil
+}
+
+// Search runs full-text search over user notes with the same paging defaults as List.
+func (s *Service) Search(ctx context.Context, userID int64, query string, limit, offset int) ([]model.NoteSearchHit, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, &model.ValidationError{Fields: map[string]string{"q": "required field"}}
+	}
 	if limit <= 0 || limit > 100 {
 		limit = 20
 	}
 	if offset < 0 {
 		offset = 0
 	}
-	if order == "" {
-		order = "created_at"
-	}
-	return s.noteRepo.List(ctx, userID, limit, offset, order)
+	return s.noteRepo.Search(ctx, userID, query, limit, offset)
 }
 
-// GetByID returns a single note owned by the user.
+// GetByID returns a single note owned by or shared with the user.
 func (s *Service) GetByID(ctx context.Context, userID, id int64) (*model.Note, error) {
-	return s.noteRepo.GetByID(ctx, userID, id)
+	ownerID, _, err := s.access(ctx, userID, id, model.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+	return s.noteRepo.GetByID(ctx, ownerID, id)
 }
 
-// UpdateByID applies partial changes and optionally replaces tags.
+// UpdateByID applies partial changes and optionally replaces manually attached tags;
+// req.AutoTags turns hashtag extraction on or off.
+// A non-zero req.IfVersion must match the current version of the note.
+// Editors of a shared note may change only its title and text, viewers nothing (ErrForbidden);
+// their edits leave auto tags of the note as they are.
 func (s *Service) UpdateByID(ctx context.Context, userID, id int64, req *service.UpdateByIDNoteReq) (*model.Note, error) {
+	ownerID, role, err := s.access(ctx, userID, id, model.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	if role != model.RoleOwner && organizes(req) {
+		return nil, model.ErrForbidden
+	}
+	if req.NotebookID != nil {
+		if err := s.checkNotebook(ctx, ownerID, *req.NotebookID); err != nil {
+			return nil, err
+		}
+	}
+	if req.TagNames != nil {
+		if err := validateTagNames(*req.TagNames); err != nil {
+			return nil, err
+		}
+	}
 	if req.TagsIDs != nil {
-		_, err := s.tagRepo.GetByIDs(ctx, userID, *req.TagsIDs)
+		_, err := s.tagRepo.GetByIDs(ctx, ownerID, *req.TagsIDs)
 		if err != nil {
 			if errors.Is(err, model.ErrNotFound) {
 				return nil, fmt.Errorf("tag not found: %w", model.ErrNotFound)
@@ -66,14 +157,59 @@ func (s *Service) UpdateByID(ctx context.Context, userID, id int64, req *service
 		}
 	}
 
-	note, err := s.noteRepo.UpdateByID(ctx, userID, id, req.Title, req.Text, req.TagsIDs)
+	upd := &model.NoteUpdate{
+		Title:        req.Title,
+		Text:         req.Text,
+		TagIDs:       req.TagsIDs,
+		TagNames:     req.TagNames,
+		Pinned:       req.Pinned,
+		Archived:     req.Archived,
+		Favorite:     req.Favorite,
+		NotebookID:   req.NotebookID,
+		AutoTags:     req.AutoTags,
+		KeepAutoTags: role != model.RoleOwner,
+		IfVersion:    req.IfVersion,
+	}
+	note, err := s.noteRepo.UpdateByID(ctx, ownerID, id, upd)
 	if err != nil {
 		return nil, err
 	}
 	return note, nil
 }
 
-// DeleteByID removes a note owned by the user.
-func (s *Service) DeleteByID(ctx context.Context, userID, id int64) error {
-	return s.noteRepo.DeleteByID(ctx, userID, id)
+// validateTagNames checks tag paths given by name: every level must be a non-empty name.
+func validateTagNames(names []string) error {
+	for _, name := range names {
+		for _, part := range strings.Split(strings.TrimSpace(name), model.TagPathSep) {
+			if strings.TrimSpace(part) == "" {
+				return &model.ValidationError{Fields: map[string]string{"tag_names": fmt.Sprintf("invalid tag name %q", name)}}
+			}
+		}
+	}
+	return nil
+}
+
+// checkNotebook makes sure a notebook the note is put into belongs to the user; 0 means no notebook.
+// Notebooks are personal, so notes of a workspace stay out of them.
+func (s *Service) checkNotebook(ctx context.Context, userID, notebookID int64) error {
+	if notebookID == 0 {
+		return nil
+	}
+	if model.WorkspaceID(ctx) != 0 {
+		return &model.ValidationError{Fields: map[string]string{"notebook_id": "notes of a workspace can't be put in a notebook"}}
+	}
+	_, err := s.notebookRepo.GetByID(ctx, userID, notebookID)
+	if errors.Is(err, model.ErrNotFound) {
+		return &model.ValidationError{Fields: map[string]string{"notebook_id": "notebook not found"}}
+	}
+	return err
+}
+
+// DeleteByID moves a note owned by the user to trash; users it is shared with get ErrForbidden.
+// A non-zero ifVersion must match the current version of the note.
+func (s *Service) DeleteByID(ctx context.Context, userID, id, ifVersion int64) error {
+	if _, _, err := s.access(ctx, userID, id, model.RoleOwner); err != nil {
+		return err
+	}
+	return s.noteRepo.DeleteByID(ctx, userID, id, ifVersion)
 }
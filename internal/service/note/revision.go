@@ -0,0 +1,87 @@
+package note
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Rasulikus/notebook/internal/model"
+)
+
+// ListRevisions returns revisions of a note owned by or shared with the user, newest first.
+func (s *Service) ListRevisions(ctx context.Context, userID, noteID int64) ([]model.NoteRevision, error) {
+	ownerID, _, err := s.access(ctx, userID, noteID, model.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+	return s.noteRepo.ListRevisions(ctx, ownerID, noteID)
+}
+
+// GetRevision returns a single revision of a note owned by or shared with the user.
+func (s *Service) GetRevision(ctx context.Context, userID, noteID int64, rev int) (*model.NoteRevision, error) {
+	ownerID, _, err := s.access(ctx, userID, noteID, model.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+	return s.noteRepo.GetRevision(ctx, ownerID, noteID, rev)
+}
+
+// DiffRevision returns a unified line diff of the note text from revision rev
+// to revision against, or to the current text when against is 0.
+func (s *Service) DiffRevision(ctx context.Context, userID, noteID int64, rev, against int) (string, error) {
+	ownerID, _, err := s.access(ctx, userID, noteID, model.RoleViewer)
+	if err != nil {
+		return "", err
+	}
+	from, err := s.noteRepo.GetRevision(ctx, ownerID, noteID, rev)
+	if err != nil {
+		return "", err
+	}
+	if against == 0 {
+		note, err := s.noteRepo.GetByID(ctx, ownerID, noteID)
+		if err != nil {
+			return "", err
+		}
+		return unifiedDiff(revisionName(rev), "current", from.Text, note.Text), nil
+	}
+	to, err := s.noteRepo.GetRevision(ctx, ownerID, noteID, against)
+	if err != nil {
+		return "", err
+	}
+	return unifiedDiff(revisionName(rev), revisionName(against), from.Text, to.Text), nil
+}
+
+// RestoreRevision overwrites the note with the content of revision rev.
+// The current content becomes a new revision, so a restore can be undone too.
+// Tags deleted since the revision was taken are skipped. An editor of a shared note restores
+// only the title and the text: tags stay as the owner left them, auto tags included.
+func (s *Service) RestoreRevision(ctx context.Context, userID, noteID int64, rev int) (*model.Note, error) {
+	ownerID, role, err := s.access(ctx, userID, noteID, model.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	revision, err := s.noteRepo.GetRevision(ctx, ownerID, noteID, rev)
+	if err != nil {
+		return nil, err
+	}
+	upd := &model.NoteUpdate{Title: &revision.Title, Text: &revision.Text, KeepAutoTags: role != model.RoleOwner}
+	if role == model.RoleOwner {
+		tagIDs := make([]int64, 0, len(revision.TagIDs))
+		for _, tagID := range revision.TagIDs {
+			_, err := s.tagRepo.GetByID(ctx, ownerID, tagID)
+			if errors.Is(err, model.ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			tagIDs = append(tagIDs, tagID)
+		}
+		upd.TagIDs = &tagIDs
+	}
+	return s.noteRepo.UpdateByID(ctx, ownerID, noteID, upd)
+}
+
+func revisionName(rev int) string {
+	return fmt.Sprintf("rev %d", rev)
+}
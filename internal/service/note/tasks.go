@@ -0,0 +1,129 @@
+package note
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Rasulikus/notebook/internal/markup"
+	"github.com/Rasulikus/notebook/internal/model"
+	"github.com/Rasulikus/notebook/internal/service"
+)
+
+// setTaskAttempts limits retries of SetTask when the note changes between reading and writing it.
+const setTaskAttempts = 3
+
+// taskNotesBatch is how many notes ListAllTasks reads at a time.
+const taskNotesBatch = 50
+
+// ListTasks returns checklist items of a note owned by or shared with the user in order of appearance.
+func (s *Service) ListTasks(ctx context.Context, userID, noteID int64) ([]model.NoteTask, error) {
+	ownerID, _, err := s.access(ctx, userID, noteID, model.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+	note, err := s.noteRepo.GetByID(ctx, ownerID, noteID)
+	if err != nil {
+		return nil, err
+	}
+	return noteTasks(note), nil
+}
+
+// SetTask marks a checklist item done, not done or toggles it by rewriting the note text.
+// The text is written only if the note still has the version it was read at; without
+// req.IfVersion a concurrent update makes it retry on the fresh text. An editor of a shared note
+// may tick items as well; hashtags in the text then don't become tags of the owner.
+func (s *Service) SetTask(ctx context.Context, userID, noteID int64, req *service.SetTaskReq) (*model.NoteTask, *model.Note, error) {
+	ownerID, role, err := s.access(ctx, userID, noteID, model.RoleEditor)
+	if err != nil {
+		return nil, nil, err
+	}
+	for attempt := 1; ; attempt++ {
+		note, err := s.noteRepo.GetByID(ctx, ownerID, noteID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if req.IfVersion != 0 && note.Version != req.IfVersion {
+			return nil, nil, model.ErrPreconditionFailed
+		}
+		tasks := noteTasks(note)
+		if req.Index < 0 || req.Index >= len(tasks) {
+			return nil, nil, fmt.Errorf("task %d: %w", req.Index, model.ErrNotFound)
+		}
+		done := !tasks[req.Index].Done
+		if req.Done != nil {
+			done = *req.Done
+		}
+		text, _ := markup.SetTask(note.Text, req.Index, done)
+
+		upd := &model.NoteUpdate{Text: &text, KeepAutoTags: role != model.RoleOwner, IfVersion: note.Version}
+		updated, err := s.noteRepo.UpdateByID(ctx, ownerID, noteID, upd)
+		if errors.Is(err, model.ErrPreconditionFailed) && req.IfVersion == 0 && attempt < setTaskAttempts {
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		task := tasks[req.Index]
+		task.Done = done
+		return &task, updated, nil
+	}
+}
+
+// ListAllTasks returns checklist items across user's notes that are not archived,
+// recently updated notes first. Notes are read in batches until the page is full.
+func (s *Service) ListAllTasks(ctx context.Context, userID int64, filter *model.TaskFilter) ([]model.NoteTask, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = 100
+	}
+	filter.Limit = min(filter.Limit, 500)
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	out := []model.NoteTask{}
+	skip := filter.Offset
+	var after *model.Note
+	for {
+		notes, err := s.noteRepo.ListWithTasks(ctx, userID, after, taskNotesBatch)
+		if err != nil {
+			return nil, err
+		}
+		for i := range notes {
+			for _, task := range noteTasks(&notes[i]) {
+				if filter.Done != nil && task.Done != *filter.Done {
+					continue
+				}
+				if skip > 0 {
+					skip--
+					continue
+				}
+				out = append(out, task)
+				if len(out) == filter.Limit {
+					return out, nil
+				}
+			}
+		}
+		if len(notes) < taskNotesBatch {
+			return out, nil
+		}
+		after = &notes[len(notes)-1]
+	}
+}
+
+// noteTasks parses checklist items of the note text.
+func noteTasks(note *model.Note) []model.NoteTask {
+	parsed := markup.Tasks(note.Text)
+	tasks := make([]model.NoteTask, 0, len(parsed))
+	for _, t := range parsed {
+		tasks = append(tasks, model.NoteTask{
+			NoteID:    note.ID,
+			NoteTitle: note.Title,
+			Index:     t.Index,
+			Line:      t.Line,
+			Text:      t.Text,
+			Done:      t.Done,
+		})
+	}
+	return tasks
+}
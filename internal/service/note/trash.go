@@ -0,0 +1,51 @@
+package note
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Rasulikus/notebook/internal/model"
+)
+
+// ListTrash returns user's notes in trash with sane paging defaults.
+func (s *Service) ListTrash(ctx context.Context, userID int64, limit, offset int) ([]model.Note, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return s.noteRepo.ListTrash(ctx, userID, limit, offset)
+}
+
+// Restore moves the user's note from trash back to the notes list.
+func (s *Service) Restore(ctx context.Context, userID, id int64) (*model.Note, error) {
+	return s.noteRepo.Restore(ctx, userID, id)
+}
+
+// DeletePermanently removes the user's note from trash for good.
+func (s *Service) DeletePermanently(ctx context.Context, userID, id int64) error {
+	return s.noteRepo.DeletePermanently(ctx, userID, id)
+}
+
+// RunTrashPurger permanently removes notes that stayed in trash longer than
+// retention, checking every interval until ctx is done. Meant to run in its own goroutine.
+func (s *Service) RunTrashPurger(ctx context.Context, interval, retention time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		purged, err := s.noteRepo.PurgeTrash(ctx, time.Now().Add(-retention))
+		if err != nil {
+			log.Printf("purge trash: %v", err)
+		} else if purged > 0 {
+			log.Printf("purge trash: removed %d note(s)", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
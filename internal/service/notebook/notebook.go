@@ -0,0 +1,78 @@
+// Package notebook provides business logic for notebooks.
+package notebook
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Rasulikus/notebook/internal/model"
+	"github.com/Rasulikus/notebook/internal/repository"
+)
+
+// Service coordinates notebook operations via repository.
+type Service struct {
+	notebookRepo repository.NotebookRepository
+}
+
+// NewService constructs the notebook service.
+func NewService(notebookRepo repository.NotebookRepository) *Service {
+	return &Service{notebookRepo: notebookRepo}
+}
+
+// Create creates a notebook for a user, at the root or inside another notebook of the same user.
+func (s *Service) Create(ctx context.Context, nb *model.Notebook) error {
+	if err := s.checkParent(ctx, nb.UserID, nb.ParentID); err != nil {
+		return err
+	}
+	return s.notebookRepo.Create(ctx, nb)
+}
+
+// List returns user's notebooks: all of them, root ones (parentID = 0) or children of a notebook.
+func (s *Service) List(ctx context.Context, userID int64, parentID *int64) ([]model.Notebook, error) {
+	if parentID != nil && *parentID != 0 {
+		if _, err := s.notebookRepo.GetByID(ctx, userID, *parentID); err != nil {
+			return nil, err
+		}
+	}
+	return s.notebookRepo.List(ctx, userID, parentID)
+}
+
+// GetByID returns a single notebook owned by the user.
+func (s *Service) GetByID(ctx context.Context, userID, id int64) (*model.Notebook, error) {
+	return s.notebookRepo.GetByID(ctx, userID, id)
+}
+
+// UpdateByID renames and/or moves the notebook (nil fields are kept) and returns the updated notebook.
+func (s *Service) UpdateByID(ctx context.Context, userID, id int64, upd *model.NotebookUpdate) (*model.Notebook, error) {
+	if upd.ParentID != nil {
+		if err := s.checkParent(ctx, userID, *upd.ParentID); err != nil {
+			return nil, err
+		}
+	}
+	return s.notebookRepo.UpdateByID(ctx, userID, id, upd)
+}
+
+// DeleteByID removes the notebook with its sub-notebooks; notes are handled by the policy,
+// move_to_root by default.
+func (s *Service) DeleteByID(ctx context.Context, userID, id int64, policy model.NotebookDeletePolicy) error {
+	switch policy {
+	case "":
+		policy = model.NotebookDeleteMoveToRoot
+	case model.NotebookDeleteMoveToRoot, model.NotebookDeleteTrash:
+	default:
+		return &model.ValidationError{Fields: map[string]string{"policy": "must be one of: move_to_root, trash"}}
+	}
+	return s.notebookRepo.DeleteByID(ctx, userID, id, policy)
+}
+
+// checkParent makes sure a non-root parent exists and belongs to the user.
+func (s *Service) checkParent(ctx context.Context, userID, parentID int64) error {
+	if parentID == 0 {
+		return nil
+	}
+	_, err := s.notebookRepo.GetByID(ctx, userID, parentID)
+	if errors.Is(err, model.ErrNotFound) {
+		return &model.ValidationError{Fields: map[string]string{"parent_id": "notebook not found"}}
+	}
+	return err
+}
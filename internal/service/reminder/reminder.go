@@ -0,0 +1,165 @@
+// Package reminder provides business logic for note reminders and the scheduler that fires them.
+// Reminders are personal: they are set on personal notes and go to their owner only, so inside
+// a workspace every call is ErrForbidden. The scheduler fires all due reminders regardless of scope.
+package reminder
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Rasulikus/notebook/internal/model"
+	"github.com/Rasulikus/notebook/internal/notify"
+	"github.com/Rasulikus/notebook/internal/repository"
+)
+
+// dueBatch is how many due reminders the scheduler handles in one transaction.
+const dueBatch = 100
+
+// Service coordinates reminder operations via repositories and fires due reminders through the notifier.
+type Service struct {
+	reminderRepo repository.ReminderRepository
+	noteRepo     repository.NoteRepository
+	notifier     notify.Notifier
+}
+
+// NewService constructs the reminder service.
+func NewService(reminderRepo repository.ReminderRepository, noteRepo repository.NoteRepository, notifier notify.Notifier) *Service {
+	return &Service{reminderRepo: reminderRepo, noteRepo: noteRepo, notifier: notifier}
+}
+
+// Create sets a reminder on the user's note. RemindAt must be in the future; Rule, if set,
+// makes it recurring and is stored in its canonical RRULE form; Timezone defaults to UTC.
+func (s *Service) Create(ctx context.Context, rem *model.Reminder) error {
+	if err := personalOnly(ctx); err != nil {
+		return err
+	}
+	if _, err := s.noteRepo.GetByID(ctx, rem.UserID, rem.NoteID); err != nil {
+		return err
+	}
+	if rem.Timezone == "" {
+		rem.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(rem.Timezone); err != nil {
+		return &model.ValidationError{Fields: map[string]string{"timezone": "unknown time zone"}}
+	}
+	if !rem.RemindAt.After(time.Now()) {
+		return &model.ValidationError{Fields: map[string]string{"remind_at": "must be in the future"}}
+	}
+	rec, err := model.ParseRecurrence(rem.Rule)
+	if err != nil {
+		return err
+	}
+	if rec != nil {
+		rem.Rule = rec.String()
+	}
+	rem.StartsAt = rem.RemindAt
+	return s.reminderRepo.Create(ctx, rem)
+}
+
+// List returns user's reminders with sane paging defaults.
+func (s *Service) List(ctx context.Context, userID int64, filter *model.ReminderFilter) ([]model.Reminder, error) {
+	if err := personalOnly(ctx); err != nil {
+		return nil, err
+	}
+	if filter.Limit <= 0 || filter.Limit > 100 {
+		filter.Limit = 20
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+	return s.reminderRepo.List(ctx, userID, filter)
+}
+
+// ListByNote returns reminders of the user's note.
+func (s *Service) ListByNote(ctx context.Context, userID, noteID int64) ([]model.Reminder, error) {
+	if err := personalOnly(ctx); err != nil {
+		return nil, err
+	}
+	if _, err := s.noteRepo.GetByID(ctx, userID, noteID); err != nil {
+		return nil, err
+	}
+	return s.reminderRepo.ListByNote(ctx, userID, noteID)
+}
+
+// DeleteByID cancels the user's reminder.
+func (s *Service) DeleteByID(ctx context.Context, userID, id int64) error {
+	if err := personalOnly(ctx); err != nil {
+		return err
+	}
+	return s.reminderRepo.DeleteByID(ctx, userID, id)
+}
+
+// RunScheduler fires due reminders every interval until ctx is done. Meant to run in its own goroutine.
+func (s *Service) RunScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := s.FireDue(ctx, time.Now()); err != nil {
+			log.Printf("fire reminders: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// FireDue moves every reminder due at now on, recurring reminders to their next occurrence
+// after now (missed ones are skipped) and the rest to done, and then notifies about it.
+// Reminders are moved on before the notification is sent, outside of the transaction that claims
+// them: a failed notification is logged and not retried, so a broken webhook can't hold the queue.
+func (s *Service) FireDue(ctx context.Context, now time.Time) error {
+	for {
+		due, err := s.reminderRepo.ClaimDue(ctx, now, dueBatch, func(rem *model.Reminder) {
+			advance(rem, now)
+		})
+		if err != nil {
+			return err
+		}
+		for i := range due {
+			if err := s.notifier.Notify(ctx, &due[i]); err != nil {
+				log.Printf("notify reminder %d: %v", due[i].ID, err)
+			}
+		}
+		if len(due) < dueBatch {
+			return nil
+		}
+	}
+}
+
+// personalOnly refuses work with reminders in a workspace scope.
+func personalOnly(ctx context.Context) error {
+	if model.WorkspaceID(ctx) != 0 {
+		return model.ErrForbidden
+	}
+	return nil
+}
+
+// advance records that the reminder fired at now and computes when it fires next.
+func advance(rem *model.Reminder, now time.Time) {
+	rem.FiredCount++
+	rem.LastFiredAt = now
+
+	rec, err := model.ParseRecurrence(rem.Rule)
+	if err != nil || rec == nil || (rec.Count > 0 && rem.FiredCount >= rec.Count) {
+		rem.Done = true
+		return
+	}
+	loc, err := time.LoadLocation(rem.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	after := rem.RemindAt
+	if now.After(after) {
+		after = now
+	}
+	next := rec.Next(rem.StartsAt.In(loc), after)
+	if next.IsZero() {
+		rem.Done = true
+		return
+	}
+	rem.RemindAt = next
+}
@@ -0,0 +1,62 @@
+package reminder
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Rasulikus/notebook/internal/model"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_advance(t *testing.T) {
+	start := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	cases := []struct {
+		name       string
+		rule       string
+		remindAt   time.Time
+		firedCount int
+		now        time.Time
+		wantAt     time.Time
+		wantDone   bool
+	}{
+		{name: "one-off", remindAt: start, now: start, wantAt: start, wantDone: true},
+		{name: "daily", rule: "daily", remindAt: start, now: start, wantAt: start.Add(day)},
+		{name: "daily catches up", rule: "daily", remindAt: start, now: start.Add(3*day + time.Hour), wantAt: start.Add(4 * day)},
+		{name: "count left", rule: "FREQ=DAILY;COUNT=3", remindAt: start.Add(day), firedCount: 1, now: start.Add(day), wantAt: start.Add(2 * day)},
+		{name: "count exhausted", rule: "FREQ=DAILY;COUNT=3", remindAt: start.Add(2 * day), firedCount: 2, now: start.Add(2 * day), wantAt: start.Add(2 * day), wantDone: true},
+		{name: "until reached", rule: "FREQ=DAILY;UNTIL=20250102", remindAt: start.Add(day), firedCount: 1, now: start.Add(day), wantAt: start.Add(day), wantDone: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rem := &model.Reminder{
+				Rule:       tc.rule,
+				Timezone:   "UTC",
+				StartsAt:   start,
+				RemindAt:   tc.remindAt,
+				FiredCount: tc.firedCount,
+			}
+			advance(rem, tc.now)
+			require.Equal(t, tc.firedCount+1, rem.FiredCount)
+			require.Equal(t, tc.now, rem.LastFiredAt)
+			require.Equal(t, tc.wantDone, rem.Done)
+			require.True(t, tc.wantAt.Equal(rem.RemindAt), "want %s, got %s", tc.wantAt, rem.RemindAt)
+		})
+	}
+}
+
+func Test_Service_WorkspaceScope(t *testing.T) {
+	// Calls are refused before any repository is touched.
+	s := NewService(nil, nil, nil)
+	ctx := model.WithWorkspace(context.Background(), &model.WorkspaceMembership{WorkspaceID: 1, UserID: 1, Role: model.WorkspaceOwner})
+
+	rem := &model.Reminder{NoteID: 1, UserID: 1, RemindAt: time.Now().Add(time.Hour)}
+	require.ErrorIs(t, s.Create(ctx, rem), model.ErrForbidden)
+	_, err := s.List(ctx, 1, &model.ReminderFilter{})
+	require.ErrorIs(t, err, model.ErrForbidden)
+	_, err = s.ListByNote(ctx, 1, 1)
+	require.ErrorIs(t, err, model.ErrForbidden)
+	require.ErrorIs(t, s.DeleteByID(ctx, 1, 1), model.ErrForbidden)
+}
@@ -2,22 +2,60 @@ package service
 
 import (
 	"context"
+	"io"
+	"time"
 
 	"github.com/Rasulikus/notebook/internal/model"
 )
 
 type UpdateByIDNoteReq struct {
-	Title   *string
-	Text    *string
-	TagsIDs *[]int64
+	Title      *string
+	Text       *string
+	TagsIDs    *[]int64
+	TagNames   *[]string // attached along with TagsIDs, missing tags are created
+	Pinned     *bool
+	Archived   *bool
+	Favorite   *bool
+	NotebookID *int64 // 0 - take the note out of its notebook
+	AutoTags   *bool  // sync tags with hashtags in the text
+	IfVersion  int64  // 0 - update regardless of the current version
+}
+
+// SetTaskReq marks a checklist item of a note done or not; nil Done toggles it.
+type SetTaskReq struct {
+	Index     int
+	Done      *bool
+	IfVersion int64 // 0 - apply to the latest version, retrying on concurrent updates
+}
+
+type UpdateTagsNoteReq struct {
+	NoteIDs []int64
+	Add     []int64
+	Remove  []int64
 }
 
 type NoteService interface {
-	Create(ctx context.Context, n *model.Note, TagsIDs []int64) (*model.Note, error)
-	List(ctx context.Context, userID int64, limit, offset int, order string) ([]model.Note, error)
+	Create(ctx context.Context, n *model.Note, TagsIDs []int64, tagNames []string) (*model.Note, error)
+	List(ctx context.Context, userID int64, filter *model.NoteFilter) (*model.Page[model.Note], error)
+	Search(ctx context.Context, userID int64, query string, limit, offset int) ([]model.NoteSearchHit, error)
 	GetByID(ctx context.Context, userID, id int64) (*model.Note, error)
 	UpdateByID(ctx context.Context, userID, id int64, req *UpdateByIDNoteReq) (*model.Note, error)
-	DeleteByID(ctx context.Context, userID, id int64) error
+	DeleteByID(ctx context.Context, userID, id, ifVersion int64) error
+	UpdateTags(ctx context.Context, userID int64, req *UpdateTagsNoteReq) ([]model.Note, error)
+	ListTrash(ctx context.Context, userID int64, limit, offset int) ([]model.Note, error)
+	Restore(ctx context.Context, userID, id int64) (*model.Note, error)
+	DeletePermanently(ctx context.Context, userID, id int64) error
+	ListRevisions(ctx context.Context, userID, noteID int64) ([]model.NoteRevision, error)
+	GetRevision(ctx context.Context, userID, noteID int64, rev int) (*model.NoteRevision, error)
+	DiffRevision(ctx context.Context, userID, noteID int64, rev, against int) (string, error)
+	RestoreRevision(ctx context.Context, userID, noteID int64, rev int) (*model.Note, error)
+	ListLinks(ctx context.Context, userID, noteID int64) ([]model.NoteLink, error)
+	ListBacklinks(ctx context.Context, userID, noteID int64) ([]model.NoteLink, error)
+	Graph(ctx context.Context, userID int64, filter *model.NoteFilter) (*model.NoteGraph, error)
+	ListTasks(ctx context.Context, userID, noteID int64) ([]model.NoteTask, error)
+	SetTask(ctx context.Context, userID, noteID int64, req *SetTaskReq) (*model.NoteTask, *model.Note, error)
+	ListAllTasks(ctx context.Context, userID int64, filter *model.TaskFilter) ([]model.NoteTask, error)
+	ListSharedWithMe(ctx context.Context, userID int64, limit, offset int) ([]model.SharedNote, error)
 }
 
 type AuthService interface {
@@ -26,6 +64,7 @@ type AuthService interface {
 	Refresh(ctx context.Context, oldRefreshToken string) (string, string, error)
 	ParseAccessToken(token string) (int64, error)
 	Logout(ctx context.Context, refreshToken string) error
+	IsAdmin(ctx context.Context, userID int64) (bool, error)
 }
 
 type JWTService interface {
@@ -35,10 +74,72 @@ type JWTService interface {
 	ParseAccessToken(token string) (int64, error)
 }
 
+type UpdateByIDTagReq struct {
+	Name     *string
+	ParentID *int64 // 0 - move the tag to the root
+}
+
 type TagService interface {
 	Create(ctx context.Context, tag *model.Tag) error
-	List(ctx context.Context, userID int64, limit, offset int, order string) ([]model.Tag, error)
+	List(ctx context.Context, userID int64, filter *model.TagFilter) (*model.Page[model.Tag], error)
 	GetByID(ctx context.Context, userID, id int64) (*model.Tag, error)
-	UpdateByID(ctx context.Context, userID int64, tag *model.Tag) (*model.Tag, error)
+	UpdateByID(ctx context.Context, userID, id int64, req *UpdateByIDTagReq) (*model.Tag, error)
+	DeleteByID(ctx context.Context, userID, id int64) error
+	Merge(ctx context.Context, userID, sourceID, targetID int64) (*model.Tag, error)
+	Suggest(ctx context.Context, userID int64, prefix string, limit int) ([]model.Tag, error)
+}
+
+type NotebookService interface {
+	Create(ctx context.Context, nb *model.Notebook) error
+	List(ctx context.Context, userID int64, parentID *int64) ([]model.Notebook, error)
+	GetByID(ctx context.Context, userID, id int64) (*model.Notebook, error)
+	UpdateByID(ctx context.Context, userID, id int64, upd *model.NotebookUpdate) (*model.Notebook, error)
+	DeleteByID(ctx context.Context, userID, id int64, policy model.NotebookDeletePolicy) error
+}
+
+type ReminderService interface {
+	Create(ctx context.Context, rem *model.Reminder) error
+	List(ctx context.Context, userID int64, filter *model.ReminderFilter) ([]model.Reminder, error)
+	ListByNote(ctx context.Context, userID, noteID int64) ([]model.Reminder, error)
+	DeleteByID(ctx context.Context, userID, id int64) error
+}
+
+type AttachmentService interface {
+	Upload(ctx context.Context, att *model.Attachment, r io.Reader) error
+	ListByNote(ctx context.Context, userID, noteID int64) ([]model.Attachment, error)
+	Open(ctx context.Context, userID, id int64) (*model.Attachment, io.ReadCloser, error)
+	DeleteByID(ctx context.Context, userID, id int64) error
+	Thumbnail(ctx context.Context, userID, id int64, size int) (*model.AttachmentThumb, error)
+	OpenThumbnail(ctx context.Context, thumb *model.AttachmentThumb) (io.ReadCloser, error)
+}
+
+type ShareService interface {
+	Create(ctx context.Context, userID, noteID int64, expiresAt time.Time, password string) (*model.NoteShare, string, error)
+	List(ctx context.Context, userID, noteID int64) ([]model.NoteShare, error)
+	Revoke(ctx context.Context, userID, id int64) error
+	Open(ctx context.Context, token, password string) (*model.Note, error)
+}
+
+type ACLService interface {
+	ShareNote(ctx context.Context, ownerID, noteID int64, email string, role model.Role) (*model.AccessGrant, error)
+	ShareTag(ctx context.Context, ownerID, tagID int64, email string, role model.Role) (*model.AccessGrant, error)
+	ListNoteGrants(ctx context.Context, ownerID, noteID int64) ([]model.AccessGrant, error)
+	ListTagGrants(ctx context.Context, ownerID, tagID int64) ([]model.AccessGrant, error)
+	Revoke(ctx context.Context, ownerID, id int64) error
+}
+
+type WorkspaceService interface {
+	Create(ctx context.Context, userID int64, name string) (*model.Workspace, error)
+	List(ctx context.Context, userID int64) ([]model.Workspace, error)
+	GetByID(ctx context.Context, userID, id int64) (*model.Workspace, error)
+	Rename(ctx context.Context, userID, id int64, name string) (*model.Workspace, error)
 	DeleteByID(ctx context.Context, userID, id int64) error
+	Membership(ctx context.Context, userID, id int64) (*model.WorkspaceMembership, error)
+	ListMembers(ctx context.Context, userID, id int64) ([]model.WorkspaceMembership, error)
+	SetMemberRole(ctx context.Context, userID, id, memberID int64, role model.WorkspaceRole) error
+	RemoveMember(ctx context.Context, userID, id, memberID int64) error
+	Invite(ctx context.Context, userID, id int64, email string, role model.WorkspaceRole) (*model.WorkspaceInvite, string, error)
+	ListInvites(ctx context.Context, userID, id int64) ([]model.WorkspaceInvite, error)
+	RevokeInvite(ctx context.Context, userID, id, inviteID int64) error
+	AcceptInvite(ctx context.Context, userID int64, token string) (*model.WorkspaceMembership, error)
 }
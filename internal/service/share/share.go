@@ -0,0 +1,135 @@
+// Package share provides business logic for public read-only links to notes.
+package share
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"time"
+
+	"github.com/Rasulikus/notebook/internal/model"
+	"github.com/Rasulikus/notebook/internal/repository"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Wrong passwords a protected share takes in a row before it is locked, and for how long.
+const (
+	maxPasswordAttempts = 5
+	passwordLockout     = 15 * time.Minute
+)
+
+// Service coordinates share operations via repositories.
+type Service struct {
+	shareRepo repository.ShareRepository
+	noteRepo  repository.NoteRepository
+}
+
+// NewService constructs the share service.
+func NewService(shareRepo repository.ShareRepository, noteRepo repository.NoteRepository) *Service {
+	return &Service{shareRepo: shareRepo, noteRepo: noteRepo}
+}
+
+// Create makes a share link for the user's note and returns it with its token, which is
+// not stored and can't be shown again. A zero expiresAt never expires; a non-empty password
+// is required to open the link. Only personal notes can be shared by a link.
+func (s *Service) Create(ctx context.Context, userID, noteID int64, expiresAt time.Time, password string) (*model.NoteShare, string, error) {
+	if model.WorkspaceID(ctx) != 0 {
+		return nil, "", model.ErrForbidden
+	}
+	if _, err := s.noteRepo.GetByID(ctx, userID, noteID); err != nil {
+		return nil, "", err
+	}
+	if !expiresAt.IsZero() && !expiresAt.After(time.Now()) {
+		return nil, "", &model.ValidationError{Fields: map[string]string{"expires_at": "must be in the future"}}
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	share := &model.NoteShare{
+		NoteID:    noteID,
+		UserID:    userID,
+		TokenHash: hashToken(token),
+		ExpiresAt: expiresAt,
+	}
+	if password != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, "", err
+		}
+		share.PasswordHash = string(hash)
+	}
+	if err := s.shareRepo.Create(ctx, share); err != nil {
+		return nil, "", err
+	}
+	return share, token, nil
+}
+
+// List returns user's shares; a non-zero noteID limits them to the note.
+func (s *Service) List(ctx context.Context, userID, noteID int64) ([]model.NoteShare, error) {
+	if noteID != 0 {
+		if _, err := s.noteRepo.GetByID(ctx, userID, noteID); err != nil {
+			return nil, err
+		}
+	}
+	return s.shareRepo.List(ctx, userID, noteID)
+}
+
+// Revoke makes the user's share stop working.
+func (s *Service) Revoke(ctx context.Context, userID, id int64) error {
+	return s.shareRepo.Revoke(ctx, userID, id)
+}
+
+// Open returns the note shared by the token. Unknown, revoked and expired tokens are all
+// ErrNotFound; a missing or wrong password for a protected share is ErrPasswordRequired.
+// Every password is counted before it is checked; after maxPasswordAttempts wrong ones in a row
+// the share answers ErrTooManyAttempts to any password for passwordLockout.
+func (s *Service) Open(ctx context.Context, token, password string) (*model.Note, error) {
+	now := time.Now()
+	share, err := s.shareRepo.GetActiveByTokenHash(ctx, hashToken(token), now)
+	if err != nil {
+		return nil, err
+	}
+	if share.PasswordHash != "" {
+		if password == "" {
+			return nil, model.ErrPasswordRequired
+		}
+		ok, err := s.shareRepo.ReservePasswordAttempt(ctx, share.ID, maxPasswordAttempts, now)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, model.ErrTooManyAttempts
+		}
+		err = bcrypt.CompareHashAndPassword([]byte(share.PasswordHash), []byte(password))
+		if err != nil {
+			if !errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+				return nil, err
+			}
+			if err := s.shareRepo.FailPassword(ctx, share.ID, maxPasswordAttempts, now.Add(passwordLockout)); err != nil {
+				return nil, err
+			}
+			return nil, model.ErrPasswordRequired
+		}
+	}
+
+	note, err := s.noteRepo.GetByID(ctx, share.UserID, share.NoteID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.shareRepo.MarkViewed(ctx, share.ID); err != nil {
+		return nil, err
+	}
+	return note, nil
+}
+
+// hashToken returns the digest a share token is stored and looked up by.
+func hashToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
@@ -4,9 +4,11 @@ package tag
 import (
 	"context"
 	"errors"
+	"strings"
 
 	"github.com/Rasulikus/notebook/internal/model"
 	"github.com/Rasulikus/notebook/internal/repository"
+	"github.com/Rasulikus/notebook/internal/service"
 )
 
 // Service coordinates tag operations via repository.
@@ -19,8 +21,11 @@ func NewService(tagRepo repository.TagRepository) *Service {
 	return &Service{tagRepo: tagRepo}
 }
 
-// Create creates a tag for a user.
+// Create creates a tag for a user, in the workspace selected in ctx if any.
 func (s *Service) Create(ctx context.Context, tag *model.Tag) error {
+	if tag.UserID != 0 {
+		tag.WorkspaceID = model.WorkspaceID(ctx)
+	}
 	err := s.tagRepo.Create(ctx, tag)
 	if err != nil {
 		if errors.Is(err, model.ErrConflict) {
@@ -31,18 +36,18 @@ func (s *Service) Create(ctx context.Context, tag *model.Tag) error {
 	return nil
 }
 
-// List returns user's tags with defaults.
-func (s *Service) List(ctx context.Context, userID int64, limit, offset int, order string) ([]model.Tag, error) {
-	if limit <= 0 || limit > 100 {
-		limit = 10
+// List returns a page of user's tags with defaults.
+func (s *Service) List(ctx context.Context, userID int64, filter *model.TagFilter) (*model.Page[model.Tag], error) {
+	if filter.Limit <= 0 || filter.Limit > 100 {
+		filter.Limit = 10
 	}
-	if offset < 0 {
-		offset = 0
+	if filter.Offset < 0 {
+		filter.Offset = 0
 	}
-	if order == "" {
-		order = "id"
+	if len(filter.Sort) == 0 {
+		filter.Sort = model.Sort{{Name: "id"}}
 	}
-	return s.tagRepo.List(ctx, userID, limit, offset, order)
+	return s.tagRepo.List(ctx, userID, filter)
 }
 
 // GetByID returns a single tag owned by the user.
@@ -50,16 +55,41 @@ func (s *Service) GetByID(ctx context.Context, userID, id int64) (*model.Tag, er
 	return s.tagRepo.GetByID(ctx, userID, id)
 }
 
-// UpdateByID updates tag fields for the given user and returns the updated tag.
-func (s *Service) UpdateByID(ctx context.Context, userID int64, tag *model.Tag) (*model.Tag, error) {
-	tag, err := s.tagRepo.UpdateByID(ctx, userID, tag)
+// UpdateByID renames and/or moves the tag (nil fields are kept) and returns the updated tag.
+// Descendant tags follow the new path.
+func (s *Service) UpdateByID(ctx context.Context, userID, id int64, req *service.UpdateByIDTagReq) (*model.Tag, error) {
+	tag, err := s.tagRepo.GetByID(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if req.Name != nil {
+		tag.Name = *req.Name
+	}
+	if req.ParentID != nil {
+		tag.ParentID = *req.ParentID
+	}
+	tag, err = s.tagRepo.UpdateByID(ctx, userID, tag)
 	if err != nil {
 		return nil, err
 	}
 	return tag, nil
 }
 
-// DeleteByID removes the user's tag.
+// DeleteByID removes the user's tag with its descendants; it is detached from all notes.
 func (s *Service) DeleteByID(ctx context.Context, userID, id int64) error {
 	return s.tagRepo.DeleteByID(ctx, userID, id)
 }
+
+// Merge moves notes and child tags of the source tag to the target and deletes the source.
+func (s *Service) Merge(ctx context.Context, userID, sourceID, targetID int64) (*model.Tag, error) {
+	return s.tagRepo.Merge(ctx, userID, sourceID, targetID)
+}
+
+// Suggest returns tags for autocomplete by name or path prefix, most used first.
+func (s *Service) Suggest(ctx context.Context, userID int64, prefix string, limit int) ([]model.Tag, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+	limit = min(limit, 50)
+	return s.tagRepo.Suggest(ctx, userID, strings.TrimSpace(prefix), limit)
+}
@@ -0,0 +1,200 @@
+// Package workspace provides business logic for team workspaces, their members and invitations.
+package workspace
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+	"time"
+
+	"github.com/Rasulikus/notebook/internal/model"
+	"github.com/Rasulikus/notebook/internal/repository"
+)
+
+// Service coordinates workspace operations via repositories.
+type Service struct {
+	workspaceRepo repository.WorkspaceRepository
+	userRepo      repository.UserRepository
+	inviteTTL     time.Duration
+}
+
+// NewService constructs the workspace service; invitations can be accepted within inviteTTL.
+func NewService(workspaceRepo repository.WorkspaceRepository, userRepo repository.UserRepository, inviteTTL time.Duration) *Service {
+	return &Service{workspaceRepo: workspaceRepo, userRepo: userRepo, inviteTTL: inviteTTL}
+}
+
+// Create makes a workspace owned by the user.
+func (s *Service) Create(ctx context.Context, userID int64, name string) (*model.Workspace, error) {
+	ws := &model.Workspace{Name: strings.TrimSpace(name)}
+	if err := s.workspaceRepo.Create(ctx, ws, userID); err != nil {
+		return nil, err
+	}
+	return ws, nil
+}
+
+// List returns workspaces the user is a member of.
+func (s *Service) List(ctx context.Context, userID int64) ([]model.Workspace, error) {
+	return s.workspaceRepo.List(ctx, userID)
+}
+
+// GetByID returns a workspace of the user.
+func (s *Service) GetByID(ctx context.Context, userID, id int64) (*model.Workspace, error) {
+	return s.workspaceRepo.GetByID(ctx, userID, id)
+}
+
+// Rename changes the name of the workspace; admins and owners only.
+func (s *Service) Rename(ctx context.Context, userID, id int64, name string) (*model.Workspace, error) {
+	if _, err := s.member(ctx, userID, id, model.WorkspaceAdmin); err != nil {
+		return nil, err
+	}
+	if err := s.workspaceRepo.Rename(ctx, id, strings.TrimSpace(name)); err != nil {
+		return nil, err
+	}
+	return s.workspaceRepo.GetByID(ctx, userID, id)
+}
+
+// DeleteByID deletes the workspace with all its notes and tags; owners only.
+func (s *Service) DeleteByID(ctx context.Context, userID, id int64) error {
+	if _, err := s.member(ctx, userID, id, model.WorkspaceOwner); err != nil {
+		return err
+	}
+	return s.workspaceRepo.DeleteByID(ctx, id)
+}
+
+// Membership returns the user's membership in the workspace; ErrNotFound for non-members.
+func (s *Service) Membership(ctx context.Context, userID, id int64) (*model.WorkspaceMembership, error) {
+	return s.workspaceRepo.GetMember(ctx, id, userID)
+}
+
+// ListMembers returns members of a workspace of the user.
+func (s *Service) ListMembers(ctx context.Context, userID, id int64) ([]model.WorkspaceMembership, error) {
+	if _, err := s.member(ctx, userID, id, model.WorkspaceViewer); err != nil {
+		return nil, err
+	}
+	return s.workspaceRepo.ListMembers(ctx, id)
+}
+
+// SetMemberRole changes the role of a member. Admins manage members and viewers,
+// admins and owners are managed by owners only; the last owner can't be demoted (ErrConflict).
+// The roles are checked in the transaction that changes the role, so a concurrent change of
+// either role can't slip in between.
+func (s *Service) SetMemberRole(ctx context.Context, userID, id, memberID int64, role model.WorkspaceRole) error {
+	if !role.Valid() {
+		return &model.ValidationError{Fields: map[string]string{"role": "must be owner, admin, member or viewer"}}
+	}
+	return s.workspaceRepo.SetMemberRole(ctx, id, userID, memberID, role, func(actor, target *model.WorkspaceMembership) error {
+		if !manages(actor.Role, target.Role) || !manages(actor.Role, role) {
+			return model.ErrForbidden
+		}
+		return nil
+	})
+}
+
+// RemoveMember takes a member out of the workspace; anyone may leave, other members are removed
+// under the same rules as their roles are changed, checked in the same transaction.
+// The last owner can't leave (ErrConflict).
+func (s *Service) RemoveMember(ctx context.Context, userID, id, memberID int64) error {
+	return s.workspaceRepo.RemoveMember(ctx, id, userID, memberID, func(actor, target *model.WorkspaceMembership) error {
+		if actor.UserID != target.UserID && !manages(actor.Role, target.Role) {
+			return model.ErrForbidden
+		}
+		return nil
+	})
+}
+
+// Invite invites a user by email to join the workspace with the role and returns the invitation
+// with its token, which is not stored and can't be shown again. Admins invite members and viewers,
+// owners may invite admins too.
+func (s *Service) Invite(ctx context.Context, userID, id int64, email string, role model.WorkspaceRole) (*model.WorkspaceInvite, string, error) {
+	if role != model.WorkspaceAdmin && role != model.WorkspaceMember && role != model.WorkspaceViewer {
+		return nil, "", &model.ValidationError{Fields: map[string]string{"role": "must be admin, member or viewer"}}
+	}
+	actor, err := s.member(ctx, userID, id, model.WorkspaceAdmin)
+	if err != nil {
+		return nil, "", err
+	}
+	if !manages(actor.Role, role) {
+		return nil, "", model.ErrForbidden
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	invite := &model.WorkspaceInvite{
+		WorkspaceID: id,
+		InvitedBy:   userID,
+		Email:       strings.ToLower(strings.TrimSpace(email)),
+		Role:        role,
+		TokenHash:   hashToken(token),
+		ExpiresAt:   time.Now().Add(s.inviteTTL),
+	}
+	if err := s.workspaceRepo.CreateInvite(ctx, invite); err != nil {
+		return nil, "", err
+	}
+	return invite, token, nil
+}
+
+// ListInvites returns pending invitations of the workspace; admins and owners only.
+func (s *Service) ListInvites(ctx context.Context, userID, id int64) ([]model.WorkspaceInvite, error) {
+	if _, err := s.member(ctx, userID, id, model.WorkspaceAdmin); err != nil {
+		return nil, err
+	}
+	return s.workspaceRepo.ListInvites(ctx, id, time.Now())
+}
+
+// RevokeInvite makes a pending invitation stop working; admins and owners only.
+func (s *Service) RevokeInvite(ctx context.Context, userID, id, inviteID int64) error {
+	if _, err := s.member(ctx, userID, id, model.WorkspaceAdmin); err != nil {
+		return err
+	}
+	return s.workspaceRepo.RevokeInvite(ctx, id, inviteID)
+}
+
+// AcceptInvite makes the user a member of the workspace the token invites to. The invitation
+// must be addressed to the user's email (ErrForbidden otherwise); unknown, used, revoked and
+// expired tokens are all ErrNotFound.
+func (s *Service) AcceptInvite(ctx context.Context, userID int64, token string) (*model.WorkspaceMembership, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.workspaceRepo.AcceptInvite(ctx, hashToken(token), userID, time.Now(), func(invite *model.WorkspaceInvite) error {
+		if !strings.EqualFold(invite.Email, user.Email) {
+			return model.ErrForbidden
+		}
+		return nil
+	})
+}
+
+// member returns the user's membership in the workspace if the role allows need;
+// non-members get ErrNotFound, weaker roles ErrForbidden.
+func (s *Service) member(ctx context.Context, userID, id int64, need model.WorkspaceRole) (*model.WorkspaceMembership, error) {
+	m, err := s.workspaceRepo.GetMember(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !m.Role.Allows(need) {
+		return nil, model.ErrForbidden
+	}
+	return m, nil
+}
+
+// manages reports whether an actor with the role may give or take away the role of another member:
+// owners manage everyone, admins only members and viewers.
+func manages(actor, role model.WorkspaceRole) bool {
+	if role == model.WorkspaceOwner || role == model.WorkspaceAdmin {
+		return actor == model.WorkspaceOwner
+	}
+	return actor.Allows(model.WorkspaceAdmin)
+}
+
+// hashToken returns the digest an invitation token is stored and looked up by.
+func hashToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
@@ -0,0 +1,15 @@
+// Package storage keeps file contents (blobs) outside the database.
+package storage
+
+import (
+	"context"
+	"io"
+)
+
+// BlobStore stores blobs under opaque slash-separated keys chosen by the caller.
+// Get of a missing key fails with model.ErrNotFound; Delete of a missing key is not an error.
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
@@ -0,0 +1,104 @@
+// Package thumbnail makes small previews of JPEG, PNG and GIF images in pure Go.
+package thumbnail
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+
+	"golang.org/x/image/draw"
+)
+
+// MaxPixels bounds the decoded size of a source image, so a small file can't
+// unpack into gigabytes of pixels.
+const MaxPixels = 40_000_000
+
+// jpegQuality is the quality thumbnails of JPEG images are encoded with.
+const jpegQuality = 85
+
+// ErrTooLarge is returned for images with more than MaxPixels pixels.
+var ErrTooLarge = errors.New("image is too large")
+
+// Thumbnail is an encoded preview that fits into a Size x Size square.
+type Thumbnail struct {
+	Size        int
+	ContentType string
+	Data        []byte
+}
+
+// Supported reports whether thumbnails can be made for the content type.
+func Supported(contentType string) bool {
+	switch contentType {
+	case "image/jpeg", "image/png", "image/gif":
+		return true
+	}
+	return false
+}
+
+// Generate decodes the image in data and makes a thumbnail for every size, keeping the aspect ratio.
+// Images are never upscaled: a size larger than the image gives a re-encoded copy.
+// JPEG sources give JPEG thumbnails; PNG and GIF (its first frame) give PNG ones to keep transparency.
+func Generate(data []byte, sizes []int) ([]Thumbnail, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if int64(cfg.Width)*int64(cfg.Height) > MaxPixels {
+		return nil, ErrTooLarge
+	}
+
+	var src image.Image
+	switch format {
+	case "jpeg":
+		src, err = jpeg.Decode(bytes.NewReader(data))
+	case "png":
+		src, err = png.Decode(bytes.NewReader(data))
+	case "gif":
+		src, err = gif.Decode(bytes.NewReader(data))
+	default:
+		return nil, fmt.Errorf("unsupported image format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	thumbs := make([]Thumbnail, 0, len(sizes))
+	for _, size := range sizes {
+		var buf bytes.Buffer
+		dst := scale(src, size)
+		thumb := Thumbnail{Size: size}
+		if format == "jpeg" {
+			thumb.ContentType = "image/jpeg"
+			err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: jpegQuality})
+		} else {
+			thumb.ContentType = "image/png"
+			err = png.Encode(&buf, dst)
+		}
+		if err != nil {
+			return nil, err
+		}
+		thumb.Data = buf.Bytes()
+		thumbs = append(thumbs, thumb)
+	}
+	return thumbs, nil
+}
+
+// scale resizes src to fit into a size x size square.
+func scale(src image.Image, size int) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w > size || h > size {
+		if w >= h {
+			w, h = size, max(1, h*size/w)
+		} else {
+			w, h = max(1, w*size/h), size
+		}
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, b, draw.Src, nil)
+	return dst
+}
@@ -0,0 +1,101 @@
+package thumbnail
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Generate(t *testing.T) {
+	cases := []struct {
+		name     string
+		data     []byte
+		sizes    []int
+		wantType string
+		wantW    []int
+		wantH    []int
+	}{
+		{"jpeg landscape", encodeJPEG(t, 400, 200), []int{100, 200}, "image/jpeg", []int{100, 200}, []int{50, 100}},
+		{"png portrait", encodePNG(t, 150, 600), []int{120}, "image/png", []int{30}, []int{120}},
+		{"png square", encodePNG(t, 300, 300), []int{64}, "image/png", []int{64}, []int{64}},
+		{"gif gives png", encodeGIF(t, 80, 40), []int{20}, "image/png", []int{20}, []int{10}},
+		{"never upscaled", encodePNG(t, 50, 20), []int{256}, "image/png", []int{50}, []int{20}},
+		{"thin side kept", encodePNG(t, 1000, 2), []int{100}, "image/png", []int{100}, []int{1}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			thumbs, err := Generate(tc.data, tc.sizes)
+			require.NoError(t, err)
+			require.Len(t, thumbs, len(tc.sizes))
+			for i, thumb := range thumbs {
+				require.Equal(t, tc.sizes[i], thumb.Size)
+				require.Equal(t, tc.wantType, thumb.ContentType)
+				cfg, format, err := image.DecodeConfig(bytes.NewReader(thumb.Data))
+				require.NoError(t, err)
+				require.Equal(t, "image/"+format, thumb.ContentType, "data is encoded as its content type")
+				require.Equal(t, tc.wantW[i], cfg.Width)
+				require.Equal(t, tc.wantH[i], cfg.Height)
+			}
+		})
+	}
+}
+
+func Test_Generate_Errors(t *testing.T) {
+	_, err := Generate(withPNGSize(t, encodePNG(t, 1, 1), 10_000, 5_000), []int{128})
+	require.ErrorIs(t, err, ErrTooLarge)
+
+	_, err = Generate(withPNGSize(t, encodePNG(t, 1, 1), 8_000, 5_000), []int{128})
+	require.Error(t, err)
+	require.NotErrorIs(t, err, ErrTooLarge, "an image within MaxPixels is decoded")
+
+	_, err = Generate([]byte("not an image"), []int{128})
+	require.Error(t, err)
+}
+
+func testImage(w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 100, A: 255})
+		}
+	}
+	return img
+}
+
+func encodeJPEG(t *testing.T, w, h int) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, testImage(w, h), nil))
+	return buf.Bytes()
+}
+
+func encodePNG(t *testing.T, w, h int) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, testImage(w, h)))
+	return buf.Bytes()
+}
+
+func encodeGIF(t *testing.T, w, h int) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, gif.Encode(&buf, testImage(w, h), nil))
+	return buf.Bytes()
+}
+
+// withPNGSize rewrites the dimensions in the IHDR chunk of a PNG, so the header
+// claims a huge image without one being encoded.
+func withPNGSize(t *testing.T, data []byte, w, h uint32) []byte {
+	t.Helper()
+	out := bytes.Clone(data)
+	require.Equal(t, "IHDR", string(out[12:16]))
+	binary.BigEndian.PutUint32(out[16:20], w)
+	binary.BigEndian.PutUint32(out[20:24], h)
+	binary.BigEndian.PutUint32(out[29:33], crc32.ChecksumIEEE(out[12:29]))
+	return out
+}
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS notes_search_vector_idx;
+ALTER TABLE IF EXISTS notes DROP COLUMN IF EXISTS search_vector;
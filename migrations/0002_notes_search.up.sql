@@ -0,0 +1,7 @@
+-- Полнотекстовый поиск по заголовку и тексту заметки
+ALTER TABLE notes ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', coalesce(title, '')), 'A') ||
+    setweight(to_tsvector('simple', coalesce(text, '')), 'B')
+) STORED;
+
+CREATE INDEX notes_search_vector_idx ON notes USING GIN (search_vector);
@@ -0,0 +1,11 @@
+-- История изменений заметок: снимок содержимого перед каждым обновлением
+CREATE TABLE note_revisions (
+    id         BIGSERIAL PRIMARY KEY,
+    note_id    BIGINT NOT NULL REFERENCES notes(id) ON DELETE CASCADE,
+    rev        INT NOT NULL,
+    title      TEXT NOT NULL,
+    text       TEXT,
+    tag_ids    BIGINT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (note_id, rev)
+);
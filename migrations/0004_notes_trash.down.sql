@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS notes_deleted_at_idx;
+ALTER TABLE IF EXISTS notes DROP COLUMN IF EXISTS deleted_at;
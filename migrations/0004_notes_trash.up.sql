@@ -0,0 +1,4 @@
+-- Корзина: удалённые заметки помечаются deleted_at и удаляются окончательно по истечении срока хранения
+ALTER TABLE notes ADD COLUMN deleted_at TIMESTAMPTZ;
+
+CREATE INDEX notes_deleted_at_idx ON notes (deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1 @@
+ALTER TABLE IF EXISTS notes DROP COLUMN IF EXISTS version;
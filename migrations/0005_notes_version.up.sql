@@ -0,0 +1,2 @@
+-- Версия заметки для оптимистичной блокировки (ETag / If-Match)
+ALTER TABLE notes ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
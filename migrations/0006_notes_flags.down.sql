@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS notes_user_archived_idx;
+
+ALTER TABLE IF EXISTS notes
+    DROP COLUMN IF EXISTS favorite,
+    DROP COLUMN IF EXISTS archived,
+    DROP COLUMN IF EXISTS pinned;
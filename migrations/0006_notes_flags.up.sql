@@ -0,0 +1,7 @@
+-- Закреплённые, архивные и избранные заметки
+ALTER TABLE notes
+    ADD COLUMN pinned   BOOLEAN NOT NULL DEFAULT false,
+    ADD COLUMN archived BOOLEAN NOT NULL DEFAULT false,
+    ADD COLUMN favorite BOOLEAN NOT NULL DEFAULT false;
+
+CREATE INDEX notes_user_archived_idx ON notes (user_id, archived);
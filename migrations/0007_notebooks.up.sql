@@ -0,0 +1,17 @@
+-- Блокноты: вложенные папки для заметок
+CREATE TABLE notebooks (
+    id         BIGSERIAL PRIMARY KEY,
+    user_id    BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    parent_id  BIGINT REFERENCES notebooks(id) ON DELETE CASCADE,
+    name       TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Имена уникальны среди соседей; корневые блокноты имеют parent_id = NULL
+CREATE UNIQUE INDEX notebooks_user_parent_name_key ON notebooks (user_id, COALESCE(parent_id, 0), name);
+CREATE INDEX notebooks_parent_id_idx ON notebooks (parent_id);
+
+ALTER TABLE notes ADD COLUMN notebook_id BIGINT REFERENCES notebooks(id) ON DELETE SET NULL;
+
+CREATE INDEX notes_notebook_id_idx ON notes (notebook_id);
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS tags_parent_id_idx;
+
+ALTER TABLE IF EXISTS tags DROP CONSTRAINT IF EXISTS tags_user_id_path_key;
+-- дочерние теги становятся корневыми; при совпадении имён откат не пройдёт
+ALTER TABLE IF EXISTS tags ADD CONSTRAINT tags_user_id_name_key UNIQUE (user_id, name);
+
+ALTER TABLE IF EXISTS tags
+    DROP COLUMN IF EXISTS path,
+    DROP COLUMN IF EXISTS parent_id;
@@ -0,0 +1,13 @@
+-- Иерархия тегов: parent_id и полный путь вида work/clients/acme, уникальный для пользователя
+ALTER TABLE tags
+    ADD COLUMN parent_id BIGINT REFERENCES tags(id) ON DELETE CASCADE,
+    ADD COLUMN path      TEXT;
+
+UPDATE tags SET path = name;
+
+ALTER TABLE tags ALTER COLUMN path SET NOT NULL;
+
+ALTER TABLE tags DROP CONSTRAINT IF EXISTS tags_user_id_name_key;
+ALTER TABLE tags ADD CONSTRAINT tags_user_id_path_key UNIQUE (user_id, path);
+
+CREATE INDEX tags_parent_id_idx ON tags (parent_id);
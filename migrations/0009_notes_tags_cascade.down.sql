@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS notes_tags_tag_id_idx;
+
+ALTER TABLE IF EXISTS notes_tags DROP CONSTRAINT IF EXISTS notes_tags_tag_id_fkey;
+ALTER TABLE IF EXISTS notes_tags
+    ADD CONSTRAINT notes_tags_tag_id_fkey FOREIGN KEY (tag_id) REFERENCES tags(id);
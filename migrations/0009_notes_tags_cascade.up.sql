@@ -0,0 +1,6 @@
+-- Удаление тега убирает его со всех заметок
+ALTER TABLE notes_tags DROP CONSTRAINT IF EXISTS notes_tags_tag_id_fkey;
+ALTER TABLE notes_tags
+    ADD CONSTRAINT notes_tags_tag_id_fkey FOREIGN KEY (tag_id) REFERENCES tags(id) ON DELETE CASCADE;
+
+CREATE INDEX notes_tags_tag_id_idx ON notes_tags (tag_id);
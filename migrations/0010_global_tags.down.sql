@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS tags_global_path_key;
+
+ALTER TABLE IF EXISTS users DROP COLUMN IF EXISTS is_admin;
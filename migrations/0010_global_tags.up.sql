@@ -0,0 +1,5 @@
+-- Администраторы управляют глобальными тегами (user_id IS NULL)
+ALTER TABLE users ADD COLUMN is_admin BOOLEAN NOT NULL DEFAULT false;
+
+-- UNIQUE (user_id, path) не работает для NULL, поэтому пути глобальных тегов уникальны отдельно
+CREATE UNIQUE INDEX tags_global_path_key ON tags (path) WHERE user_id IS NULL;
@@ -0,0 +1,3 @@
+ALTER TABLE IF EXISTS notes DROP COLUMN IF EXISTS auto_tags;
+
+ALTER TABLE IF EXISTS notes_tags DROP COLUMN IF EXISTS source;
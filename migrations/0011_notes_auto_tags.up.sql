@@ -0,0 +1,5 @@
+-- Теги, извлечённые из хэштегов текста (auto), отделены от добавленных вручную (manual)
+ALTER TABLE notes_tags ADD COLUMN source TEXT NOT NULL DEFAULT 'manual' CHECK (source IN ('manual', 'auto'));
+
+-- Режим автоматических тегов включается для каждой заметки отдельно
+ALTER TABLE notes ADD COLUMN auto_tags BOOLEAN NOT NULL DEFAULT false;
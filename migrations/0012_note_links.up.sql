@@ -0,0 +1,11 @@
+-- Ссылки [[Заголовок]] и [[note:123]] из текста заметок.
+-- ref хранит ссылку как она написана, target_id - заметку, на которую она указывает:
+-- NULL, пока такой заметки нет (висячая ссылка); после переименования цели ссылка не ломается.
+CREATE TABLE note_links (
+    source_id  BIGINT NOT NULL REFERENCES notes(id) ON DELETE CASCADE,
+    ref        TEXT   NOT NULL,
+    target_id  BIGINT REFERENCES notes(id) ON DELETE SET NULL,
+    PRIMARY KEY (source_id, ref)
+);
+
+CREATE INDEX note_links_target_idx ON note_links (target_id);
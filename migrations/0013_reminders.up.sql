@@ -0,0 +1,20 @@
+-- Напоминания по заметкам; повторяющиеся после срабатывания переносят remind_at на следующий раз
+CREATE TABLE reminders (
+    id            BIGSERIAL PRIMARY KEY,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    user_id       BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    note_id       BIGINT NOT NULL REFERENCES notes(id) ON DELETE CASCADE,
+    remind_at     TIMESTAMPTZ NOT NULL,
+    starts_at     TIMESTAMPTZ NOT NULL,
+    rule          TEXT NOT NULL DEFAULT '',
+    timezone      TEXT NOT NULL DEFAULT 'UTC',
+    fired_count   INT NOT NULL DEFAULT 0,
+    last_fired_at TIMESTAMPTZ,
+    done          BOOLEAN NOT NULL DEFAULT false
+);
+
+-- Планировщик выбирает только несработавшие напоминания по времени
+CREATE INDEX reminders_due_idx ON reminders (remind_at) WHERE NOT done;
+CREATE INDEX reminders_user_idx ON reminders (user_id, remind_at);
+CREATE INDEX reminders_note_idx ON reminders (note_id);
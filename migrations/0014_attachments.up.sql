@@ -0,0 +1,18 @@
+-- Метаданные вложений; содержимое хранится в blob store по storage_key.
+-- При окончательном удалении заметки note_id обнуляется, а файл удаляет фоновая очистка
+CREATE TABLE attachments (
+    id           BIGSERIAL PRIMARY KEY,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    user_id      BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    note_id      BIGINT REFERENCES notes(id) ON DELETE SET NULL,
+    name         TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size         BIGINT NOT NULL CHECK (size >= 0),
+    sha256       TEXT NOT NULL,
+    storage_key  TEXT NOT NULL UNIQUE
+);
+
+CREATE INDEX attachments_note_idx ON attachments (note_id);
+CREATE INDEX attachments_user_idx ON attachments (user_id);
+-- Очистка выбирает только отвязанные вложения
+CREATE INDEX attachments_orphan_idx ON attachments (id) WHERE note_id IS NULL;
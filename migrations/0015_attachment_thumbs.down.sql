@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS attachment_thumbs;
+DROP INDEX IF EXISTS attachments_thumb_pending_idx;
+ALTER TABLE IF EXISTS attachments DROP COLUMN IF EXISTS thumb_status;
@@ -0,0 +1,21 @@
+-- Состояние превью: none - не изображение, pending - ждёт фонового воркера
+ALTER TABLE attachments
+    ADD COLUMN thumb_status TEXT NOT NULL DEFAULT 'none'
+        CHECK (thumb_status IN ('none', 'pending', 'ready', 'failed'));
+
+UPDATE attachments SET thumb_status = 'pending'
+WHERE content_type IN ('image/jpeg', 'image/png', 'image/gif');
+
+CREATE INDEX attachments_thumb_pending_idx ON attachments (id) WHERE thumb_status = 'pending';
+
+-- Превью вложений по размерам; файлы удаляются вместе с вложением
+CREATE TABLE attachment_thumbs (
+    attachment_id BIGINT NOT NULL REFERENCES attachments(id) ON DELETE CASCADE,
+    size          INT NOT NULL CHECK (size > 0),
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    content_type  TEXT NOT NULL,
+    byte_size     BIGINT NOT NULL,
+    sha256        TEXT NOT NULL,
+    storage_key   TEXT NOT NULL,
+    PRIMARY KEY (attachment_id, size)
+);
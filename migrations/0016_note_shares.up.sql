@@ -0,0 +1,16 @@
+-- Публичные ссылки на заметки; хранится только sha256 токена
+CREATE TABLE note_shares (
+    id             BIGSERIAL PRIMARY KEY,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT now(),
+    user_id        BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    note_id        BIGINT NOT NULL REFERENCES notes(id) ON DELETE CASCADE,
+    token_hash     BYTEA NOT NULL UNIQUE,
+    password_hash  TEXT,
+    expires_at     TIMESTAMPTZ,
+    revoked_at     TIMESTAMPTZ,
+    views          BIGINT NOT NULL DEFAULT 0,
+    last_viewed_at TIMESTAMPTZ
+);
+
+CREATE INDEX note_shares_note_idx ON note_shares (note_id);
+CREATE INDEX note_shares_user_idx ON note_shares (user_id, created_at DESC);
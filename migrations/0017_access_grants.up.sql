@@ -0,0 +1,17 @@
+-- Доступ других пользователей к заметке или ко всем заметкам владельца с тегом
+CREATE TABLE access_grants (
+    id         BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    owner_id   BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    grantee_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    note_id    BIGINT REFERENCES notes(id) ON DELETE CASCADE,
+    tag_id     BIGINT REFERENCES tags(id) ON DELETE CASCADE,
+    role       TEXT NOT NULL CHECK (role IN ('viewer', 'editor')),
+    CHECK ((note_id IS NULL) <> (tag_id IS NULL)),
+    CHECK (owner_id <> grantee_id)
+);
+
+-- Повторная выдача доступа меняет роль
+CREATE UNIQUE INDEX access_grants_note_uidx ON access_grants (note_id, grantee_id) WHERE note_id IS NOT NULL;
+CREATE UNIQUE INDEX access_grants_tag_uidx ON access_grants (tag_id, grantee_id) WHERE tag_id IS NOT NULL;
+CREATE INDEX access_grants_grantee_idx ON access_grants (grantee_id);
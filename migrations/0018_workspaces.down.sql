@@ -0,0 +1,14 @@
+DROP INDEX IF EXISTS tags_workspace_path_key;
+DROP INDEX IF EXISTS tags_user_path_key;
+DROP INDEX IF EXISTS notes_workspace_idx;
+
+-- заметки и теги пространств удаляются вместе с ними
+DELETE FROM notes WHERE workspace_id IS NOT NULL;
+DELETE FROM tags WHERE workspace_id IS NOT NULL;
+ALTER TABLE IF EXISTS notes DROP COLUMN IF EXISTS workspace_id;
+ALTER TABLE IF EXISTS tags DROP COLUMN IF EXISTS workspace_id;
+ALTER TABLE IF EXISTS tags ADD CONSTRAINT tags_user_id_path_key UNIQUE (user_id, path);
+
+DROP TABLE IF EXISTS workspace_invites;
+DROP TABLE IF EXISTS workspace_members;
+DROP TABLE IF EXISTS workspaces;
@@ -0,0 +1,43 @@
+-- Командные пространства: владеют заметками и тегами, доступ по ролям участников
+CREATE TABLE workspaces (
+    id         BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    name       TEXT NOT NULL
+);
+
+CREATE TABLE workspace_members (
+    workspace_id BIGINT NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    user_id      BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    role         TEXT NOT NULL CHECK (role IN ('owner', 'admin', 'member', 'viewer')),
+    PRIMARY KEY (workspace_id, user_id)
+);
+
+CREATE INDEX workspace_members_user_idx ON workspace_members (user_id);
+
+-- Приглашения; хранится только sha256 токена
+CREATE TABLE workspace_invites (
+    id           BIGSERIAL PRIMARY KEY,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    workspace_id BIGINT NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    invited_by   BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    email        TEXT NOT NULL,
+    role         TEXT NOT NULL CHECK (role IN ('admin', 'member', 'viewer')),
+    token_hash   BYTEA NOT NULL UNIQUE,
+    expires_at   TIMESTAMPTZ NOT NULL,
+    accepted_at  TIMESTAMPTZ,
+    revoked_at   TIMESTAMPTZ
+);
+
+CREATE INDEX workspace_invites_workspace_idx ON workspace_invites (workspace_id, created_at DESC);
+
+-- Заметки и теги пространства; user_id остаётся автором
+ALTER TABLE notes ADD COLUMN workspace_id BIGINT REFERENCES workspaces(id) ON DELETE CASCADE;
+ALTER TABLE tags ADD COLUMN workspace_id BIGINT REFERENCES workspaces(id) ON DELETE CASCADE;
+
+CREATE INDEX notes_workspace_idx ON notes (workspace_id) WHERE workspace_id IS NOT NULL;
+
+-- пути личных тегов уникальны у пользователя, тегов пространства - в пространстве
+ALTER TABLE tags DROP CONSTRAINT tags_user_id_path_key;
+CREATE UNIQUE INDEX tags_user_path_key ON tags (user_id, path) WHERE workspace_id IS NULL;
+CREATE UNIQUE INDEX tags_workspace_path_key ON tags (workspace_id, path) WHERE workspace_id IS NOT NULL;
@@ -0,0 +1 @@
+ALTER TABLE IF EXISTS attachments DROP COLUMN IF EXISTS thumb_attempts;
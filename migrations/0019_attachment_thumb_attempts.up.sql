@@ -0,0 +1,2 @@
+-- Сколько раз воркер не смог сделать превью; после нескольких попыток вложение помечается failed
+ALTER TABLE attachments ADD COLUMN thumb_attempts INT NOT NULL DEFAULT 0;
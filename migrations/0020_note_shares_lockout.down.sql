@@ -0,0 +1,2 @@
+ALTER TABLE IF EXISTS note_shares DROP COLUMN IF EXISTS locked_until;
+ALTER TABLE IF EXISTS note_shares DROP COLUMN IF EXISTS failed_attempts;
@@ -0,0 +1,4 @@
+-- Неудачные попытки ввести пароль ссылки; после нескольких подряд ссылка блокируется до locked_until
+ALTER TABLE note_shares
+    ADD COLUMN failed_attempts INT NOT NULL DEFAULT 0,
+    ADD COLUMN locked_until    TIMESTAMPTZ;